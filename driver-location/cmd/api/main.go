@@ -12,17 +12,29 @@ import (
 
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	files "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/docs"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/handler"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/mapmatch"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/middleware"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/smoothing"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/spoofing"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/idempotency"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/requestid"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/tracing"
 )
 
 // @securityDefinitions.apiKey ApiKeyAuth
@@ -44,11 +56,30 @@ func main() {
 		_ = logger.Sync()
 	}()
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:  config.ServiceName,
+		Environment:  cfg.Environment,
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to shut down tracing", zap.Error(err))
+		}
+	}()
+
 	// Initialize MongoDB connection
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	opts := options.Client().ApplyURI(cfg.MongoURI)
+	opts := options.Client().ApplyURI(cfg.MongoURI).SetMonitor(tracing.NewMongoMonitor())
 	mongoClient, err := mongo.Connect(opts)
 	if err != nil {
 		logger.Fatal("failed to connect to MongoDB", zap.Error(err))
@@ -66,17 +97,143 @@ func main() {
 		logger.Fatal("failed to initialize repository", zap.Error(err))
 	}
 
-	// Initialize service
-	srv := service.NewService(repo, logger)
+	// Initialize the API key store
+	var keyRepo repository.APIKeyRepository
+	switch cfg.ApiKeyStore {
+	case config.APIKeyStoreMongo:
+		keyRepo, err = repository.NewAPIKeyRepository(ctx, mongoClient.Database(cfg.MongoDBName).Collection(cfg.ApiKeyCollectionName))
+	case config.APIKeyStoreFile:
+		keyRepo, err = repository.NewFileAPIKeyRepository(cfg.ApiKeyFile)
+	default:
+		logger.Fatal("unsupported API key store", zap.String("store", cfg.ApiKeyStore))
+	}
+	if err != nil {
+		logger.Fatal("failed to initialize API key store", zap.Error(err))
+	}
+
+	// Initialize the audit log
+	auditRepo, err := repository.NewAuditRepository(ctx, mongoClient.Database(cfg.MongoDBName).Collection(cfg.AuditCollectionName))
+	if err != nil {
+		logger.Fatal("failed to initialize audit log", zap.Error(err))
+	}
+
+	// Initialize the pickup point store
+	pickupPointRepo, err := repository.NewPickupPointRepository(ctx, mongoClient.Database(cfg.MongoDBName).Collection(cfg.PickupPointCollection))
+	if err != nil {
+		logger.Fatal("failed to initialize pickup point store", zap.Error(err))
+	}
+
+	// Initialize the idempotency store
+	idempotencyStore, err := idempotency.NewMongoStore(ctx, mongoClient.Database(cfg.MongoDBName).Collection(cfg.IdempotencyCollection), cfg.IdempotencyTTL, metrics.ObserveMongoOperation)
+	if err != nil {
+		logger.Fatal("failed to initialize idempotency store", zap.Error(err))
+	}
+
+	// Initialize rate limiting
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case config.RateLimitStoreMemory:
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case config.RateLimitStoreRedis:
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logger.Fatal("invalid Redis URL", zap.Error(err))
+		}
+		redisClient := redis.NewClient(redisOpts)
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error("failed to close Redis client", zap.Error(err))
+			}
+		}()
+		rateLimitStore = ratelimit.NewRedisStore(redisClient, config.ServiceName+":ratelimit:")
+	default:
+		logger.Fatal("unsupported rate limit store", zap.String("store", cfg.RateLimitStore))
+	}
+	rateLimits, err := ratelimit.ParseLimits(cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	if err != nil {
+		logger.Fatal("invalid rate limits", zap.Error(err))
+	}
+
+	switch cfg.SpoofingMode {
+	case config.SpoofingModeOff, config.SpoofingModeFlag, config.SpoofingModeReject:
+	default:
+		logger.Fatal("unsupported spoofing mode", zap.String("mode", cfg.SpoofingMode))
+	}
+	detector := spoofing.NewDetector(spoofing.Config{
+		Mode:            cfg.SpoofingMode,
+		MaxSpeed:        cfg.SpoofMaxSpeedKMH / 3.6,
+		MaxJumpDistance: cfg.SpoofMaxJumpDistance,
+		JumpWindow:      cfg.SpoofJumpWindow,
+		StaticDuration:  cfg.SpoofStaticDuration,
+	})
+
+	var smoother *smoothing.Filter
+	if cfg.SmoothingEnabled {
+		smoother = smoothing.NewFilter(smoothing.Config{
+			ProcessNoise:     cfg.SmoothingProcessNoise,
+			MeasurementNoise: cfg.SmoothingMeasurementNoise,
+			ResetAfter:       cfg.SmoothingResetAfter,
+		})
+
+		// Locations stored while smoothing was disabled have no smoothed
+		// location, so smoothed searches would miss them.
+		backfillCtx, cancelBackfill := context.WithTimeout(context.Background(), config.BackfillTimeout)
+		backfilled, err := repo.BackfillSmoothedLocations(backfillCtx)
+		cancelBackfill()
+		if err != nil {
+			logger.Error("failed to backfill smoothed locations", zap.Error(err))
+		} else if backfilled > 0 {
+			logger.Info("backfilled smoothed locations", zap.Int64("locations", backfilled))
+		}
+	}
+
+	var matcher *mapmatch.Matcher
+	if cfg.RoadNetworkFile != "" {
+		network, err := mapmatch.LoadNetwork(cfg.RoadNetworkFile)
+		if err != nil {
+			logger.Fatal("failed to load road network", zap.Error(err))
+		}
+		logger.Info("loaded road network", zap.Int("segments", network.Segments()))
+		matcher = mapmatch.NewMatcher(network, cfg.RoadSnapDistance)
+
+		// Locations stored while map matching was disabled have no snapped
+		// location, so snapped searches would miss them.
+		backfillCtx, cancelBackfill := context.WithTimeout(context.Background(), config.BackfillTimeout)
+		backfilled, err := repo.BackfillSnappedLocations(backfillCtx, matcher.Match)
+		cancelBackfill()
+		if err != nil {
+			logger.Error("failed to backfill snapped locations", zap.Error(err))
+		} else if backfilled > 0 {
+			logger.Info("backfilled snapped locations", zap.Int64("locations", backfilled))
+		}
+	}
+
+	// Initialize services
+	srv := service.NewService(repo, detector, smoother, matcher, cfg.SpoofFlagTTL, logger)
+	keyService := service.NewAPIKeyService(keyRepo, cfg.ApiKey, cfg.ApiKeyCacheTTL, logger)
+	auditService := service.NewAuditService(auditRepo, logger)
+	pickupPointService := service.NewPickupPointService(pickupPointRepo, logger)
 
 	// Create handlers
-	locationHandler := handler.NewLocationHandler(srv, logger)
+	locationHandler := handler.NewLocationHandler(srv, auditService, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(keyService, auditService, cfg.ApiKeyRotationOverlap, logger)
+	auditHandler := handler.NewAuditHandler(auditService, logger)
+	pickupPointHandler := handler.NewPickupPointHandler(pickupPointService, auditService, logger)
 	healthHandler := handler.NewHealthHandler(srv)
 
 	// Create a gin router and attach middlewares
 	router := gin.New()
+	// Only the configured proxies may set the client IP through
+	// X-Forwarded-For; otherwise callers could pick their own rate limit bucket.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("invalid trusted proxies", zap.Error(err))
+	}
+	router.Use(otelgin.Middleware(config.ServiceName))
+	router.Use(requestid.Middleware(logger))
 	router.Use(middleware.LoggerMiddleware(logger))
-	router.Use(gin.Recovery())
+	router.Use(middleware.MetricsMiddleware())
+	router.Use(gin.CustomRecovery(apierror.Recover))
+	router.NoRoute(apierror.NotFound)
 
 	if cfg.SwaggerEnabled {
 		// Serve Swagger documentation
@@ -87,11 +244,19 @@ func main() {
 	// Register public routes
 	root := router.Group("/")
 	healthHandler.RegisterRoutes(root)
+	root.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
 	// Register private routes
 	v1 := router.Group("/api/v1")
-	v1.Use(middleware.AuthMiddleware(*cfg))
+	v1.Use(middleware.AuthMiddleware(keyService, logger))
+	if cfg.RateLimitEnabled {
+		v1.Use(middleware.RateLimitMiddleware(rateLimitStore, rateLimits, logger))
+	}
+	v1.Use(middleware.IdempotencyMiddleware(idempotencyStore, config.IdempotentRoutes, int64(cfg.IdempotencyMaxBodyBytes), logger))
 	locationHandler.RegisterRoutes(v1)
+	apiKeyHandler.RegisterRoutes(v1)
+	auditHandler.RegisterRoutes(v1)
+	pickupPointHandler.RegisterRoutes(v1)
 
 	// Create http server
 	httpServer := &http.Server{
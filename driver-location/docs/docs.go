@@ -15,14 +15,61 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/locations": {
+        "/api/v1/admin/keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every API key, including expired and revoked ones. Secrets are never returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List API keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.APIKeyListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a new driver location",
+                "description": "Issues a new API key with the given scopes. The key is only returned in this response.",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,25 +77,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "locations"
+                    "admin"
                 ],
-                "summary": "Create a new driver location",
+                "summary": "Issue an API key",
                 "parameters": [
                     {
-                        "description": "Create location request",
+                        "description": "Create API key request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateLocationRequest"
+                            "$ref": "#/definitions/dto.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateLocationResponse"
+                            "$ref": "#/definitions/dto.IssuedAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -63,6 +110,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -72,14 +131,78 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/locations/batch": {
+        "/api/v1/admin/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revokes an API key immediately",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Revoke an API key",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.APIKeyResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/keys/{id}/rotate": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates multiple driver locations at once",
+                "description": "Issues a replacement key with the same name, scopes and lifetime. The old key keeps working for the overlap period, or until it expires if that is sooner.",
                 "consumes": [
                     "application/json"
                 ],
@@ -87,25 +210,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "locations"
+                    "admin"
                 ],
-                "summary": "Create driver locations in bulk",
+                "summary": "Rotate an API key",
                 "parameters": [
                     {
-                        "description": "Create bulk location request",
+                        "type": "string",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rotate API key request",
                         "name": "request",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateLocationBulkRequest"
+                            "$ref": "#/definitions/dto.RotateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateLocationBulkResponse"
+                            "$ref": "#/definitions/dto.IssuedAPIKeyResponse"
                         }
                     },
                     "400": {
@@ -120,6 +249,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -129,40 +282,87 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/locations/import": {
-            "post": {
+        "/api/v1/audit": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Imports driver locations from a CSV file.",
-                "consumes": [
-                    "text/csv"
-                ],
+                "description": "Lists bulk location writes, API key administration and pickup point changes, newest first. Every attempt is listed with its outcome, including failed ones. With format=csv the matching entries, up to 10000, are exported as a CSV file; when more entries match, the export holds the newest 10000 and carries an X-Audit-Truncated: true header, and older entries can be exported by narrowing the time range with to.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv"
                 ],
                 "tags": [
-                    "locations"
+                    "admin"
                 ],
-                "summary": "Import driver locations from CSV",
+                "summary": "List audit entries",
                 "parameters": [
                     {
-                        "description": "CSV data",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "API key ID that performed the operation",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "locations.batch_create",
+                            "locations.import",
+                            "keys.issue",
+                            "keys.rotate",
+                            "keys.revoke",
+                            "pickup_points.create",
+                            "pickup_points.update",
+                            "pickup_points.delete"
+                        ],
+                        "type": "string",
+                        "description": "Operation",
+                        "name": "operation",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Earliest timestamp, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Latest timestamp, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Maximum number of entries, default 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.ImportLocationCSVResponse"
+                            "$ref": "#/definitions/dto.AuditListResponse"
+                        },
+                        "headers": {
+                            "X-Audit-Truncated": {
+                                "type": "string",
+                                "description": "true when a CSV export was cut short"
+                            }
                         }
                     },
                     "400": {
@@ -177,6 +377,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -186,14 +398,63 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/locations/search": {
+        "/api/v1/drivers/flagged": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the drivers with spoofing flags raised within SPOOF_FLAG_TTL, most recently flagged first, up to 1000.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "List flagged drivers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.FlaggedDriversResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Searches for driver locations based on a GeoJSON point and radius",
+                "description": "Creates a new driver location. With a driver_id, the location of the driver is updated in place and checked against the previous one; implausible updates are flagged, or rejected with implausible_location when SPOOFING_MODE is reject.",
                 "consumes": [
                     "application/json"
                 ],
@@ -203,23 +464,29 @@ const docTemplate = `{
                 "tags": [
                     "locations"
                 ],
-                "summary": "Search for driver locations",
+                "summary": "Create a new driver location",
                 "parameters": [
                     {
-                        "description": "Search location request",
+                        "description": "Create location request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.SearchLocationRequest"
+                            "$ref": "#/definitions/dto.CreateLocationRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.SearchLocationResponse"
+                            "$ref": "#/definitions/dto.CreateLocationResponse"
                         }
                     },
                     "400": {
@@ -234,8 +501,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -249,28 +540,924 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Check if the service is healthy",
+        "/api/v1/locations/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates multiple driver locations at once",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "locations"
                 ],
-                "summary": "Check if the service is healthy",
-                "responses": {
-                    "200": {
+                "summary": "Create driver locations in bulk",
+                "parameters": [
+                    {
+                        "description": "Create bulk location request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateLocationBulkRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateLocationBulkResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations/count": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Counts the driver locations a search with the same request would find. Unlike a search, the count is not limited to 100 drivers.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Count driver locations",
+                "parameters": [
+                    {
+                        "description": "Search location request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CountLocationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations/import": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Imports driver locations from a CSV file. After a header row, each row holds latitude and longitude, optionally followed by heading, speed and accuracy; empty cells are left unset.",
+                "consumes": [
+                    "text/csv"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Import driver locations from CSV",
+                "parameters": [
+                    {
+                        "description": "CSV data",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ImportLocationCSVResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/locations/search": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Searches for driver locations based on a GeoJSON point and radius. With position set to smoothed or snapped, drivers are searched by their smoothed location or their location on the road network.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "locations"
+                ],
+                "summary": "Search for driver locations",
+                "parameters": [
+                    {
+                        "description": "Search location request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchLocationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchLocationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pickup-points": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the curated pickup points ordered by name, up to 1000.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pickup-points"
+                ],
+                "summary": "List pickup points",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only list the points of this zone",
+                        "name": "zone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds a curated pickup point that matching can move riders to.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pickup-points"
+                ],
+                "summary": "Create a pickup point",
+                "parameters": [
+                    {
+                        "description": "Pickup point",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pickup-points/search": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the pickup points within radius meters of a GeoJSON point, nearest first, up to 20. An empty list means there is no pickup point nearby.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pickup-points"
+                ],
+                "summary": "Search pickup points",
+                "parameters": [
+                    {
+                        "description": "Search pickup points request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchPickupPointsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SearchPickupPointsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pickup-points/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replaces the name, zone and location of a pickup point.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pickup-points"
+                ],
+                "summary": "Update a pickup point",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pickup point ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pickup point",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Deletes a pickup point",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pickup-points"
+                ],
+                "summary": "Delete a pickup point",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Pickup point ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PickupPointResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/live": {
+            "get": {
+                "description": "Reports that the process is up, without checking its dependencies",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Check if the service is alive",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthCheckResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
+            "get": {
+                "description": "Checks MongoDB and the geospatial index, and reports the status and latency of each",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Check if the service is ready",
+                "responses": {
+                    "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/dto.HealthCheckResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthCheckResponse"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "location.coordinates"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must have exactly 2 elements"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "len"
+                }
+            }
+        },
+        "dto.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "matching"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "dlk_Q2x1c3Rl"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "locations:read"
+                    ]
+                }
+            }
+        },
+        "dto.APIKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.APIKey"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.APIKey"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "id": {
+                    "type": "string",
+                    "example": "6650f1c2e4b0a1b2c3d4e5f6"
+                },
+                "operation": {
+                    "type": "string",
+                    "example": "locations.import"
+                },
+                "outcome": {
+                    "type": "string",
+                    "enum": [
+                        "succeeded",
+                        "failed"
+                    ],
+                    "example": "succeeded"
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"
+                },
+                "source_ip": {
+                    "type": "string",
+                    "example": "10.0.0.12"
+                },
+                "successful": {
+                    "type": "integer",
+                    "example": 998
+                },
+                "target": {
+                    "type": "string",
+                    "example": "0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 1000
+                }
+            }
+        },
+        "dto.AuditListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditEntry"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.CountLocationData": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 250
+                }
+            }
+        },
+        "dto.CountLocationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.CountLocationData"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "matching"
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "locations:read"
+                    ]
+                }
+            }
+        },
         "dto.CreateLocationBulkData": {
             "type": "object",
             "properties": {
@@ -285,6 +1472,54 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.CreateLocationBulkItem": {
+            "type": "object",
+            "properties": {
+                "acceptance_rate": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.9
+                },
+                "accuracy": {
+                    "type": "number",
+                    "maximum": 10000,
+                    "example": 8
+                },
+                "heading": {
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 90
+                },
+                "idle_since": {
+                    "type": "string",
+                    "example": "2026-01-01T12:00:00Z"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 41.0082
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 28.9784
+                },
+                "rating": {
+                    "type": "number",
+                    "maximum": 5,
+                    "minimum": 0,
+                    "example": 4.8
+                },
+                "speed": {
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 12.5
+                },
+                "vehicle": {
+                    "$ref": "#/definitions/dto.Vehicle"
+                }
+            }
+        },
         "dto.CreateLocationBulkRequest": {
             "type": "object",
             "required": [
@@ -296,7 +1531,7 @@ const docTemplate = `{
                     "maxItems": 1000,
                     "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/dto.CreateLocationRequest"
+                        "$ref": "#/definitions/dto.CreateLocationBulkItem"
                     }
                 }
             }
@@ -312,43 +1547,178 @@ const docTemplate = `{
                 }
             }
         },
-        "dto.CreateLocationData": {
+        "dto.CreateLocationData": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateLocationRequest": {
+            "type": "object",
+            "properties": {
+                "acceptance_rate": {
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.9
+                },
+                "accuracy": {
+                    "type": "number",
+                    "maximum": 10000,
+                    "example": 8
+                },
+                "driver_id": {
+                    "description": "DriverID updates the location of the driver in place and checks it\nagainst the previous one for spoofing.",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "driver-42"
+                },
+                "heading": {
+                    "description": "Heading is the direction of travel in degrees clockwise from north,\nSpeed is in metres per second and Accuracy is the horizontal accuracy\nof the fix in metres.",
+                    "type": "number",
+                    "minimum": 0,
+                    "example": 90
+                },
+                "idle_since": {
+                    "type": "string",
+                    "example": "2026-01-01T12:00:00Z"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 41.0082
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": 28.9784
+                },
+                "rating": {
+                    "description": "Rating is the driver's rating out of 5, AcceptanceRate the share of\noffered trips the driver accepted and IdleSince when the driver last\nbecame available for a trip.",
+                    "type": "number",
+                    "maximum": 5,
+                    "minimum": 0,
+                    "example": 4.8
+                },
+                "speed": {
+                    "type": "number",
+                    "maximum": 100,
+                    "minimum": 0,
+                    "example": 12.5
+                },
+                "vehicle": {
+                    "$ref": "#/definitions/dto.Vehicle"
+                }
+            }
+        },
+        "dto.CreateLocationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.CreateLocationData"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number",
+                    "example": 1.42
+                },
+                "name": {
+                    "type": "string",
+                    "example": "mongodb"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "dto.DriverFlag": {
             "type": "object",
             "properties": {
-                "message": {
+                "count": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "moved 12000 m in 30s"
+                },
+                "detected_at": {
                     "type": "string"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "teleport"
                 }
             }
         },
-        "dto.CreateLocationRequest": {
+        "dto.ErrorResponse": {
             "type": "object",
             "properties": {
-                "latitude": {
-                    "type": "number",
-                    "example": 41.0082
+                "code": {
+                    "type": "string",
+                    "example": "validation_failed"
                 },
-                "longitude": {
-                    "type": "number",
-                    "example": 28.9784
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierror.FieldError"
+                    }
+                },
+                "error": {
+                    "type": "string",
+                    "example": "The request contains invalid fields."
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"
+                },
+                "success": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.CreateLocationResponse": {
+        "dto.FlaggedDriver": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/dto.CreateLocationData"
+                "flagged_at": {
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean"
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DriverFlag"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "dto.ErrorResponse": {
+        "dto.FlaggedDriversResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.FlaggedDriver"
+                    }
                 },
                 "success": {
                     "type": "boolean"
@@ -381,6 +1751,12 @@ const docTemplate = `{
         "dto.HealthCheckResponse": {
             "type": "object",
             "properties": {
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DependencyStatus"
+                    }
+                },
                 "status": {
                     "type": "string",
                     "example": "ok"
@@ -412,6 +1788,170 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.IssuedAPIKeyData": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "dlk_Q2x1c3RlcktleUV4YW1wbGVPbmx5Tm90UmVhbA"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "matching"
+                },
+                "prefix": {
+                    "type": "string",
+                    "example": "dlk_Q2x1c3Rl"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "locations:read"
+                    ]
+                }
+            }
+        },
+        "dto.IssuedAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.IssuedAPIKeyData"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.NearbyPickupPoint": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "distance": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5d3c1a9e-2b7f-4c61-9d0e-8a4f2b6c1e37"
+                },
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Terminal 1 Gate 4"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string",
+                    "example": "airport"
+                }
+            }
+        },
+        "dto.PickupPoint": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5d3c1a9e-2b7f-4c61-9d0e-8a4f2b6c1e37"
+                },
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Terminal 1 Gate 4"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "zone": {
+                    "type": "string",
+                    "example": "airport"
+                }
+            }
+        },
+        "dto.PickupPointListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PickupPoint"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.PickupPointRequest": {
+            "type": "object",
+            "required": [
+                "location",
+                "name"
+            ],
+            "properties": {
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Terminal 1 Gate 4"
+                },
+                "zone": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "airport"
+                }
+            }
+        },
+        "dto.PickupPointResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.PickupPoint"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.RotateAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "overlap_seconds": {
+                    "type": "integer",
+                    "maximum": 2592000,
+                    "minimum": 0,
+                    "example": 86400
+                }
+            }
+        },
         "dto.SearchLocationData": {
             "type": "object",
             "properties": {
@@ -436,10 +1976,29 @@ const docTemplate = `{
                 "location": {
                     "$ref": "#/definitions/dto.GeoJSONPoint"
                 },
+                "max_accuracy": {
+                    "description": "MaxAccuracy excludes locations whose reported accuracy is worse than\nthis many meters. Locations without a reported accuracy are kept.",
+                    "type": "number",
+                    "maximum": 10000,
+                    "example": 50
+                },
+                "position": {
+                    "description": "Position selects whether drivers are searched by their raw, smoothed or\nsnapped location. Smoothed locations require SMOOTHING_ENABLED and\nsnapped locations ROAD_NETWORK_FILE.",
+                    "type": "string",
+                    "enum": [
+                        "raw",
+                        "smoothed",
+                        "snapped"
+                    ],
+                    "example": "raw"
+                },
                 "radius": {
                     "type": "number",
                     "maximum": 10000,
                     "minimum": 10
+                },
+                "requirements": {
+                    "$ref": "#/definitions/dto.VehicleRequirements"
                 }
             }
         },
@@ -454,17 +2013,174 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.SearchPickupPointsRequest": {
+            "type": "object",
+            "required": [
+                "location",
+                "radius"
+            ],
+            "properties": {
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "radius": {
+                    "type": "number",
+                    "maximum": 1000,
+                    "minimum": 1,
+                    "example": 150
+                },
+                "zone": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "airport"
+                }
+            }
+        },
+        "dto.SearchPickupPointsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.NearbyPickupPoint"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
         "dto.SearchResultLocation": {
             "type": "object",
             "properties": {
+                "acceptance_rate": {
+                    "type": "number",
+                    "example": 0.9
+                },
+                "accuracy": {
+                    "type": "number",
+                    "example": 8
+                },
                 "distance": {
                     "type": "number"
                 },
+                "flags": {
+                    "description": "Flags lists the spoofing flags currently raised against the driver.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "teleport"
+                    ]
+                },
+                "heading": {
+                    "type": "number",
+                    "example": 90
+                },
                 "id": {
                     "type": "string"
                 },
+                "idle_seconds": {
+                    "description": "IdleSeconds is how long ago the driver became available for a trip.",
+                    "type": "number",
+                    "example": 420
+                },
                 "location": {
                     "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "rating": {
+                    "type": "number",
+                    "example": 4.8
+                },
+                "road_segment_id": {
+                    "description": "RoadSegmentID identifies the road segment the driver was snapped to,\nas from_id:to_id of the road network.",
+                    "type": "string",
+                    "example": "1001:1002"
+                },
+                "smoothed_location": {
+                    "description": "SmoothedLocation is the location with GPS jitter filtered out. Distance\nis measured from it when smoothed locations are searched.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.GeoJSONPoint"
+                        }
+                    ]
+                },
+                "snapped_location": {
+                    "description": "SnappedLocation is the location moved onto the nearest road. Distance\nis measured from it when snapped locations are searched.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.GeoJSONPoint"
+                        }
+                    ]
+                },
+                "speed": {
+                    "type": "number",
+                    "example": 12.5
+                },
+                "vehicle": {
+                    "$ref": "#/definitions/dto.Vehicle"
+                }
+            }
+        },
+        "dto.Vehicle": {
+            "type": "object",
+            "required": [
+                "capacity",
+                "type"
+            ],
+            "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "maximum": 8,
+                    "minimum": 1,
+                    "example": 4
+                },
+                "pet_friendly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "taxi",
+                        "comfort",
+                        "xl"
+                    ],
+                    "example": "taxi"
+                },
+                "wheelchair_accessible": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "dto.VehicleRequirements": {
+            "type": "object",
+            "properties": {
+                "min_capacity": {
+                    "type": "integer",
+                    "maximum": 8,
+                    "minimum": 1,
+                    "example": 4
+                },
+                "pet_friendly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "vehicle_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "taxi",
+                        "xl"
+                    ]
+                },
+                "wheelchair_accessible": {
+                    "type": "boolean",
+                    "example": false
                 }
             }
         }
@@ -0,0 +1,45 @@
+// Package apierror is the catalogue of errors returned by the driver location
+// API. The Error type, the response format and the errors shared by both
+// services come from the shared apierror package and are re-exported here,
+// so handlers only need this package.
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+)
+
+// Error is an entry of the catalogue.
+type Error = apierror.Error
+
+var (
+	Respond        = apierror.Respond
+	RespondBinding = apierror.RespondBinding
+	NotFound       = apierror.NotFound
+	Recover        = apierror.Recover
+)
+
+var (
+	ErrMalformedRequest         = apierror.ErrMalformedRequest
+	ErrValidationFailed         = apierror.ErrValidationFailed
+	ErrNotFound                 = apierror.ErrNotFound
+	ErrRateLimited              = apierror.ErrRateLimited
+	ErrInvalidIdempotencyKey    = apierror.ErrInvalidIdempotencyKey
+	ErrIdempotencyKeyReused     = apierror.ErrIdempotencyKeyReused
+	ErrIdempotencyKeyInProgress = apierror.ErrIdempotencyKeyInProgress
+	ErrInternal                 = apierror.ErrInternal
+)
+
+var (
+	ErrInvalidCSV          = Error{Code: "invalid_csv", Status: http.StatusBadRequest, Message: config.ErrInvalidCSV}
+	ErrUnauthorized        = Error{Code: "unauthorized", Status: http.StatusUnauthorized, Message: config.ErrUnauthorized}
+	ErrForbidden           = Error{Code: "insufficient_scope", Status: http.StatusForbidden, Message: config.ErrForbidden}
+	ErrAPIKeyNotFound      = Error{Code: "api_key_not_found", Status: http.StatusNotFound, Message: config.ErrAPIKeyNotFound}
+	ErrAPIKeyInactive      = Error{Code: "api_key_inactive", Status: http.StatusConflict, Message: config.ErrAPIKeyInactive}
+	ErrPickupPointNotFound = Error{Code: "pickup_point_not_found", Status: http.StatusNotFound, Message: config.ErrPickupPointNotFound}
+	ErrNoDriversFound      = Error{Code: "no_drivers_found", Status: http.StatusNotFound, Message: config.ErrNoDriversFound}
+	ErrImplausibleLocation = Error{Code: "implausible_location", Status: http.StatusUnprocessableEntity, Message: config.ErrImplausibleLocation}
+)
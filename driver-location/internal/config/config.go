@@ -2,18 +2,64 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/tracing"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	ApiKey              string
-	Environment         string
-	SwaggerEnabled      bool
-	MongoURI            string
-	MongoDBName         string
-	MongoCollectionName string
+	// ApiKey is an optional bootstrap key granted every scope, used to issue
+	// the first stored keys.
+	ApiKey                string
+	ApiKeyStore           string
+	ApiKeyFile            string
+	ApiKeyCollectionName  string
+	ApiKeyCacheTTL        time.Duration
+	ApiKeyRotationOverlap time.Duration
+	Environment           string
+	SwaggerEnabled        bool
+	MongoURI              string
+	MongoDBName           string
+	MongoCollectionName   string
+	TracingExporter       string
+	OTLPEndpoint          string
+	TracingSampleRatio    float64
+	RateLimitEnabled      bool
+	RateLimitStore        string
+	RateLimitDefault      string
+	RateLimitRoutes       string
+	RedisURL              string
+	// TrustedProxies lists the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers are believed. When it is empty
+	// the client IP is the address of the connection.
+	TrustedProxies          []string
+	IdempotencyCollection   string
+	AuditCollectionName     string
+	PickupPointCollection   string
+	IdempotencyTTL          time.Duration
+	IdempotencyMaxBodyBytes int
+	SpoofingMode            string
+	SpoofMaxSpeedKMH        float64
+	SpoofMaxJumpDistance    float64
+	SpoofJumpWindow         time.Duration
+	SpoofStaticDuration     time.Duration
+	SpoofFlagTTL            time.Duration
+	SmoothingEnabled        bool
+	// SmoothingProcessNoise is in metres per second squared and
+	// SmoothingMeasurementNoise in metres, both as standard deviations.
+	SmoothingProcessNoise     float64
+	SmoothingMeasurementNoise float64
+	SmoothingResetAfter       time.Duration
+	// RoadNetworkFile is the road network that locations are snapped to.
+	// Map matching is disabled when it is empty.
+	RoadNetworkFile string
+	// RoadSnapDistance is in metres.
+	RoadSnapDistance float64
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -30,13 +76,114 @@ func LoadConfig() (*Config, error) {
 		return defaultValue
 	}
 
+	tracingSampleRatio, err := parseFloat(getEnv("TRACING_SAMPLE_RATIO", "1.0"), "TRACING_SAMPLE_RATIO")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyCacheTTL, err := parseDuration(getEnv("API_KEY_CACHE_TTL", "30s"), "API_KEY_CACHE_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyRotationOverlap, err := parseDuration(getEnv("API_KEY_ROTATION_OVERLAP", "24h"), "API_KEY_ROTATION_OVERLAP")
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyTTL, err := parseDuration(getEnv("IDEMPOTENCY_TTL", "24h"), "IDEMPOTENCY_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyMaxBodyBytes, err := parsePositiveInt(getEnv("IDEMPOTENCY_MAX_BODY_BYTES", "10485760"), "IDEMPOTENCY_MAX_BODY_BYTES")
+	if err != nil {
+		return nil, err
+	}
+
+	spoofMaxSpeedKMH, err := parseFloat(getEnv("SPOOF_MAX_SPEED_KMH", "200"), "SPOOF_MAX_SPEED_KMH")
+	if err != nil {
+		return nil, err
+	}
+
+	spoofMaxJumpDistance, err := parseFloat(getEnv("SPOOF_MAX_JUMP_DISTANCE", "10000"), "SPOOF_MAX_JUMP_DISTANCE")
+	if err != nil {
+		return nil, err
+	}
+
+	spoofJumpWindow, err := parseDuration(getEnv("SPOOF_JUMP_WINDOW", "5m"), "SPOOF_JUMP_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	spoofStaticDuration, err := parseDuration(getEnv("SPOOF_STATIC_DURATION", "1h"), "SPOOF_STATIC_DURATION")
+	if err != nil {
+		return nil, err
+	}
+
+	spoofFlagTTL, err := parseDuration(getEnv("SPOOF_FLAG_TTL", "24h"), "SPOOF_FLAG_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	smoothingProcessNoise, err := parsePositiveFloat(getEnv("SMOOTHING_PROCESS_NOISE", "0.5"), "SMOOTHING_PROCESS_NOISE")
+	if err != nil {
+		return nil, err
+	}
+
+	smoothingMeasurementNoise, err := parsePositiveFloat(getEnv("SMOOTHING_MEASUREMENT_NOISE", "15"), "SMOOTHING_MEASUREMENT_NOISE")
+	if err != nil {
+		return nil, err
+	}
+
+	smoothingResetAfter, err := parsePositiveDuration(getEnv("SMOOTHING_RESET_AFTER", "2m"), "SMOOTHING_RESET_AFTER")
+	if err != nil {
+		return nil, err
+	}
+
+	roadSnapDistance, err := parseFloatRange(getEnv("ROAD_SNAP_DISTANCE", "50"), "ROAD_SNAP_DISTANCE", 1, MaxRoadSnapDistance)
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
-		ApiKey:              getEnv("X_API_KEY", ""),
-		Environment:         getEnv("ENVIRONMENT", "development"),
-		SwaggerEnabled:      parseBool(getEnv("SWAGGER_ENABLED", "true")),
-		MongoURI:            getEnv("MONGO_URI", ""),
-		MongoDBName:         getEnv("MONGO_DB_NAME", ""),
-		MongoCollectionName: getEnv("MONGO_COLLECTION_NAME", ""),
+		ApiKey:                    os.Getenv("X_API_KEY"),
+		ApiKeyStore:               getEnv("API_KEY_STORE", APIKeyStoreMongo),
+		ApiKeyFile:                getEnv("API_KEY_FILE", "api_keys.json"),
+		ApiKeyCollectionName:      getEnv("API_KEY_COLLECTION_NAME", "api_keys"),
+		ApiKeyCacheTTL:            apiKeyCacheTTL,
+		ApiKeyRotationOverlap:     apiKeyRotationOverlap,
+		Environment:               getEnv("ENVIRONMENT", "development"),
+		SwaggerEnabled:            parseBool(getEnv("SWAGGER_ENABLED", "true")),
+		MongoURI:                  getEnv("MONGO_URI", ""),
+		MongoDBName:               getEnv("MONGO_DB_NAME", ""),
+		MongoCollectionName:       getEnv("MONGO_COLLECTION_NAME", ""),
+		TracingExporter:           getEnv("TRACING_EXPORTER", tracing.ExporterNone),
+		OTLPEndpoint:              getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		TracingSampleRatio:        tracingSampleRatio,
+		RateLimitEnabled:          parseBool(getEnv("RATE_LIMIT_ENABLED", "true")),
+		RateLimitStore:            getEnv("RATE_LIMIT_STORE", RateLimitStoreMemory),
+		RateLimitDefault:          getEnv("RATE_LIMIT_DEFAULT", "20/s:40"),
+		RateLimitRoutes:           os.Getenv("RATE_LIMIT_ROUTES"),
+		RedisURL:                  getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		TrustedProxies:            parseList(os.Getenv("TRUSTED_PROXIES")),
+		IdempotencyCollection:     getEnv("IDEMPOTENCY_COLLECTION_NAME", "idempotency_keys"),
+		AuditCollectionName:       getEnv("AUDIT_COLLECTION_NAME", "audit_log"),
+		PickupPointCollection:     getEnv("PICKUP_POINT_COLLECTION_NAME", "pickup_points"),
+		SpoofingMode:              getEnv("SPOOFING_MODE", SpoofingModeFlag),
+		SpoofMaxSpeedKMH:          spoofMaxSpeedKMH,
+		SpoofMaxJumpDistance:      spoofMaxJumpDistance,
+		SpoofJumpWindow:           spoofJumpWindow,
+		SpoofStaticDuration:       spoofStaticDuration,
+		SpoofFlagTTL:              spoofFlagTTL,
+		SmoothingEnabled:          parseBool(getEnv("SMOOTHING_ENABLED", "false")),
+		SmoothingProcessNoise:     smoothingProcessNoise,
+		SmoothingMeasurementNoise: smoothingMeasurementNoise,
+		SmoothingResetAfter:       smoothingResetAfter,
+		RoadNetworkFile:           os.Getenv("ROAD_NETWORK_FILE"),
+		RoadSnapDistance:          roadSnapDistance,
+		IdempotencyTTL:            idempotencyTTL,
+		IdempotencyMaxBodyBytes:   idempotencyMaxBodyBytes,
 	}
 
 	if len(missing) > 0 {
@@ -49,3 +196,88 @@ func LoadConfig() (*Config, error) {
 func parseBool(s string) bool {
 	return strings.EqualFold(s, "true")
 }
+
+// parseList splits a comma-separated list, dropping empty entries.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseInt(s, fieldName string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", fieldName, s, err)
+	}
+	return v, nil
+}
+
+// parsePositiveInt parses an integer that must be greater than zero.
+func parsePositiveInt(s, fieldName string) (int, error) {
+	v, err := parseInt(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
+
+func parseFloat(s, fieldName string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", fieldName, s, err)
+	}
+	return v, nil
+}
+
+// parsePositiveFloat parses a finite float that must be greater than zero.
+func parsePositiveFloat(s, fieldName string) (float64, error) {
+	v, err := parseFloat(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	// Written so that NaN is rejected too.
+	if !(v > 0) || math.IsInf(v, 1) {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
+
+// parseFloatRange parses a float that must lie between min and max inclusive.
+func parseFloatRange(s, fieldName string, min, max float64) (float64, error) {
+	v, err := parseFloat(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	// Written so that NaN is rejected too.
+	if !(v >= min && v <= max) {
+		return 0, fmt.Errorf("invalid %s value '%s': must be between %g and %g", fieldName, s, min, max)
+	}
+	return v, nil
+}
+
+func parseDuration(s, fieldName string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", fieldName, s, err)
+	}
+	return v, nil
+}
+
+// parsePositiveDuration parses a duration that must be greater than zero.
+func parsePositiveDuration(s, fieldName string) (time.Duration, error) {
+	v, err := parseDuration(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setRequiredEnv sets the variables LoadConfig cannot default.
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("MONGO_URI", "mongodb://localhost:27017")
+	t.Setenv("MONGO_DB_NAME", "driver_location")
+	t.Setenv("MONGO_COLLECTION_NAME", "driver_location")
+}
+
+func TestLoadConfig_Validation(t *testing.T) {
+	tests := []struct {
+		name          string
+		key           string
+		value         string
+		expectedError bool
+	}{
+		{name: "road snap distance", key: "ROAD_SNAP_DISTANCE", value: "75"},
+		{name: "road snap distance zero", key: "ROAD_SNAP_DISTANCE", value: "0", expectedError: true},
+		{name: "road snap distance negative", key: "ROAD_SNAP_DISTANCE", value: "-5", expectedError: true},
+		{name: "road snap distance too large", key: "ROAD_SNAP_DISTANCE", value: "5000", expectedError: true},
+		{name: "road snap distance infinite", key: "ROAD_SNAP_DISTANCE", value: "Inf", expectedError: true},
+		{name: "road snap distance NaN", key: "ROAD_SNAP_DISTANCE", value: "NaN", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			setRequiredEnv(t)
+			t.Setenv(tt.key, tt.value)
+
+			// Execute
+			cfg, err := LoadConfig()
+
+			// Assert
+			if tt.expectedError {
+				assert.ErrorContains(t, err, tt.key)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, cfg)
+		})
+	}
+}
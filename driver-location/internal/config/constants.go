@@ -1,11 +1,132 @@
 package config
 
+import "time"
+
+const (
+	ErrUnauthorized        = "Missing or invalid API key."
+	ErrNoDriversFound      = "No drivers found."
+	ErrInvalidCSV          = "The CSV data could not be parsed."
+	ErrForbidden           = "The API key is not allowed to perform this operation."
+	ErrAPIKeyNotFound      = "API key not found."
+	ErrAPIKeyInactive      = "The API key is expired or revoked."
+	ErrPickupPointNotFound = "Pickup point not found."
+
+	ErrImplausibleLocation = "The location is implausible given the previous location of the driver."
+)
+
 const (
-	ErrInternalServer = "An unexpected error occurred. Please try again later."
-	ErrUnauthorized   = "Unauthorized. You shall not pass!"
-	ErrNotFound       = "Not found."
+	ScopeLocationsRead   = "locations:read"
+	ScopeLocationsWrite  = "locations:write"
+	ScopeLocationsImport = "locations:import"
+	ScopeKeysAdmin       = "keys:admin"
+	ScopeAuditRead       = "audit:read"
+	// ScopePickupPointsWrite manages pickup points. Reading them only
+	// requires ScopeLocationsRead.
+	ScopePickupPointsWrite = "pickup_points:write"
+)
+
+// AllScopes lists every scope an API key can be granted.
+var AllScopes = []string{ScopeLocationsRead, ScopeLocationsWrite, ScopeLocationsImport, ScopeKeysAdmin, ScopeAuditRead, ScopePickupPointsWrite}
+
+const (
+	APIKeyStoreMongo = "mongo"
+	APIKeyStoreFile  = "file"
+	// APIKeyContextKey is the gin context key of the authenticated *models.APIKey.
+	APIKeyContextKey = "api_key"
+	// APIKeyPrefix starts every issued key so leaked keys are easy to recognise.
+	APIKeyPrefix = "dlk_"
+	// APIKeyDisplayLength is how much of a key is kept in clear to identify it.
+	APIKeyDisplayLength = 12
+	// BootstrapAPIKeyID identifies the key configured through X_API_KEY.
+	BootstrapAPIKeyID = "bootstrap"
+)
+
+const (
+	AuditOperationBatchCreate = "locations.batch_create"
+	AuditOperationImport      = "locations.import"
+	AuditOperationKeyIssue    = "keys.issue"
+	AuditOperationKeyRotate   = "keys.rotate"
+	AuditOperationKeyRevoke   = "keys.revoke"
+
+	AuditOperationPickupPointCreate = "pickup_points.create"
+	AuditOperationPickupPointUpdate = "pickup_points.update"
+	AuditOperationPickupPointDelete = "pickup_points.delete"
+
+	AuditOutcomeSucceeded = "succeeded"
+	AuditOutcomeFailed    = "failed"
+
+	AuditFormatJSON = "json"
+	AuditFormatCSV  = "csv"
+	// DefaultAuditPageSize is how many entries are listed when no limit is given.
+	DefaultAuditPageSize = 100
+	// MaxAuditExportEntries caps the entries of a CSV export. Larger exports
+	// are cut short and flagged with AuditTruncatedHeader.
+	MaxAuditExportEntries = 10000
+	AuditTruncatedHeader  = "X-Audit-Truncated"
+	AuditRecordTimeout    = 5 * time.Second
 )
 
 const (
 	MaxSearchResults = 100
+	// MaxPickupPointResults caps the pickup points returned by a search and
+	// MaxPickupPoints those listed.
+	MaxPickupPointResults = 20
+	MaxPickupPoints       = 1000
+)
+
+const (
+	ReadinessTimeout = 2 * time.Second
+	// BackfillTimeout bounds the startup backfill of smoothed and of snapped
+	// locations.
+	BackfillTimeout = time.Minute
+	// BackfillBatchSize is the number of snapped locations written at once
+	// by the startup backfill.
+	BackfillBatchSize = 1000
+)
+
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// IdempotentRoutes lists the routes that honour the Idempotency-Key header.
+var IdempotentRoutes = []string{
+	"POST /api/v1/locations",
+	"POST /api/v1/locations/batch",
+	"POST /api/v1/locations/import",
+	"POST /api/v1/pickup-points",
+}
+
+const (
+	ServiceName = "driver-location"
+)
+
+const (
+	SpoofingModeOff    = "off"
+	SpoofingModeFlag   = "flag"
+	SpoofingModeReject = "reject"
+	// MaxFlaggedDrivers caps the drivers listed by the flagged drivers endpoint.
+	MaxFlaggedDrivers = 1000
+	// MaxDriverUpdateAttempts is how many times an update of a driver is
+	// checked and written again when another update of the driver won the race.
+	MaxDriverUpdateAttempts = 3
+)
+
+const (
+	PositionRaw      = "raw"
+	PositionSmoothed = "smoothed"
+	PositionSnapped  = "snapped"
+)
+
+// MaxRoadSnapDistance bounds ROAD_SNAP_DISTANCE, in metres.
+const MaxRoadSnapDistance = 1000
+
+const (
+	ImportSourceBulk = "bulk"
+	ImportSourceCSV  = "csv"
+)
+
+const (
+	DependencyMongoDB  = "mongodb"
+	DependencyGeoIndex = "geo_index"
 )
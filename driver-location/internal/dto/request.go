@@ -1,20 +1,111 @@
 package dto
 
+import "time"
+
 type GeoJSONPoint struct {
 	Type        string    `json:"type" binding:"required,eq=Point" example:"Point"`
 	Coordinates []float64 `json:"coordinates" binding:"required,len=2" example:"28.9784,41.0082" swaggertype:"array,number"`
 }
 
+type Vehicle struct {
+	Type                 string `json:"type" binding:"required,oneof=taxi comfort xl" example:"taxi"`
+	Capacity             int    `json:"capacity" binding:"required,min=1,max=8" example:"4"`
+	WheelchairAccessible bool   `json:"wheelchair_accessible" example:"false"`
+	PetFriendly          bool   `json:"pet_friendly" example:"false"`
+}
+
 type CreateLocationRequest struct {
-	Latitude  float64 `json:"latitude" binding:"latitude" example:"41.0082"`
-	Longitude float64 `json:"longitude" binding:"longitude" example:"28.9784"`
+	Latitude  float64  `json:"latitude" binding:"latitude" example:"41.0082"`
+	Longitude float64  `json:"longitude" binding:"longitude" example:"28.9784"`
+	Vehicle   *Vehicle `json:"vehicle,omitempty"`
+	// Heading is the direction of travel in degrees clockwise from north,
+	// Speed is in metres per second and Accuracy is the horizontal accuracy
+	// of the fix in metres.
+	Heading  *float64 `json:"heading,omitempty" binding:"omitempty,min=0,lt=360" example:"90"`
+	Speed    *float64 `json:"speed,omitempty" binding:"omitempty,min=0,max=100" example:"12.5"`
+	Accuracy *float64 `json:"accuracy,omitempty" binding:"omitempty,gt=0,max=10000" example:"8"`
+	// Rating is the driver's rating out of 5, AcceptanceRate the share of
+	// offered trips the driver accepted and IdleSince when the driver last
+	// became available for a trip.
+	Rating         *float64   `json:"rating,omitempty" binding:"omitempty,min=0,max=5" example:"4.8"`
+	AcceptanceRate *float64   `json:"acceptance_rate,omitempty" binding:"omitempty,min=0,max=1" example:"0.9"`
+	IdleSince      *time.Time `json:"idle_since,omitempty" example:"2026-01-01T12:00:00Z"`
+	// DriverID updates the location of the driver in place and checks it
+	// against the previous one for spoofing.
+	DriverID string `json:"driver_id,omitempty" binding:"omitempty,max=64" example:"driver-42"`
+}
+
+// CreateLocationBulkItem is a location of a bulk request. Bulk locations are
+// not tied to drivers.
+type CreateLocationBulkItem struct {
+	Latitude       float64    `json:"latitude" binding:"latitude" example:"41.0082"`
+	Longitude      float64    `json:"longitude" binding:"longitude" example:"28.9784"`
+	Vehicle        *Vehicle   `json:"vehicle,omitempty"`
+	Heading        *float64   `json:"heading,omitempty" binding:"omitempty,min=0,lt=360" example:"90"`
+	Speed          *float64   `json:"speed,omitempty" binding:"omitempty,min=0,max=100" example:"12.5"`
+	Accuracy       *float64   `json:"accuracy,omitempty" binding:"omitempty,gt=0,max=10000" example:"8"`
+	Rating         *float64   `json:"rating,omitempty" binding:"omitempty,min=0,max=5" example:"4.8"`
+	AcceptanceRate *float64   `json:"acceptance_rate,omitempty" binding:"omitempty,min=0,max=1" example:"0.9"`
+	IdleSince      *time.Time `json:"idle_since,omitempty" example:"2026-01-01T12:00:00Z"`
 }
 
 type CreateLocationBulkRequest struct {
-	Locations []CreateLocationRequest `json:"locations" binding:"required,min=1,max=1000,dive"`
+	Locations []CreateLocationBulkItem `json:"locations" binding:"required,min=1,max=1000,dive"`
+}
+
+type VehicleRequirements struct {
+	VehicleTypes         []string `json:"vehicle_types,omitempty" binding:"omitempty,dive,oneof=taxi comfort xl" example:"taxi,xl"`
+	MinCapacity          int      `json:"min_capacity,omitempty" binding:"omitempty,min=1,max=8" example:"4"`
+	WheelchairAccessible bool     `json:"wheelchair_accessible,omitempty" example:"false"`
+	PetFriendly          bool     `json:"pet_friendly,omitempty" example:"false"`
 }
 
 type SearchLocationRequest struct {
+	Location     GeoJSONPoint         `json:"location" binding:"required"`
+	Radius       float64              `json:"radius" binding:"required,min=10,max=10000"`
+	Requirements *VehicleRequirements `json:"requirements,omitempty"`
+	// MaxAccuracy excludes locations whose reported accuracy is worse than
+	// this many meters. Locations without a reported accuracy are kept.
+	MaxAccuracy float64 `json:"max_accuracy,omitempty" binding:"omitempty,gt=0,max=10000" example:"50"`
+	// Position selects whether drivers are searched by their raw, smoothed or
+	// snapped location. Smoothed locations require SMOOTHING_ENABLED and
+	// snapped locations ROAD_NETWORK_FILE.
+	Position string `json:"position,omitempty" binding:"omitempty,oneof=raw smoothed snapped" example:"raw"`
+}
+
+// PickupPointRequest creates or replaces a pickup point.
+type PickupPointRequest struct {
+	Name     string       `json:"name" binding:"required,max=100" example:"Terminal 1 Gate 4"`
+	Zone     string       `json:"zone,omitempty" binding:"omitempty,max=64" example:"airport"`
 	Location GeoJSONPoint `json:"location" binding:"required"`
-	Radius   float64      `json:"radius" binding:"required,min=10,max=10000"`
+}
+
+type SearchPickupPointsRequest struct {
+	Location GeoJSONPoint `json:"location" binding:"required"`
+	Radius   float64      `json:"radius" binding:"required,min=1,max=1000" example:"150"`
+	Zone     string       `json:"zone,omitempty" binding:"omitempty,max=64" example:"airport"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=64" example:"matching"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=locations:read locations:write locations:import keys:admin audit:read pickup_points:write" example:"locations:read"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" example:"2027-01-01T00:00:00Z"`
+}
+
+// RotateAPIKeyRequest sets how long the old key stays valid. The configured
+// overlap is used when OverlapSeconds is omitted.
+type RotateAPIKeyRequest struct {
+	OverlapSeconds *int `json:"overlap_seconds,omitempty" binding:"omitempty,min=0,max=2592000" example:"86400"`
+}
+
+// ListAuditRequest filters audit entries. From and To are RFC 3339 timestamps
+// bounding the entries inclusively. Format csv exports up to 10000 entries
+// as a file instead of listing a page.
+type ListAuditRequest struct {
+	Actor     string     `form:"actor" binding:"omitempty,max=128" example:"0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"`
+	Operation string     `form:"operation" binding:"omitempty,oneof=locations.batch_create locations.import keys.issue keys.rotate keys.revoke pickup_points.create pickup_points.update pickup_points.delete" example:"locations.import"`
+	From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-01-01T00:00:00Z"`
+	To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-02-01T00:00:00Z"`
+	Limit     int        `form:"limit" binding:"omitempty,min=1,max=1000" example:"100"`
+	Format    string     `form:"format" binding:"omitempty,oneof=json csv" example:"json"`
 }
@@ -1,12 +1,31 @@
 package dto
 
-type ErrorResponse struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error"`
-}
+import (
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse = apierror.ErrorResponse
+
+// FieldError describes why a single request field was rejected.
+type FieldError = apierror.FieldError
+
+// Problem is an RFC 7807 problem details body, returned instead of
+// ErrorResponse when the client accepts application/problem+json.
+type Problem = apierror.Problem
 
 type HealthCheckResponse struct {
-	Status string `json:"status" example:"ok"`
+	Status       string             `json:"status" example:"ok"`
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+}
+
+type DependencyStatus struct {
+	Name      string  `json:"name" example:"mongodb"`
+	Status    string  `json:"status" example:"ok"`
+	LatencyMs float64 `json:"latency_ms" example:"1.42"`
+	Error     string  `json:"error,omitempty"`
 }
 
 type CreateLocationData struct {
@@ -43,6 +62,34 @@ type SearchResultLocation struct {
 	ID       string       `json:"id"`
 	Location GeoJSONPoint `json:"location"`
 	Distance float64      `json:"distance"`
+	Vehicle  *Vehicle     `json:"vehicle,omitempty"`
+	Heading  *float64     `json:"heading,omitempty" example:"90"`
+	Speed    *float64     `json:"speed,omitempty" example:"12.5"`
+	Accuracy *float64     `json:"accuracy,omitempty" example:"8"`
+	Rating   *float64     `json:"rating,omitempty" example:"4.8"`
+	// IdleSeconds is how long ago the driver became available for a trip.
+	IdleSeconds    *float64 `json:"idle_seconds,omitempty" example:"420"`
+	AcceptanceRate *float64 `json:"acceptance_rate,omitempty" example:"0.9"`
+	// SmoothedLocation is the location with GPS jitter filtered out. Distance
+	// is measured from it when smoothed locations are searched.
+	SmoothedLocation *GeoJSONPoint `json:"smoothed_location,omitempty"`
+	// SnappedLocation is the location moved onto the nearest road. Distance
+	// is measured from it when snapped locations are searched.
+	SnappedLocation *GeoJSONPoint `json:"snapped_location,omitempty"`
+	// RoadSegmentID identifies the road segment the driver was snapped to,
+	// as from_id:to_id of the road network.
+	RoadSegmentID string `json:"road_segment_id,omitempty" example:"1001:1002"`
+	// Flags lists the spoofing flags currently raised against the driver.
+	Flags []string `json:"flags,omitempty" example:"teleport"`
+}
+
+type CountLocationResponse struct {
+	Success bool              `json:"success"`
+	Data    CountLocationData `json:"data"`
+}
+
+type CountLocationData struct {
+	Count int64 `json:"count" example:"250"`
 }
 
 type ImportLocationCSVResponse struct {
@@ -55,3 +102,104 @@ type ImportLocationCSVData struct {
 	Successful int `json:"successful"`
 	Failed     int `json:"failed"`
 }
+
+type APIKey struct {
+	ID        string     `json:"id" example:"0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"`
+	Name      string     `json:"name" example:"matching"`
+	Prefix    string     `json:"prefix" example:"dlk_Q2x1c3Rl"`
+	Scopes    []string   `json:"scopes" example:"locations:read"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyResponse struct {
+	Success bool   `json:"success"`
+	Data    APIKey `json:"data"`
+}
+
+type APIKeyListResponse struct {
+	Success bool     `json:"success"`
+	Data    []APIKey `json:"data"`
+}
+
+// IssuedAPIKeyData includes the key itself, which is only returned once.
+type IssuedAPIKeyData struct {
+	APIKey
+	Key string `json:"key" example:"dlk_Q2x1c3RlcktleUV4YW1wbGVPbmx5Tm90UmVhbA"`
+}
+
+type IssuedAPIKeyResponse struct {
+	Success bool             `json:"success"`
+	Data    IssuedAPIKeyData `json:"data"`
+}
+
+type PickupPoint struct {
+	ID        string       `json:"id" example:"5d3c1a9e-2b7f-4c61-9d0e-8a4f2b6c1e37"`
+	Name      string       `json:"name" example:"Terminal 1 Gate 4"`
+	Zone      string       `json:"zone,omitempty" example:"airport"`
+	Location  GeoJSONPoint `json:"location"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type PickupPointResponse struct {
+	Success bool        `json:"success"`
+	Data    PickupPoint `json:"data"`
+}
+
+type PickupPointListResponse struct {
+	Success bool          `json:"success"`
+	Data    []PickupPoint `json:"data"`
+}
+
+// NearbyPickupPoint is a pickup point found by a search and its distance in
+// meters from the searched location.
+type NearbyPickupPoint struct {
+	PickupPoint
+	Distance float64 `json:"distance" example:"42.5"`
+}
+
+type SearchPickupPointsResponse struct {
+	Success bool                `json:"success"`
+	Data    []NearbyPickupPoint `json:"data"`
+}
+
+type AuditEntry struct {
+	ID         string    `json:"id" example:"6650f1c2e4b0a1b2c3d4e5f6"`
+	Timestamp  time.Time `json:"timestamp"`
+	Actor      string    `json:"actor" example:"0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"`
+	Operation  string    `json:"operation" example:"locations.import"`
+	Outcome    string    `json:"outcome" example:"succeeded" enums:"succeeded,failed"`
+	Target     string    `json:"target,omitempty" example:"0b7c2f0e-8f3a-4b9e-9a53-2f1d7c4e6a10"`
+	Total      *int      `json:"total,omitempty" example:"1000"`
+	Successful *int      `json:"successful,omitempty" example:"998"`
+	Failed     *int      `json:"failed,omitempty" example:"2"`
+	SourceIP   string    `json:"source_ip" example:"10.0.0.12"`
+	RequestID  string    `json:"request_id,omitempty" example:"3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"`
+}
+
+type AuditListResponse struct {
+	Success bool         `json:"success"`
+	Data    []AuditEntry `json:"data"`
+}
+
+type FlaggedDriversResponse struct {
+	Success bool            `json:"success"`
+	Data    []FlaggedDriver `json:"data"`
+}
+
+type FlaggedDriver struct {
+	ID        string       `json:"id"`
+	Location  GeoJSONPoint `json:"location"`
+	UpdatedAt time.Time    `json:"updated_at"`
+	FlaggedAt time.Time    `json:"flagged_at"`
+	Flags     []DriverFlag `json:"flags"`
+}
+
+type DriverFlag struct {
+	Type       string    `json:"type" example:"teleport"`
+	Detail     string    `json:"detail" example:"moved 12000 m in 30s"`
+	DetectedAt time.Time `json:"detected_at"`
+	Count      int       `json:"count" example:"3"`
+}
@@ -0,0 +1,40 @@
+// Package geo holds the great-circle and local flat-earth calculations shared
+// by spoofing detection, smoothing and map matching.
+package geo
+
+import "math"
+
+// EarthRadiusMeters is the mean Earth radius used for great-circle calculations.
+const EarthRadiusMeters = 6371000.0
+
+// MetersPerDegree is the length of a degree of latitude.
+const MetersPerDegree = EarthRadiusMeters * math.Pi / 180
+
+// MetersPerLonDegree returns the length of a degree of longitude at lat.
+// Over the few hundred metres between updates or around a road, positions
+// can be handled in metres north and east of a point using this and
+// MetersPerDegree.
+func MetersPerLonDegree(lat float64) float64 {
+	return MetersPerDegree * math.Cos(lat*math.Pi/180)
+}
+
+// NormalizeLongitude wraps lon into [-180, 180), so that positions moved
+// across the antimeridian stay valid.
+func NormalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// Distance returns the great-circle distance in metres between two points.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,28 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// One degree of latitude is 1/360 of the Earth's circumference.
+	assert.InDelta(t, MetersPerDegree, Distance(40.0, 29.0, 41.0, 29.0), 1)
+	assert.InDelta(t, 111195, Distance(40.0, 29.0, 41.0, 29.0), 10)
+	assert.Zero(t, Distance(40.0, 29.0, 40.0, 29.0))
+}
+
+func TestMetersPerLonDegree(t *testing.T) {
+	assert.InDelta(t, MetersPerDegree, MetersPerLonDegree(0), 1e-9)
+	assert.InDelta(t, MetersPerDegree/2, MetersPerLonDegree(60), 1e-9)
+	assert.InDelta(t, 0, MetersPerLonDegree(90), 1e-9)
+}
+
+func TestNormalizeLongitude(t *testing.T) {
+	assert.InDelta(t, 29, NormalizeLongitude(29), 1e-9)
+	assert.InDelta(t, -179.5, NormalizeLongitude(180.5), 1e-9)
+	assert.InDelta(t, 179.5, NormalizeLongitude(-180.5), 1e-9)
+	assert.InDelta(t, -180, NormalizeLongitude(180), 1e-9)
+	assert.InDelta(t, 10, NormalizeLongitude(730), 1e-9)
+}
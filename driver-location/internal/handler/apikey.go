@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type APIKeyHandler struct {
+	keys            service.APIKeyService
+	audit           service.AuditService
+	rotationOverlap time.Duration
+	logger          *zap.Logger
+}
+
+func NewAPIKeyHandler(keys service.APIKeyService, audit service.AuditService, rotationOverlap time.Duration, logger *zap.Logger) *APIKeyHandler {
+	return &APIKeyHandler{keys: keys, audit: audit, rotationOverlap: rotationOverlap, logger: logger}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(r *gin.RouterGroup) {
+	admin := r.Group("/admin/keys", middleware.RequireScope(config.ScopeKeysAdmin))
+	admin.GET("", h.listKeys)
+	admin.POST("", h.issueKey)
+	admin.POST("/:id/rotate", h.rotateKey)
+	admin.DELETE("/:id", h.revokeKey)
+}
+
+// @Summary List API keys
+// @Description Lists every API key, including expired and revoked ones. Secrets are never returned.
+// @Tags admin
+// @Produce json
+// @Success 200 {object} dto.APIKeyListResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/keys [get]
+func (h *APIKeyHandler) listKeys(c *gin.Context) {
+	keys, err := h.keys.ListKeys(c.Request.Context())
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to list api keys", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	data := make([]dto.APIKey, len(keys))
+	for i, k := range keys {
+		data[i] = toAPIKeyDTO(k)
+	}
+
+	c.JSON(http.StatusOK, dto.APIKeyListResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// @Summary Issue an API key
+// @Description Issues a new API key with the given scopes. The key is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAPIKeyRequest true "Create API key request"
+// @Success 201 {object} dto.IssuedAPIKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/keys [post]
+func (h *APIKeyHandler) issueKey(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		apierror.Respond(c, apierror.ErrValidationFailed, dto.FieldError{
+			Field:   "expires_at",
+			Reason:  "future",
+			Message: "must be in the future",
+		})
+		return
+	}
+
+	issued, err := h.keys.IssueKey(c.Request.Context(), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		recordAudit(c, h.audit, h.logger, config.AuditOperationKeyIssue, "", nil, err)
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to issue api key", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+	recordAudit(c, h.audit, h.logger, config.AuditOperationKeyIssue, issued.APIKey.ID, nil, nil)
+
+	c.JSON(http.StatusCreated, dto.IssuedAPIKeyResponse{
+		Success: true,
+		Data:    toIssuedAPIKeyDTO(issued),
+	})
+}
+
+// @Summary Rotate an API key
+// @Description Issues a replacement key with the same name, scopes and lifetime. The old key keeps working for the overlap period, or until it expires if that is sooner.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path string true "API key ID"
+// @Param request body dto.RotateAPIKeyRequest false "Rotate API key request"
+// @Success 201 {object} dto.IssuedAPIKeyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/keys/{id}/rotate [post]
+func (h *APIKeyHandler) rotateKey(c *gin.Context) {
+	var req dto.RotateAPIKeyRequest
+
+	// The body is optional.
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	overlap := h.rotationOverlap
+	if req.OverlapSeconds != nil {
+		overlap = time.Duration(*req.OverlapSeconds) * time.Second
+	}
+
+	issued, err := h.keys.RotateKey(c.Request.Context(), c.Param("id"), overlap)
+	recordAudit(c, h.audit, h.logger, config.AuditOperationKeyRotate, c.Param("id"), nil, err)
+	if err != nil {
+		h.respondKeyError(c, "Failed to rotate api key", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IssuedAPIKeyResponse{
+		Success: true,
+		Data:    toIssuedAPIKeyDTO(issued),
+	})
+}
+
+// @Summary Revoke an API key
+// @Description Revokes an API key immediately
+// @Tags admin
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} dto.APIKeyResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/keys/{id} [delete]
+func (h *APIKeyHandler) revokeKey(c *gin.Context) {
+	key, err := h.keys.RevokeKey(c.Request.Context(), c.Param("id"))
+	recordAudit(c, h.audit, h.logger, config.AuditOperationKeyRevoke, c.Param("id"), nil, err)
+	if err != nil {
+		h.respondKeyError(c, "Failed to revoke api key", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.APIKeyResponse{
+		Success: true,
+		Data:    toAPIKeyDTO(key),
+	})
+}
+
+func (h *APIKeyHandler) respondKeyError(c *gin.Context, msg string, err error) {
+	switch {
+	case errors.Is(err, repository.ErrAPIKeyNotFound):
+		apierror.Respond(c, apierror.ErrAPIKeyNotFound)
+	case errors.Is(err, service.ErrAPIKeyInactive):
+		apierror.Respond(c, apierror.ErrAPIKeyInactive)
+	default:
+		logging.FromContext(c.Request.Context(), h.logger).Error(msg, zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+	}
+}
+
+func toAPIKeyDTO(k *models.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+		ExpiresAt: k.ExpiresAt,
+		RevokedAt: k.RevokedAt,
+	}
+}
+
+func toIssuedAPIKeyDTO(issued *models.IssuedAPIKey) dto.IssuedAPIKeyData {
+	return dto.IssuedAPIKeyData{
+		APIKey: toAPIKeyDTO(issued.APIKey),
+		Key:    issued.Secret,
+	}
+}
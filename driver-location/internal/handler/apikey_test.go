@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+)
+
+const testRotationOverlap = 24 * time.Hour
+
+// MockAPIKeyService implements service.APIKeyService for testing
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	args := m.Called(ctx, secret)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyService) IssueKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*models.IssuedAPIKey, error) {
+	args := m.Called(ctx, name, scopes, expiresAt)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.IssuedAPIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyService) RotateKey(ctx context.Context, id string, overlap time.Duration) (*models.IssuedAPIKey, error) {
+	args := m.Called(ctx, id, overlap)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.IssuedAPIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeKey(ctx context.Context, id string) (*models.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) != nil {
+		return args.Get(0).(*models.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *MockAPIKeyService) ListKeys(ctx context.Context) ([]*models.APIKey, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.APIKey), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func testIssuedKey() *models.IssuedAPIKey {
+	return &models.IssuedAPIKey{
+		APIKey: &models.APIKey{ID: "k1", Name: "matching", Prefix: "dlk_abcdefgh", Scopes: []string{config.ScopeLocationsRead}},
+		Secret: "dlk_abcdefghsecret",
+	}
+}
+
+// setupAPIKeyRouter serves the admin routes as a caller with the given scopes.
+func setupAPIKeyRouter(keys service.APIKeyService, audit service.AuditService, scopes ...string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(config.APIKeyContextKey, &models.APIKey{ID: "caller", Scopes: scopes})
+	})
+	NewAPIKeyHandler(keys, audit, testRotationOverlap, zap.NewNop()).RegisterRoutes(router.Group("/"))
+	return router
+}
+
+func TestAPIKeyHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		scopes             []string
+		mockSetup          func(*MockAPIKeyService)
+		expectedStatusCode int
+		expectedAudit      string
+		expectedOutcome    string
+		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "issue - success",
+			method: http.MethodPost,
+			path:   "/admin/keys",
+			body:   `{"name":"matching","scopes":["locations:read"]}`,
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("IssueKey", mock.Anything, "matching", []string{config.ScopeLocationsRead}, (*time.Time)(nil)).Return(testIssuedKey(), nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedAudit:      config.AuditOperationKeyIssue,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.IssuedAPIKeyResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "dlk_abcdefghsecret", resp.Data.Key)
+				assert.Equal(t, "k1", resp.Data.ID)
+			},
+		},
+		{
+			name:               "issue - unknown scope",
+			method:             http.MethodPost,
+			path:               "/admin/keys",
+			body:               `{"name":"matching","scopes":["locations:delete"]}`,
+			scopes:             []string{config.ScopeKeysAdmin},
+			mockSetup:          func(m *MockAPIKeyService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "scopes[0]", resp.Details[0].Field)
+			},
+		},
+		{
+			name:               "issue - expiry in the past",
+			method:             http.MethodPost,
+			path:               "/admin/keys",
+			body:               `{"name":"matching","scopes":["locations:read"],"expires_at":"2020-01-01T00:00:00Z"}`,
+			scopes:             []string{config.ScopeKeysAdmin},
+			mockSetup:          func(m *MockAPIKeyService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:               "issue - missing admin scope",
+			method:             http.MethodPost,
+			path:               "/admin/keys",
+			body:               `{"name":"matching","scopes":["locations:read"]}`,
+			scopes:             []string{config.ScopeLocationsWrite},
+			mockSetup:          func(m *MockAPIKeyService) {},
+			expectedStatusCode: http.StatusForbidden,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "rotate - default overlap",
+			method: http.MethodPost,
+			path:   "/admin/keys/k0/rotate",
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("RotateKey", mock.Anything, "k0", testRotationOverlap).Return(testIssuedKey(), nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedAudit:      config.AuditOperationKeyRotate,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "rotate - custom overlap",
+			method: http.MethodPost,
+			path:   "/admin/keys/k0/rotate",
+			body:   `{"overlap_seconds":60}`,
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("RotateKey", mock.Anything, "k0", time.Minute).Return(testIssuedKey(), nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedAudit:      config.AuditOperationKeyRotate,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "rotate - revoked key",
+			method: http.MethodPost,
+			path:   "/admin/keys/k0/rotate",
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("RotateKey", mock.Anything, "k0", testRotationOverlap).Return(nil, service.ErrAPIKeyInactive)
+			},
+			expectedStatusCode: http.StatusConflict,
+			expectedAudit:      config.AuditOperationKeyRotate,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "revoke - success",
+			method: http.MethodDelete,
+			path:   "/admin/keys/k1",
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("RevokeKey", mock.Anything, "k1").Return(testIssuedKey().APIKey, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedAudit:      config.AuditOperationKeyRevoke,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "revoke - not found",
+			method: http.MethodDelete,
+			path:   "/admin/keys/missing",
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("RevokeKey", mock.Anything, "missing").Return(nil, repository.ErrAPIKeyNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedAudit:      config.AuditOperationKeyRevoke,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "api_key_not_found", resp.Code)
+			},
+		},
+		{
+			name:   "list - success",
+			method: http.MethodGet,
+			path:   "/admin/keys",
+			scopes: []string{config.ScopeKeysAdmin},
+			mockSetup: func(m *MockAPIKeyService) {
+				m.On("ListKeys", mock.Anything).Return([]*models.APIKey{testIssuedKey().APIKey}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.NotContains(t, recorder.Body.String(), "hash")
+				var resp dto.APIKeyListResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Len(t, resp.Data, 1)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := &MockAPIKeyService{}
+			tt.mockSetup(mockService)
+			mockAudit := &MockAuditService{}
+			if tt.expectedAudit != "" {
+				mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+					return e.Operation == tt.expectedAudit && e.Outcome == tt.expectedOutcome && e.Actor == "caller"
+				})).Return(nil)
+			}
+			router := setupAPIKeyRouter(mockService, mockAudit, tt.scopes...)
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			tt.assertResponse(t, recorder)
+			mockService.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
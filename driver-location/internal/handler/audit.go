@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type AuditHandler struct {
+	audit  service.AuditService
+	logger *zap.Logger
+}
+
+func NewAuditHandler(audit service.AuditService, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{audit: audit, logger: logger}
+}
+
+func (h *AuditHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/audit", middleware.RequireScope(config.ScopeAuditRead), h.listAudit)
+}
+
+// @Summary List audit entries
+// @Description Lists bulk location writes, API key administration and pickup point changes, newest first. Every attempt is listed with its outcome, including failed ones. With format=csv the matching entries, up to 10000, are exported as a CSV file; when more entries match, the export holds the newest 10000 and carries an X-Audit-Truncated: true header, and older entries can be exported by narrowing the time range with to.
+// @Tags admin
+// @Produce json
+// @Produce text/csv
+// @Param actor query string false "API key ID that performed the operation"
+// @Param operation query string false "Operation" Enums(locations.batch_create, locations.import, keys.issue, keys.rotate, keys.revoke, pickup_points.create, pickup_points.update, pickup_points.delete)
+// @Param from query string false "Earliest timestamp, RFC 3339"
+// @Param to query string false "Latest timestamp, RFC 3339"
+// @Param limit query int false "Maximum number of entries, default 100" minimum(1) maximum(1000)
+// @Param format query string false "Response format" Enums(json, csv)
+// @Success 200 {object} dto.AuditListResponse
+// @Header 200 {string} X-Audit-Truncated "true when a CSV export was cut short"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/audit [get]
+func (h *AuditHandler) listAudit(c *gin.Context) {
+	var req dto.ListAuditRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	if req.From != nil && req.To != nil && req.To.Before(*req.From) {
+		apierror.Respond(c, apierror.ErrValidationFailed, dto.FieldError{
+			Field:   "to",
+			Reason:  "after_from",
+			Message: "must not be before from",
+		})
+		return
+	}
+
+	filter := models.AuditFilter{
+		Actor:     req.Actor,
+		Operation: req.Operation,
+		Limit:     req.Limit,
+	}
+	if req.From != nil {
+		filter.From = *req.From
+	}
+	if req.To != nil {
+		filter.To = *req.To
+	}
+	export := req.Format == config.AuditFormatCSV
+	switch {
+	case export:
+		// One more entry than exported shows whether the export is complete.
+		filter.Limit = config.MaxAuditExportEntries + 1
+	case filter.Limit == 0:
+		filter.Limit = config.DefaultAuditPageSize
+	}
+
+	entries, err := h.audit.List(c.Request.Context(), filter)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to list audit entries", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	if export {
+		if len(entries) > config.MaxAuditExportEntries {
+			entries = entries[:config.MaxAuditExportEntries]
+			c.Header(config.AuditTruncatedHeader, "true")
+		}
+		h.exportAudit(c, entries)
+		return
+	}
+
+	data := make([]dto.AuditEntry, len(entries))
+	for i, e := range entries {
+		data[i] = toAuditEntryDTO(e)
+	}
+
+	c.JSON(http.StatusOK, dto.AuditListResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// exportAudit writes entries as a CSV attachment.
+func (h *AuditHandler) exportAudit(c *gin.Context, entries []*models.AuditEntry) {
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="audit.csv"`)
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{"id", "timestamp", "actor", "operation", "outcome", "target", "total", "successful", "failed", "source_ip", "request_id"})
+	for _, e := range entries {
+		var total, successful, failed string
+		if e.Result != nil {
+			total = strconv.Itoa(e.Result.Total)
+			successful = strconv.Itoa(e.Result.Successful)
+			failed = strconv.Itoa(e.Result.Failed)
+		}
+		_ = w.Write([]string{
+			e.ID.Hex(), e.Timestamp.UTC().Format(time.RFC3339Nano), e.Actor, e.Operation, e.Outcome, e.Target,
+			total, successful, failed, e.SourceIP, e.RequestID,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to export audit entries", zap.Error(err))
+	}
+}
+
+// recordAudit records an attempt by the API key of the request to perform an
+// operation, which failed if opErr is set. result holds the rows a bulk
+// operation wrote, even if it failed part way. The entry is written even if
+// the client has gone away, and a failure to write it is logged rather than
+// failing an operation that already happened.
+func recordAudit(c *gin.Context, audit service.AuditService, logger *zap.Logger, operation, target string, result *models.BulkResult, opErr error) {
+	outcome := config.AuditOutcomeSucceeded
+	if opErr != nil {
+		outcome = config.AuditOutcomeFailed
+	}
+	entry := &models.AuditEntry{
+		Operation: operation,
+		Outcome:   outcome,
+		Target:    target,
+		Result:    result,
+		SourceIP:  c.ClientIP(),
+		RequestID: logging.RequestID(c.Request.Context()),
+	}
+	if key, ok := c.Value(config.APIKeyContextKey).(*models.APIKey); ok {
+		entry.Actor = key.ID
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), config.AuditRecordTimeout)
+	defer cancel()
+	if err := audit.Record(ctx, entry); err != nil {
+		logging.FromContext(ctx, logger).Error("Failed to record audit entry",
+			zap.Error(err),
+			zap.String("operation", operation),
+		)
+	}
+}
+
+func toAuditEntryDTO(e *models.AuditEntry) dto.AuditEntry {
+	entry := dto.AuditEntry{
+		ID:        e.ID.Hex(),
+		Timestamp: e.Timestamp,
+		Actor:     e.Actor,
+		Operation: e.Operation,
+		Outcome:   e.Outcome,
+		Target:    e.Target,
+		SourceIP:  e.SourceIP,
+		RequestID: e.RequestID,
+	}
+	if e.Result != nil {
+		entry.Total = &e.Result.Total
+		entry.Successful = &e.Result.Successful
+		entry.Failed = &e.Result.Failed
+	}
+	return entry
+}
@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// MockAuditService implements service.AuditService for testing
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, entry *models.AuditEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.AuditEntry), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func testAuditEntries() []*models.AuditEntry {
+	return []*models.AuditEntry{
+		{
+			ID:        bson.NewObjectID(),
+			Timestamp: time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+			Actor:     "k1",
+			Operation: config.AuditOperationImport,
+			Outcome:   config.AuditOutcomeSucceeded,
+			Result:    &models.BulkResult{Total: 10, Successful: 9, Failed: 1},
+			SourceIP:  "10.0.0.1",
+		},
+		{
+			ID:        bson.NewObjectID(),
+			Timestamp: time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC),
+			Actor:     "k1",
+			Operation: config.AuditOperationKeyRevoke,
+			Outcome:   config.AuditOutcomeFailed,
+			Target:    "k2",
+			SourceIP:  "10.0.0.1",
+		},
+	}
+}
+
+func TestAuditHandler_ListAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name               string
+		query              string
+		scopes             []string
+		mockSetup          func(*MockAuditService)
+		expectedStatusCode int
+		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "default page",
+			scopes: []string{config.ScopeAuditRead},
+			mockSetup: func(m *MockAuditService) {
+				m.On("List", mock.Anything, models.AuditFilter{Limit: config.DefaultAuditPageSize}).Return(testAuditEntries(), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.AuditListResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				require.Len(t, resp.Data, 2)
+				assert.Equal(t, 9, *resp.Data[0].Successful)
+				assert.Nil(t, resp.Data[1].Total)
+				assert.Equal(t, "k2", resp.Data[1].Target)
+				assert.Equal(t, config.AuditOutcomeFailed, resp.Data[1].Outcome)
+			},
+		},
+		{
+			name:   "filters",
+			query:  "actor=k1&operation=locations.import&from=2026-01-01T00:00:00Z&to=2026-01-31T00:00:00Z&limit=5",
+			scopes: []string{config.ScopeAuditRead},
+			mockSetup: func(m *MockAuditService) {
+				m.On("List", mock.Anything, models.AuditFilter{
+					Actor:     "k1",
+					Operation: config.AuditOperationImport,
+					From:      time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:        time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC),
+					Limit:     5,
+				}).Return([]*models.AuditEntry{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "csv export",
+			query:  "format=csv",
+			scopes: []string{config.ScopeAuditRead},
+			mockSetup: func(m *MockAuditService) {
+				m.On("List", mock.Anything, models.AuditFilter{Limit: config.MaxAuditExportEntries + 1}).Return(testAuditEntries(), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				assert.Contains(t, recorder.Header().Get("Content-Disposition"), "attachment")
+				assert.Empty(t, recorder.Header().Get(config.AuditTruncatedHeader))
+				records, err := csv.NewReader(strings.NewReader(recorder.Body.String())).ReadAll()
+				require.NoError(t, err)
+				require.Len(t, records, 3)
+				assert.Equal(t, []string{"2026-01-02T10:00:00Z", "k1", "locations.import", "succeeded", "", "10", "9", "1", "10.0.0.1", ""}, records[1][1:])
+			},
+		},
+		{
+			name:   "csv export - truncated",
+			query:  "format=csv",
+			scopes: []string{config.ScopeAuditRead},
+			mockSetup: func(m *MockAuditService) {
+				entries := make([]*models.AuditEntry, config.MaxAuditExportEntries+1)
+				for i := range entries {
+					entries[i] = testAuditEntries()[0]
+				}
+				m.On("List", mock.Anything, models.AuditFilter{Limit: config.MaxAuditExportEntries + 1}).Return(entries, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				assert.Equal(t, "true", recorder.Header().Get(config.AuditTruncatedHeader))
+				records, err := csv.NewReader(strings.NewReader(recorder.Body.String())).ReadAll()
+				require.NoError(t, err)
+				assert.Len(t, records, config.MaxAuditExportEntries+1)
+			},
+		},
+		{
+			name:               "to before from",
+			query:              "from=2026-02-01T00:00:00Z&to=2026-01-01T00:00:00Z",
+			scopes:             []string{config.ScopeAuditRead},
+			mockSetup:          func(m *MockAuditService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "to", resp.Details[0].Field)
+			},
+		},
+		{
+			name:               "invalid timestamp",
+			query:              "from=yesterday",
+			scopes:             []string{config.ScopeAuditRead},
+			mockSetup:          func(m *MockAuditService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:               "unknown operation",
+			query:              "operation=locations.delete",
+			scopes:             []string{config.ScopeAuditRead},
+			mockSetup:          func(m *MockAuditService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:               "missing audit scope",
+			scopes:             []string{config.ScopeKeysAdmin},
+			mockSetup:          func(m *MockAuditService) {},
+			expectedStatusCode: http.StatusForbidden,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "service error",
+			scopes: []string{config.ScopeAuditRead},
+			mockSetup: func(m *MockAuditService) {
+				m.On("List", mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockAudit := &MockAuditService{}
+			tt.mockSetup(mockAudit)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(config.APIKeyContextKey, &models.APIKey{ID: "caller", Scopes: tt.scopes})
+			})
+			NewAuditHandler(mockAudit, zap.NewNop()).RegisterRoutes(router.Group("/"))
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/audit?"+tt.query, nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			tt.assertResponse(t, recorder)
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
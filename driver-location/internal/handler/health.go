@@ -1,11 +1,15 @@
 package handler
 
 import (
+	"context"
+	"math"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
 )
 
@@ -18,23 +22,60 @@ func NewHealthHandler(service service.Service) *HealthHandler {
 }
 
 func (h *HealthHandler) RegisterRoutes(r *gin.RouterGroup) {
-	r.GET("/health", h.healthCheck)
+	r.GET("/health/live", h.liveness)
+	r.GET("/health/ready", h.readiness)
 }
 
-// @Summary Check if the service is healthy
-// @Description Check if the service is healthy
+// @Summary Check if the service is alive
+// @Description Reports that the process is up, without checking its dependencies
 // @Tags health
 // @Produce json
 // @Success 200 {object} dto.HealthCheckResponse
-// @Router /health [get]
-func (h *HealthHandler) healthCheck(c *gin.Context) {
-	if err := h.service.HealthCheck(c.Request.Context()); err != nil {
-		c.JSON(http.StatusServiceUnavailable, dto.HealthCheckResponse{
-			Status: "unavailable",
-		})
-		return
-	}
+// @Router /health/live [get]
+func (h *HealthHandler) liveness(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.HealthCheckResponse{
 		Status: "ok",
 	})
 }
+
+// @Summary Check if the service is ready
+// @Description Checks MongoDB and the geospatial index, and reports the status and latency of each
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthCheckResponse
+// @Failure 503 {object} dto.HealthCheckResponse
+// @Router /health/ready [get]
+func (h *HealthHandler) readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.ReadinessTimeout)
+	defer cancel()
+
+	response := toHealthCheckResponse(h.service.CheckReadiness(ctx))
+	if response.Status != "ok" {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func toHealthCheckResponse(checks []models.DependencyCheck) dto.HealthCheckResponse {
+	response := dto.HealthCheckResponse{
+		Status:       "ok",
+		Dependencies: make([]dto.DependencyStatus, len(checks)),
+	}
+
+	for i, check := range checks {
+		status := dto.DependencyStatus{
+			Name:      check.Name,
+			Status:    "ok",
+			LatencyMs: math.Round(float64(check.Latency.Microseconds())/10) / 100,
+		}
+		if check.Err != nil {
+			status.Status = "unavailable"
+			status.Error = check.Err.Error()
+			response.Status = "unavailable"
+		}
+		response.Dependencies[i] = status
+	}
+
+	return response
+}
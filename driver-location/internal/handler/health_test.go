@@ -6,18 +6,40 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
 )
 
-func (m *MockService) HealthCheck(ctx context.Context) error {
+func (m *MockService) CheckReadiness(ctx context.Context) []models.DependencyCheck {
 	args := m.Called(ctx)
-	return args.Error(0)
+	return args.Get(0).([]models.DependencyCheck)
+}
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Setup
+	mockService := NewMockService()
+	handler := NewHealthHandler(mockService)
+
+	// Execute
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/health/live", nil)
+	handler.liveness(ctx)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"status": "ok"}`, recorder.Body.String())
+	mockService.AssertNotCalled(t, "CheckReadiness", mock.Anything)
 }
 
-func TestHealthHandler_HealthCheck(t *testing.T) {
+func TestHealthHandler_Readiness(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	tests := []struct {
@@ -27,20 +49,32 @@ func TestHealthHandler_HealthCheck(t *testing.T) {
 		expectedBody       string
 	}{
 		{
-			name: "success",
+			name: "ready",
 			mockSetup: func(m *MockService) {
-				m.On("HealthCheck", mock.Anything).Return(nil)
+				m.On("CheckReadiness", mock.Anything).Return([]models.DependencyCheck{
+					{Name: "mongodb", Latency: 1500 * time.Microsecond},
+					{Name: "geo_index", Latency: 2 * time.Millisecond},
+				})
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedBody:       `{"status": "ok"}`,
+			expectedBody: `{"status": "ok", "dependencies": [
+				{"name": "mongodb", "status": "ok", "latency_ms": 1.5},
+				{"name": "geo_index", "status": "ok", "latency_ms": 2}
+			]}`,
 		},
 		{
-			name: "failure",
+			name: "dependency down",
 			mockSetup: func(m *MockService) {
-				m.On("HealthCheck", mock.Anything).Return(errors.New("db error"))
+				m.On("CheckReadiness", mock.Anything).Return([]models.DependencyCheck{
+					{Name: "mongodb", Latency: time.Millisecond},
+					{Name: "geo_index", Latency: time.Millisecond, Err: errors.New("geospatial index is missing")},
+				})
 			},
 			expectedStatusCode: http.StatusServiceUnavailable,
-			expectedBody:       `{"status": "unavailable"}`,
+			expectedBody: `{"status": "unavailable", "dependencies": [
+				{"name": "mongodb", "status": "ok", "latency_ms": 1},
+				{"name": "geo_index", "status": "unavailable", "latency_ms": 1, "error": "geospatial index is missing"}
+			]}`,
 		},
 	}
 
@@ -54,8 +88,8 @@ func TestHealthHandler_HealthCheck(t *testing.T) {
 			// Execute
 			recorder := httptest.NewRecorder()
 			ctx, _ := gin.CreateTestContext(recorder)
-			ctx.Request = httptest.NewRequest(http.MethodGet, "/health", nil)
-			handler.healthCheck(ctx)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			handler.readiness(ctx)
 
 			// Assert
 			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
@@ -1,42 +1,56 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/middleware"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
 type LocationHandler struct {
 	service service.Service
+	audit   service.AuditService
 	logger  *zap.Logger
 }
 
-func NewLocationHandler(service service.Service, logger *zap.Logger) *LocationHandler {
-	return &LocationHandler{service: service, logger: logger}
+func NewLocationHandler(service service.Service, audit service.AuditService, logger *zap.Logger) *LocationHandler {
+	return &LocationHandler{service: service, audit: audit, logger: logger}
 }
 
 func (h *LocationHandler) RegisterRoutes(r *gin.RouterGroup) {
-	r.POST("/locations", h.createDriverLocation)
-	r.POST("/locations/batch", h.createDriverLocationBulk)
-	r.POST("/locations/search", h.searchDriverLocation)
-	r.POST("/locations/import", h.importDriverLocations)
+	r.POST("/locations", middleware.RequireScope(config.ScopeLocationsWrite), h.createDriverLocation)
+	r.POST("/locations/batch", middleware.RequireScope(config.ScopeLocationsWrite), h.createDriverLocationBulk)
+	r.POST("/locations/search", middleware.RequireScope(config.ScopeLocationsRead), h.searchDriverLocation)
+	r.POST("/locations/count", middleware.RequireScope(config.ScopeLocationsRead), h.countDriverLocations)
+	r.POST("/locations/import", middleware.RequireScope(config.ScopeLocationsImport), h.importDriverLocations)
+	r.GET("/drivers/flagged", middleware.RequireScope(config.ScopeLocationsRead), h.listFlaggedDrivers)
 }
 
 // @Summary Create a new driver location
-// @Description Creates a new driver location
+// @Description Creates a new driver location. With a driver_id, the location of the driver is updated in place and checked against the previous one; implausible updates are flagged, or rejected with implausible_location when SPOOFING_MODE is reject.
 // @Tags locations
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateLocationRequest true "Create location request"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Success 200 {object} dto.CreateLocationResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/v1/locations [post]
@@ -44,21 +58,28 @@ func (h *LocationHandler) createDriverLocation(c *gin.Context) {
 	var req dto.CreateLocationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Failed to bind JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to bind JSON", zap.Error(err))
+		apierror.RespondBinding(c, err)
 		return
 	}
 
 	locationModel := models.NewDriverLocation(req.Latitude, req.Longitude)
+	locationModel.Vehicle = toVehicleModel(req.Vehicle)
+	locationModel.Heading = req.Heading
+	locationModel.Speed = req.Speed
+	locationModel.Accuracy = req.Accuracy
+	locationModel.Rating = req.Rating
+	locationModel.AcceptanceRate = req.AcceptanceRate
+	locationModel.IdleSince = toTime(req.IdleSince)
+	locationModel.DriverID = req.DriverID
 	if err := h.service.CreateDriverLocation(c.Request.Context(), locationModel); err != nil {
-		h.logger.Error("Failed to create driver location", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Success: false,
-			Error:   config.ErrInternalServer,
-		})
+		if errors.Is(err, service.ErrImplausibleLocation) {
+			logging.FromContext(c.Request.Context(), h.logger).Warn("Rejected implausible driver location", zap.Error(err))
+			apierror.Respond(c, apierror.ErrImplausibleLocation)
+			return
+		}
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to create driver location", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
 		return
 	}
 
@@ -76,9 +97,15 @@ func (h *LocationHandler) createDriverLocation(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body dto.CreateLocationBulkRequest true "Create bulk location request"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Success 200 {object} dto.CreateLocationBulkResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/v1/locations/batch [post]
@@ -86,11 +113,8 @@ func (h *LocationHandler) createDriverLocationBulk(c *gin.Context) {
 	var req dto.CreateLocationBulkRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Failed to bind JSON", zap.Error(err))
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to bind JSON", zap.Error(err))
+		apierror.RespondBinding(c, err)
 		return
 	}
 
@@ -100,15 +124,20 @@ func (h *LocationHandler) createDriverLocationBulk(c *gin.Context) {
 			dtoReq.Latitude,
 			dtoReq.Longitude,
 		)
+		locationModels[i].Vehicle = toVehicleModel(dtoReq.Vehicle)
+		locationModels[i].Heading = dtoReq.Heading
+		locationModels[i].Speed = dtoReq.Speed
+		locationModels[i].Accuracy = dtoReq.Accuracy
+		locationModels[i].Rating = dtoReq.Rating
+		locationModels[i].AcceptanceRate = dtoReq.AcceptanceRate
+		locationModels[i].IdleSince = toTime(dtoReq.IdleSince)
 	}
 
 	result, err := h.service.CreateDriverLocationBulk(c.Request.Context(), locationModels)
+	recordAudit(c, h.audit, h.logger, config.AuditOperationBatchCreate, "", result, err)
 	if err != nil {
-		h.logger.Error("Failed to create bulk locations", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Success: false,
-			Error:   config.ErrInternalServer,
-		})
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to create bulk locations", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
 		return
 	}
 
@@ -123,7 +152,7 @@ func (h *LocationHandler) createDriverLocationBulk(c *gin.Context) {
 }
 
 // @Summary Search for driver locations
-// @Description Searches for driver locations based on a GeoJSON point and radius
+// @Description Searches for driver locations based on a GeoJSON point and radius. With position set to smoothed or snapped, drivers are searched by their smoothed location or their location on the road network.
 // @Tags locations
 // @Accept json
 // @Produce json
@@ -131,7 +160,9 @@ func (h *LocationHandler) createDriverLocationBulk(c *gin.Context) {
 // @Success 200 {object} dto.SearchLocationResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/v1/locations/search [post]
@@ -139,36 +170,33 @@ func (h *LocationHandler) searchDriverLocation(c *gin.Context) {
 	var req dto.SearchLocationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Failed to bind JSON for searchDriverLocation",
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to bind JSON for searchDriverLocation",
 			zap.Error(err),
 			zap.String("path", c.Request.URL.Path),
 		)
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		apierror.RespondBinding(c, err)
 		return
 	}
 
 	lon := req.Location.Coordinates[0]
 	lat := req.Location.Coordinates[1]
-	searchResult, err := h.service.SearchDriverLocation(c.Request.Context(), lat, lon, req.Radius)
+	filter := toSearchFilter(req.Requirements)
+	filter.MaxAccuracy = req.MaxAccuracy
+	filter.Position = req.Position
+	searchResult, err := h.service.SearchDriverLocation(c.Request.Context(), lat, lon, req.Radius, filter)
+	if respondPositionError(c, err) {
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to search driver locations",
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to search driver locations",
 			zap.Error(err),
 			zap.String("path", c.Request.URL.Path),
 		)
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Success: false,
-			Error:   config.ErrInternalServer,
-		})
+		apierror.Respond(c, apierror.ErrInternal)
 		return
 	}
 	if len(searchResult) == 0 {
-		c.JSON(http.StatusNotFound, dto.ErrorResponse{
-			Success: false,
-			Error:   config.ErrNotFound,
-		})
+		apierror.Respond(c, apierror.ErrNoDriversFound)
 		return
 	}
 
@@ -180,7 +208,28 @@ func (h *LocationHandler) searchDriverLocation(c *gin.Context) {
 				Type:        "Point",
 				Coordinates: []float64{e.Longitude, e.Latitude},
 			},
-			Distance: e.Distance,
+			Distance:       e.Distance,
+			Vehicle:        toVehicleDTO(e.Vehicle),
+			Heading:        e.Heading,
+			Speed:          e.Speed,
+			Accuracy:       e.Accuracy,
+			Rating:         e.Rating,
+			IdleSeconds:    e.IdleSeconds,
+			AcceptanceRate: e.AcceptanceRate,
+			RoadSegmentID:  e.RoadSegmentID,
+			Flags:          e.ActiveFlags,
+		}
+		if e.Smoothed != nil {
+			drivers[i].SmoothedLocation = &dto.GeoJSONPoint{
+				Type:        "Point",
+				Coordinates: e.Smoothed.Coordinates,
+			}
+		}
+		if e.Snapped != nil {
+			drivers[i].SnappedLocation = &dto.GeoJSONPoint{
+				Type:        "Point",
+				Coordinates: e.Snapped.Coordinates,
+			}
 		}
 	}
 
@@ -193,26 +242,106 @@ func (h *LocationHandler) searchDriverLocation(c *gin.Context) {
 	})
 }
 
+// @Summary Count driver locations
+// @Description Counts the driver locations a search with the same request would find. Unlike a search, the count is not limited to 100 drivers.
+// @Tags locations
+// @Accept json
+// @Produce json
+// @Param request body dto.SearchLocationRequest true "Search location request"
+// @Success 200 {object} dto.CountLocationResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/locations/count [post]
+func (h *LocationHandler) countDriverLocations(c *gin.Context) {
+	var req dto.SearchLocationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to bind JSON for countDriverLocations",
+			zap.Error(err),
+			zap.String("path", c.Request.URL.Path),
+		)
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	lon := req.Location.Coordinates[0]
+	lat := req.Location.Coordinates[1]
+	filter := toSearchFilter(req.Requirements)
+	filter.MaxAccuracy = req.MaxAccuracy
+	filter.Position = req.Position
+	count, err := h.service.CountDriverLocations(c.Request.Context(), lat, lon, req.Radius, filter)
+	if respondPositionError(c, err) {
+		return
+	}
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to count driver locations",
+			zap.Error(err),
+			zap.String("path", c.Request.URL.Path),
+		)
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.CountLocationResponse{
+		Success: true,
+		Data:    dto.CountLocationData{Count: count},
+	})
+}
+
+// respondPositionError responds when err reports that the searched position
+// is not stored, and reports whether it did.
+func respondPositionError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, service.ErrSmoothingDisabled):
+		apierror.Respond(c, apierror.ErrValidationFailed, dto.FieldError{
+			Field:   "position",
+			Reason:  "smoothing_disabled",
+			Message: "smoothed locations are not available while smoothing is disabled",
+		})
+		return true
+	case errors.Is(err, service.ErrMapMatchingDisabled):
+		apierror.Respond(c, apierror.ErrValidationFailed, dto.FieldError{
+			Field:   "position",
+			Reason:  "map_matching_disabled",
+			Message: "snapped locations are not available while map matching is disabled",
+		})
+		return true
+	}
+	return false
+}
+
 // @Summary Import driver locations from CSV
-// @Description Imports driver locations from a CSV file.
+// @Description Imports driver locations from a CSV file. After a header row, each row holds latitude and longitude, optionally followed by heading, speed and accuracy; empty cells are left unset.
 // @Tags locations
 // @Accept text/csv
 // @Produce json
 // @Param request body string true "CSV data"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Success 200 {object} dto.ImportLocationCSVResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Security ApiKeyAuth
 // @Router /api/v1/locations/import [post]
 func (h *LocationHandler) importDriverLocations(c *gin.Context) {
 	result, err := h.service.ImportDriverLocationsFromCSV(c.Request.Context(), c.Request.Body)
+	recordAudit(c, h.audit, h.logger, config.AuditOperationImport, "", result, err)
 	if err != nil {
-		h.logger.Error("Failed to import locations from CSV", zap.Error(err))
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to import locations from CSV", zap.Error(err))
+		if errors.Is(err, service.ErrInvalidCSV) {
+			apierror.Respond(c, apierror.ErrInvalidCSV)
+			return
+		}
+		apierror.Respond(c, apierror.ErrInternal)
 		return
 	}
 
@@ -225,3 +354,100 @@ func (h *LocationHandler) importDriverLocations(c *gin.Context) {
 		},
 	})
 }
+
+// @Summary List flagged drivers
+// @Description Lists the drivers with spoofing flags raised within SPOOF_FLAG_TTL, most recently flagged first, up to 1000.
+// @Tags locations
+// @Produce json
+// @Success 200 {object} dto.FlaggedDriversResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/drivers/flagged [get]
+func (h *LocationHandler) listFlaggedDrivers(c *gin.Context) {
+	drivers, err := h.service.ListFlaggedDrivers(c.Request.Context())
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to list flagged drivers", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	data := make([]dto.FlaggedDriver, len(drivers))
+	for i, d := range drivers {
+		data[i] = toFlaggedDriverDTO(d)
+	}
+
+	c.JSON(http.StatusOK, dto.FlaggedDriversResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+func toFlaggedDriverDTO(d *models.DriverLocation) dto.FlaggedDriver {
+	flags := make([]dto.DriverFlag, 0, len(d.Flags))
+	for _, flagType := range models.ActiveFlags(d.Flags, time.Time{}) {
+		flag := d.Flags[flagType]
+		flags = append(flags, dto.DriverFlag{
+			Type:       flagType,
+			Detail:     flag.Detail,
+			DetectedAt: flag.DetectedAt,
+			Count:      flag.Count,
+		})
+	}
+	return dto.FlaggedDriver{
+		ID: d.DriverID,
+		Location: dto.GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: []float64{d.Longitude(), d.Latitude()},
+		},
+		UpdatedAt: d.UpdatedAt,
+		FlaggedAt: d.FlaggedAt,
+		Flags:     flags,
+	}
+}
+
+func toVehicleModel(v *dto.Vehicle) *models.Vehicle {
+	if v == nil {
+		return nil
+	}
+	return &models.Vehicle{
+		Type:                 v.Type,
+		Capacity:             v.Capacity,
+		WheelchairAccessible: v.WheelchairAccessible,
+		PetFriendly:          v.PetFriendly,
+	}
+}
+
+func toVehicleDTO(v *models.Vehicle) *dto.Vehicle {
+	if v == nil {
+		return nil
+	}
+	return &dto.Vehicle{
+		Type:                 v.Type,
+		Capacity:             v.Capacity,
+		WheelchairAccessible: v.WheelchairAccessible,
+		PetFriendly:          v.PetFriendly,
+	}
+}
+
+func toSearchFilter(r *dto.VehicleRequirements) models.SearchFilter {
+	if r == nil {
+		return models.SearchFilter{}
+	}
+	return models.SearchFilter{
+		VehicleTypes:         r.VehicleTypes,
+		MinCapacity:          r.MinCapacity,
+		WheelchairAccessible: r.WheelchairAccessible,
+		PetFriendly:          r.PetFriendly,
+	}
+}
+
+// toTime returns the UTC time of t, or the zero time when t is nil.
+func toTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
@@ -5,14 +5,18 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -41,14 +45,19 @@ func (m *MockService) CreateDriverLocationBulk(ctx context.Context, locations []
 	return nil, args.Error(1)
 }
 
-func (m *MockService) SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64) ([]*models.SearchResult, error) {
-	args := m.Called(ctx, latitude, longitude, radius)
+func (m *MockService) SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error) {
+	args := m.Called(ctx, latitude, longitude, radius, filter)
 	if args.Get(0) != nil {
 		return args.Get(0).([]*models.SearchResult), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
 
+func (m *MockService) CountDriverLocations(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) (int64, error) {
+	args := m.Called(ctx, latitude, longitude, radius, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockService) ImportDriverLocationsFromCSV(ctx context.Context, reader io.Reader) (*models.BulkResult, error) {
 	args := m.Called(ctx, reader)
 	if args.Get(0) != nil {
@@ -57,6 +66,14 @@ func (m *MockService) ImportDriverLocationsFromCSV(ctx context.Context, reader i
 	return nil, args.Error(1)
 }
 
+func (m *MockService) ListFlaggedDrivers(ctx context.Context) ([]*models.DriverLocation, error) {
+	args := m.Called(ctx)
+	if args.Get(0) != nil {
+		return args.Get(0).([]*models.DriverLocation), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
 // Test helpers
 func setupTestContext(method, path string, body io.Reader) (*gin.Context, *httptest.ResponseRecorder) {
 	recorder := httptest.NewRecorder()
@@ -71,6 +88,10 @@ func marshalJSON(t *testing.T, v interface{}) []byte {
 	return data
 }
 
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func unmarshalJSON(t *testing.T, data []byte, v interface{}) {
 	err := json.Unmarshal(data, v)
 	assert.NoError(t, err)
@@ -105,6 +126,134 @@ func TestLocationHandler_CreateDriverLocation(t *testing.T) {
 				assert.True(t, resp.Success)
 			},
 		},
+		{
+			name: "success - with vehicle",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				Vehicle:   &dto.Vehicle{Type: "comfort", Capacity: 4, PetFriendly: true},
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CreateDriverLocation", mock.Anything, mock.MatchedBy(func(loc *models.DriverLocation) bool {
+					return loc.Vehicle != nil && loc.Vehicle.Type == "comfort" && loc.Vehicle.PetFriendly
+				})).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name: "success - with motion",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				Heading:   ptr(0.0),
+				Speed:     ptr(12.5),
+				Accuracy:  ptr(8.0),
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CreateDriverLocation", mock.Anything, mock.MatchedBy(func(loc *models.DriverLocation) bool {
+					return *loc.Heading == 0 && *loc.Speed == 12.5 && *loc.Accuracy == 8
+				})).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name: "bad request - heading out of range",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				Heading:   ptr(360.0),
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "heading", resp.Details[0].Field)
+			},
+		},
+		{
+			name: "success - with driver attributes",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:       41.0,
+				Longitude:      29.0,
+				Rating:         ptr(4.8),
+				AcceptanceRate: ptr(0.9),
+				IdleSince:      ptr(time.Date(2026, 1, 1, 15, 0, 0, 0, time.FixedZone("TRT", 3*60*60))),
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CreateDriverLocation", mock.Anything, mock.MatchedBy(func(loc *models.DriverLocation) bool {
+					return *loc.Rating == 4.8 && *loc.AcceptanceRate == 0.9 &&
+						loc.IdleSince.Equal(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)) && loc.IdleSince.Location() == time.UTC
+				})).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name: "bad request - rating out of range",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				Rating:    ptr(5.5),
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "rating", resp.Details[0].Field)
+			},
+		},
+		{
+			name: "bad request - acceptance rate out of range",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:       41.0,
+				Longitude:      29.0,
+				AcceptanceRate: ptr(1.5),
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "acceptance_rate", resp.Details[0].Field)
+			},
+		},
+		{
+			name: "success - with driver",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				DriverID:  "d1",
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CreateDriverLocation", mock.Anything, mock.MatchedBy(func(loc *models.DriverLocation) bool {
+					return loc.DriverID == "d1"
+				})).Return(nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name: "implausible location",
+			requestBody: dto.CreateLocationRequest{
+				Latitude:  41.0,
+				Longitude: 29.0,
+				DriverID:  "d1",
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CreateDriverLocation", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("%w: moved 12000 m in 30s", service.ErrImplausibleLocation))
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "implausible_location", resp.Code)
+			},
+		},
 		{
 			name:               "bad request - invalid json",
 			requestBody:        "invalid json",
@@ -126,7 +275,7 @@ func TestLocationHandler_CreateDriverLocation(t *testing.T) {
 				var resp dto.ErrorResponse
 				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
 				assert.False(t, resp.Success)
-				assert.Equal(t, config.ErrInternalServer, resp.Error)
+				assert.Equal(t, apierror.ErrInternal.Message, resp.Error)
 			},
 		},
 	}
@@ -136,7 +285,7 @@ func TestLocationHandler_CreateDriverLocation(t *testing.T) {
 			// Setup
 			mockService := NewMockService()
 			tt.mockSetup(mockService)
-			handler := NewLocationHandler(mockService, logger)
+			handler := NewLocationHandler(mockService, &MockAuditService{}, logger)
 
 			// Prepare request body
 			var body io.Reader
@@ -167,12 +316,14 @@ func TestLocationHandler_CreateDriverLocationBulk(t *testing.T) {
 		requestBody        interface{}
 		mockSetup          func(*MockService)
 		expectedStatusCode int
+		expectedOutcome    string
+		expectedAudit      *models.BulkResult
 		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
 			name: "success",
 			requestBody: dto.CreateLocationBulkRequest{
-				Locations: []dto.CreateLocationRequest{
+				Locations: []dto.CreateLocationBulkItem{
 					{Latitude: 41.0, Longitude: 29.0},
 					{Latitude: 41.1, Longitude: 29.1},
 				},
@@ -188,6 +339,8 @@ func TestLocationHandler_CreateDriverLocationBulk(t *testing.T) {
 				})).Return(expectedResult, nil)
 			},
 			expectedStatusCode: http.StatusOK,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			expectedAudit:      &models.BulkResult{Total: 2, Successful: 2},
 			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				var resp dto.CreateLocationBulkResponse
 				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
@@ -195,6 +348,23 @@ func TestLocationHandler_CreateDriverLocationBulk(t *testing.T) {
 				assert.Equal(t, 2, resp.Data.Total)
 			},
 		},
+		{
+			name: "service error after a partial write",
+			requestBody: dto.CreateLocationBulkRequest{
+				Locations: []dto.CreateLocationBulkItem{
+					{Latitude: 41.0, Longitude: 29.0},
+					{Latitude: 41.1, Longitude: 29.1},
+				},
+			},
+			mockSetup: func(m *MockService) {
+				partial := &models.BulkResult{Total: 2, Successful: 1, Failed: 1}
+				m.On("CreateDriverLocationBulk", mock.Anything, mock.Anything).Return(partial, errors.New("db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			expectedAudit:      &models.BulkResult{Total: 2, Successful: 1, Failed: 1},
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
 		{
 			name:               "bad request - invalid json",
 			requestBody:        "invalid json",
@@ -209,7 +379,14 @@ func TestLocationHandler_CreateDriverLocationBulk(t *testing.T) {
 			// Setup
 			mockService := NewMockService()
 			tt.mockSetup(mockService)
-			handler := NewLocationHandler(mockService, logger)
+			mockAudit := &MockAuditService{}
+			if tt.expectedOutcome != "" {
+				mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+					return e.Operation == config.AuditOperationBatchCreate && e.Outcome == tt.expectedOutcome &&
+						assert.ObjectsAreEqual(tt.expectedAudit, e.Result)
+				})).Return(nil)
+			}
+			handler := NewLocationHandler(mockService, mockAudit, logger)
 
 			// Prepare request body
 			var body io.Reader
@@ -227,6 +404,7 @@ func TestLocationHandler_CreateDriverLocationBulk(t *testing.T) {
 			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
 			tt.assertResponse(t, recorder)
 			mockService.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
 		})
 	}
 }
@@ -255,7 +433,7 @@ func TestLocationHandler_SearchDriverLocation(t *testing.T) {
 				expectedResults := []*models.SearchResult{
 					{Latitude: 41.0, Longitude: 29.0, Distance: 100},
 				}
-				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0).Return(expectedResults, nil)
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{}).Return(expectedResults, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
@@ -265,6 +443,233 @@ func TestLocationHandler_SearchDriverLocation(t *testing.T) {
 				assert.Len(t, resp.Data.Locations, 1)
 			},
 		},
+		{
+			name: "success - vehicle requirements forwarded",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius: 10.0,
+				Requirements: &dto.VehicleRequirements{
+					VehicleTypes:         []string{"xl"},
+					MinCapacity:          6,
+					WheelchairAccessible: true,
+				},
+			},
+			mockSetup: func(m *MockService) {
+				expectedFilter := models.SearchFilter{
+					VehicleTypes:         []string{"xl"},
+					MinCapacity:          6,
+					WheelchairAccessible: true,
+				}
+				expectedResults := []*models.SearchResult{
+					{
+						Latitude:  41.0,
+						Longitude: 29.0,
+						Distance:  100,
+						Vehicle:   &models.Vehicle{Type: "xl", Capacity: 6, WheelchairAccessible: true},
+					},
+				}
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, expectedFilter).Return(expectedResults, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.True(t, resp.Success)
+				assert.Len(t, resp.Data.Locations, 1)
+				assert.Equal(t, "xl", resp.Data.Locations[0].Vehicle.Type)
+			},
+		},
+		{
+			name: "success - active flags included",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius: 10.0,
+			},
+			mockSetup: func(m *MockService) {
+				expectedResults := []*models.SearchResult{
+					{DriverID: "d1", Latitude: 41.0, Longitude: 29.0, Distance: 100, ActiveFlags: []string{"teleport"}},
+					{DriverID: "d2", Latitude: 41.0, Longitude: 29.0, Distance: 200},
+				}
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{}).Return(expectedResults, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, []string{"teleport"}, resp.Data.Locations[0].Flags)
+				assert.Empty(t, resp.Data.Locations[1].Flags)
+			},
+		},
+		{
+			name: "success - smoothed position",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSmoothed,
+			},
+			mockSetup: func(m *MockService) {
+				expectedResults := []*models.SearchResult{
+					{
+						DriverID:  "d1",
+						Latitude:  41.0,
+						Longitude: 29.0,
+						Distance:  20,
+						Smoothed:  &models.GeoJSON{Type: "Point", Coordinates: []float64{29.0001, 41.0001}},
+					},
+				}
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSmoothed}).Return(expectedResults, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, []float64{29.0001, 41.0001}, resp.Data.Locations[0].SmoothedLocation.Coordinates)
+				assert.Equal(t, []float64{29.0, 41.0}, resp.Data.Locations[0].Location.Coordinates)
+			},
+		},
+		{
+			name: "success - snapped position",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSnapped,
+			},
+			mockSetup: func(m *MockService) {
+				expectedResults := []*models.SearchResult{
+					{
+						DriverID:      "d1",
+						Latitude:      41.0,
+						Longitude:     29.0,
+						Distance:      20,
+						Snapped:       &models.GeoJSON{Type: "Point", Coordinates: []float64{29.0002, 41.0}},
+						RoadSegmentID: "a:b",
+					},
+				}
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSnapped}).Return(expectedResults, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, []float64{29.0002, 41.0}, resp.Data.Locations[0].SnappedLocation.Coordinates)
+				assert.Equal(t, []float64{29.0, 41.0}, resp.Data.Locations[0].Location.Coordinates)
+				assert.Equal(t, "a:b", resp.Data.Locations[0].RoadSegmentID)
+			},
+		},
+		{
+			name: "success - accuracy threshold and motion",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:      10.0,
+				MaxAccuracy: 50,
+			},
+			mockSetup: func(m *MockService) {
+				expectedResults := []*models.SearchResult{
+					{
+						DriverID: "d1", Latitude: 41.0, Longitude: 29.0, Distance: 20, Heading: ptr(270.0), Accuracy: ptr(12.0),
+						Rating: ptr(4.8), IdleSeconds: ptr(420.0), AcceptanceRate: ptr(0.9),
+					},
+				}
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{MaxAccuracy: 50}).Return(expectedResults, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, 270.0, *resp.Data.Locations[0].Heading)
+				assert.Equal(t, 12.0, *resp.Data.Locations[0].Accuracy)
+				assert.Nil(t, resp.Data.Locations[0].Speed)
+				assert.Equal(t, 4.8, *resp.Data.Locations[0].Rating)
+				assert.Equal(t, 420.0, *resp.Data.Locations[0].IdleSeconds)
+				assert.Equal(t, 0.9, *resp.Data.Locations[0].AcceptanceRate)
+			},
+		},
+		{
+			name: "bad request - smoothing disabled",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSmoothed,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSmoothed}).Return(nil, service.ErrSmoothingDisabled)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "position", resp.Details[0].Field)
+			},
+		},
+		{
+			name: "bad request - map matching disabled",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSnapped,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSnapped}).Return(nil, service.ErrMapMatchingDisabled)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "position", resp.Details[0].Field)
+				assert.Equal(t, "map_matching_disabled", resp.Details[0].Reason)
+			},
+		},
+		{
+			name: "bad request - unknown position",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: "predicted",
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name: "bad request - unknown vehicle type",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius: 10.0,
+				Requirements: &dto.VehicleRequirements{
+					VehicleTypes: []string{"spaceship"},
+				},
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
 		{
 			name: "not found - no results",
 			requestBody: dto.SearchLocationRequest{
@@ -275,7 +680,7 @@ func TestLocationHandler_SearchDriverLocation(t *testing.T) {
 				Radius: 10.0,
 			},
 			mockSetup: func(m *MockService) {
-				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0).Return([]*models.SearchResult{}, nil)
+				m.On("SearchDriverLocation", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{}).Return([]*models.SearchResult{}, nil)
 			},
 			expectedStatusCode: http.StatusNotFound,
 			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
@@ -287,7 +692,7 @@ func TestLocationHandler_SearchDriverLocation(t *testing.T) {
 			// Setup
 			mockService := NewMockService()
 			tt.mockSetup(mockService)
-			handler := NewLocationHandler(mockService, logger)
+			handler := NewLocationHandler(mockService, &MockAuditService{}, logger)
 
 			// Execute
 			body := bytes.NewBuffer(marshalJSON(t, tt.requestBody))
@@ -302,6 +707,114 @@ func TestLocationHandler_SearchDriverLocation(t *testing.T) {
 	}
 }
 
+func TestLocationHandler_CountDriverLocations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+
+	tests := []struct {
+		name               string
+		requestBody        interface{}
+		mockSetup          func(*MockService)
+		expectedStatusCode int
+		expectedCount      int64
+	}{
+		{
+			name: "success - more drivers than a search returns",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius: 10.0,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CountDriverLocations", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{}).Return(int64(250), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedCount:      250,
+		},
+		{
+			name: "success - no drivers",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSnapped,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CountDriverLocations", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSnapped}).Return(int64(0), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "bad request - smoothing disabled",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius:   10.0,
+				Position: config.PositionSmoothed,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CountDriverLocations", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{Position: config.PositionSmoothed}).Return(int64(0), service.ErrSmoothingDisabled)
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "bad request - missing radius",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+			},
+			mockSetup:          func(m *MockService) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "internal error",
+			requestBody: dto.SearchLocationRequest{
+				Location: dto.GeoJSONPoint{
+					Type:        "Point",
+					Coordinates: []float64{29.0, 41.0},
+				},
+				Radius: 10.0,
+			},
+			mockSetup: func(m *MockService) {
+				m.On("CountDriverLocations", mock.Anything, 41.0, 29.0, 10.0, models.SearchFilter{}).Return(int64(0), errors.New("database error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := NewMockService()
+			tt.mockSetup(mockService)
+			handler := NewLocationHandler(mockService, &MockAuditService{}, logger)
+
+			// Execute
+			body := bytes.NewBuffer(marshalJSON(t, tt.requestBody))
+			ctx, recorder := setupTestContext(http.MethodPost, "/locations/count", body)
+			handler.countDriverLocations(ctx)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			if tt.expectedStatusCode == http.StatusOK {
+				var resp dto.CountLocationResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.True(t, resp.Success)
+				assert.Equal(t, tt.expectedCount, resp.Data.Count)
+			}
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
 func TestLocationHandler_ImportDriverLocations(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	logger := zap.NewNop()
@@ -311,6 +824,8 @@ func TestLocationHandler_ImportDriverLocations(t *testing.T) {
 		csvData            string
 		mockSetup          func(*MockService)
 		expectedStatusCode int
+		expectedOutcome    string
+		expectedAudit      *models.BulkResult
 		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
 	}{
 		{
@@ -325,6 +840,8 @@ func TestLocationHandler_ImportDriverLocations(t *testing.T) {
 				m.On("ImportDriverLocationsFromCSV", mock.Anything, mock.Anything).Return(expectedResult, nil)
 			},
 			expectedStatusCode: http.StatusOK,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			expectedAudit:      &models.BulkResult{Total: 10, Successful: 10},
 			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
 				var resp dto.ImportLocationCSVResponse
 				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
@@ -332,6 +849,37 @@ func TestLocationHandler_ImportDriverLocations(t *testing.T) {
 				assert.Equal(t, 10, resp.Data.Total)
 			},
 		},
+		{
+			name:    "bad request - invalid csv",
+			csvData: "lat,lon\nnorth,29",
+			mockSetup: func(m *MockService) {
+				m.On("ImportDriverLocationsFromCSV", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: failed to parse latitude", service.ErrInvalidCSV))
+			},
+			expectedStatusCode: http.StatusBadRequest,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "invalid_csv", resp.Code)
+				assert.Equal(t, config.ErrInvalidCSV, resp.Error)
+			},
+		},
+		{
+			name:    "service error after a partial write",
+			csvData: "lat,lon\n41,29\n41.1,29.1",
+			mockSetup: func(m *MockService) {
+				partial := &models.BulkResult{Total: 2, Successful: 1, Failed: 1}
+				m.On("ImportDriverLocationsFromCSV", mock.Anything, mock.Anything).Return(partial, errors.New("db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			expectedAudit:      &models.BulkResult{Total: 2, Successful: 1, Failed: 1},
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "internal_error", resp.Code)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -339,13 +887,85 @@ func TestLocationHandler_ImportDriverLocations(t *testing.T) {
 			// Setup
 			mockService := NewMockService()
 			tt.mockSetup(mockService)
-			handler := NewLocationHandler(mockService, logger)
+			mockAudit := &MockAuditService{}
+			if tt.expectedOutcome != "" {
+				mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+					return e.Operation == config.AuditOperationImport && e.Outcome == tt.expectedOutcome &&
+						assert.ObjectsAreEqual(tt.expectedAudit, e.Result)
+				})).Return(nil)
+			}
+			handler := NewLocationHandler(mockService, mockAudit, logger)
 
 			// Execute
 			body := bytes.NewBufferString(tt.csvData)
 			ctx, recorder := setupTestContext(http.MethodPost, "/locations/import", body)
 			handler.importDriverLocations(ctx)
 
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			tt.assertResponse(t, recorder)
+			mockService.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLocationHandler_ListFlaggedDrivers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zap.NewNop()
+	detectedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		mockSetup          func(*MockService)
+		expectedStatusCode int
+		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name: "success",
+			mockSetup: func(m *MockService) {
+				driver := models.NewDriverLocation(41.0, 29.0)
+				driver.DriverID = "d1"
+				driver.FlaggedAt = detectedAt
+				driver.Flags = map[string]models.DriverFlag{
+					"teleport":          {Detail: "moved 12000 m in 30s", DetectedAt: detectedAt, Count: 2},
+					"implausible_speed": {Detail: "1440 km/h between updates", DetectedAt: detectedAt, Count: 2},
+				}
+				m.On("ListFlaggedDrivers", mock.Anything).Return([]*models.DriverLocation{driver}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.FlaggedDriversResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Len(t, resp.Data, 1)
+				assert.Equal(t, "d1", resp.Data[0].ID)
+				assert.Equal(t, []float64{29.0, 41.0}, resp.Data[0].Location.Coordinates)
+				assert.Len(t, resp.Data[0].Flags, 2)
+				assert.Equal(t, "implausible_speed", resp.Data[0].Flags[0].Type)
+				assert.Equal(t, 2, resp.Data[0].Flags[1].Count)
+			},
+		},
+		{
+			name: "service error",
+			mockSetup: func(m *MockService) {
+				m.On("ListFlaggedDrivers", mock.Anything).Return(nil, errors.New("db error"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := NewMockService()
+			tt.mockSetup(mockService)
+			handler := NewLocationHandler(mockService, &MockAuditService{}, logger)
+
+			// Execute
+			ctx, recorder := setupTestContext(http.MethodGet, "/drivers/flagged", nil)
+			handler.listFlaggedDrivers(ctx)
+
 			// Assert
 			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
 			tt.assertResponse(t, recorder)
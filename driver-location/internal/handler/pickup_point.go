@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type PickupPointHandler struct {
+	points service.PickupPointService
+	audit  service.AuditService
+	logger *zap.Logger
+}
+
+func NewPickupPointHandler(points service.PickupPointService, audit service.AuditService, logger *zap.Logger) *PickupPointHandler {
+	return &PickupPointHandler{points: points, audit: audit, logger: logger}
+}
+
+func (h *PickupPointHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/pickup-points", middleware.RequireScope(config.ScopeLocationsRead), h.listPickupPoints)
+	r.POST("/pickup-points/search", middleware.RequireScope(config.ScopeLocationsRead), h.searchPickupPoints)
+	r.POST("/pickup-points", middleware.RequireScope(config.ScopePickupPointsWrite), h.createPickupPoint)
+	r.PUT("/pickup-points/:id", middleware.RequireScope(config.ScopePickupPointsWrite), h.updatePickupPoint)
+	r.DELETE("/pickup-points/:id", middleware.RequireScope(config.ScopePickupPointsWrite), h.deletePickupPoint)
+}
+
+// @Summary List pickup points
+// @Description Lists the curated pickup points ordered by name, up to 1000.
+// @Tags pickup-points
+// @Produce json
+// @Param zone query string false "Only list the points of this zone"
+// @Success 200 {object} dto.PickupPointListResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/pickup-points [get]
+func (h *PickupPointHandler) listPickupPoints(c *gin.Context) {
+	points, err := h.points.ListPickupPoints(c.Request.Context(), c.Query("zone"))
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to list pickup points", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	data := make([]dto.PickupPoint, len(points))
+	for i, p := range points {
+		data[i] = toPickupPointDTO(p)
+	}
+
+	c.JSON(http.StatusOK, dto.PickupPointListResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// @Summary Search pickup points
+// @Description Returns the pickup points within radius meters of a GeoJSON point, nearest first, up to 20. An empty list means there is no pickup point nearby.
+// @Tags pickup-points
+// @Accept json
+// @Produce json
+// @Param request body dto.SearchPickupPointsRequest true "Search pickup points request"
+// @Success 200 {object} dto.SearchPickupPointsResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/pickup-points/search [post]
+func (h *PickupPointHandler) searchPickupPoints(c *gin.Context) {
+	var req dto.SearchPickupPointsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	lon := req.Location.Coordinates[0]
+	lat := req.Location.Coordinates[1]
+	points, err := h.points.SearchPickupPoints(c.Request.Context(), lat, lon, req.Radius, req.Zone)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to search pickup points", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	data := make([]dto.NearbyPickupPoint, len(points))
+	for i, p := range points {
+		data[i] = dto.NearbyPickupPoint{
+			PickupPoint: toPickupPointDTO(&p.PickupPoint),
+			Distance:    p.Distance,
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.SearchPickupPointsResponse{
+		Success: true,
+		Data:    data,
+	})
+}
+
+// @Summary Create a pickup point
+// @Description Adds a curated pickup point that matching can move riders to.
+// @Tags pickup-points
+// @Accept json
+// @Produce json
+// @Param request body dto.PickupPointRequest true "Pickup point"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
+// @Success 201 {object} dto.PickupPointResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/pickup-points [post]
+func (h *PickupPointHandler) createPickupPoint(c *gin.Context) {
+	point, ok := bindPickupPoint(c)
+	if !ok {
+		return
+	}
+
+	err := h.points.CreatePickupPoint(c.Request.Context(), point)
+	recordAudit(c, h.audit, h.logger, config.AuditOperationPickupPointCreate, point.ID, nil, err)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("Failed to create pickup point", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.PickupPointResponse{
+		Success: true,
+		Data:    toPickupPointDTO(point),
+	})
+}
+
+// @Summary Update a pickup point
+// @Description Replaces the name, zone and location of a pickup point.
+// @Tags pickup-points
+// @Accept json
+// @Produce json
+// @Param id path string true "Pickup point ID"
+// @Param request body dto.PickupPointRequest true "Pickup point"
+// @Success 200 {object} dto.PickupPointResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/pickup-points/{id} [put]
+func (h *PickupPointHandler) updatePickupPoint(c *gin.Context) {
+	point, ok := bindPickupPoint(c)
+	if !ok {
+		return
+	}
+	point.ID = c.Param("id")
+
+	updated, err := h.points.UpdatePickupPoint(c.Request.Context(), point)
+	recordAudit(c, h.audit, h.logger, config.AuditOperationPickupPointUpdate, point.ID, nil, err)
+	if err != nil {
+		h.respondPickupPointError(c, "Failed to update pickup point", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PickupPointResponse{
+		Success: true,
+		Data:    toPickupPointDTO(updated),
+	})
+}
+
+// @Summary Delete a pickup point
+// @Description Deletes a pickup point
+// @Tags pickup-points
+// @Produce json
+// @Param id path string true "Pickup point ID"
+// @Success 200 {object} dto.PickupPointResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Security ApiKeyAuth
+// @Router /api/v1/pickup-points/{id} [delete]
+func (h *PickupPointHandler) deletePickupPoint(c *gin.Context) {
+	point, err := h.points.DeletePickupPoint(c.Request.Context(), c.Param("id"))
+	recordAudit(c, h.audit, h.logger, config.AuditOperationPickupPointDelete, c.Param("id"), nil, err)
+	if err != nil {
+		h.respondPickupPointError(c, "Failed to delete pickup point", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.PickupPointResponse{
+		Success: true,
+		Data:    toPickupPointDTO(point),
+	})
+}
+
+func (h *PickupPointHandler) respondPickupPointError(c *gin.Context, msg string, err error) {
+	if errors.Is(err, repository.ErrPickupPointNotFound) {
+		apierror.Respond(c, apierror.ErrPickupPointNotFound)
+		return
+	}
+	logging.FromContext(c.Request.Context(), h.logger).Error(msg, zap.Error(err))
+	apierror.Respond(c, apierror.ErrInternal)
+}
+
+// bindPickupPoint reads a pickup point from the request body. It responds
+// with a validation error and returns false when the body is invalid.
+func bindPickupPoint(c *gin.Context) (*models.PickupPoint, bool) {
+	var req dto.PickupPointRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return nil, false
+	}
+
+	lon := req.Location.Coordinates[0]
+	lat := req.Location.Coordinates[1]
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		apierror.Respond(c, apierror.ErrValidationFailed, dto.FieldError{
+			Field:   "location.coordinates",
+			Reason:  "range",
+			Message: "must be a longitude between -180 and 180 followed by a latitude between -90 and 90",
+		})
+		return nil, false
+	}
+
+	return &models.PickupPoint{
+		Name:     req.Name,
+		Zone:     req.Zone,
+		Location: models.GeoJSON{Type: "Point", Coordinates: []float64{lon, lat}},
+	}, true
+}
+
+func toPickupPointDTO(p *models.PickupPoint) dto.PickupPoint {
+	return dto.PickupPoint{
+		ID:   p.ID,
+		Name: p.Name,
+		Zone: p.Zone,
+		Location: dto.GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: p.Location.Coordinates,
+		},
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
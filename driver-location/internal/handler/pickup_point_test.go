@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+)
+
+// MockPickupPointService implements service.PickupPointService for testing
+type MockPickupPointService struct {
+	mock.Mock
+}
+
+func (m *MockPickupPointService) CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	args := m.Called(ctx, point)
+	point.ID = "p1"
+	return args.Error(0)
+}
+
+func (m *MockPickupPointService) ListPickupPoints(ctx context.Context, zone string) ([]*models.PickupPoint, error) {
+	args := m.Called(ctx, zone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointService) UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) (*models.PickupPoint, error) {
+	args := m.Called(ctx, point)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointService) DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointService) SearchPickupPoints(ctx context.Context, latitude, longitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error) {
+	args := m.Called(ctx, latitude, longitude, radius, zone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NearbyPickupPoint), args.Error(1)
+}
+
+func testPickupPoint() *models.PickupPoint {
+	return &models.PickupPoint{
+		ID:       "p1",
+		Name:     "Terminal 1 Gate 4",
+		Zone:     "airport",
+		Location: models.GeoJSON{Type: "Point", Coordinates: []float64{28.81, 40.98}},
+	}
+}
+
+// setupPickupPointRouter serves the pickup point routes as a caller with the given scopes.
+func setupPickupPointRouter(points service.PickupPointService, audit service.AuditService, scopes ...string) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(config.APIKeyContextKey, &models.APIKey{ID: "caller", Scopes: scopes})
+	})
+	NewPickupPointHandler(points, audit, zap.NewNop()).RegisterRoutes(router.Group("/"))
+	return router
+}
+
+func TestPickupPointHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// isTestPoint matches the point described by the request bodies below.
+	isTestPoint := mock.MatchedBy(func(p *models.PickupPoint) bool {
+		return p.Name == "Terminal 1 Gate 4" && p.Zone == "airport" && p.Location.Coordinates[0] == 28.81 && p.Location.Coordinates[1] == 40.98
+	})
+	const pointBody = `{"name":"Terminal 1 Gate 4","zone":"airport","location":{"type":"Point","coordinates":[28.81,40.98]}}`
+
+	tests := []struct {
+		name               string
+		method             string
+		path               string
+		body               string
+		scopes             []string
+		mockSetup          func(*MockPickupPointService)
+		expectedStatusCode int
+		expectedAudit      string
+		expectedOutcome    string
+		assertResponse     func(*testing.T, *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "create - success",
+			method: http.MethodPost,
+			path:   "/pickup-points",
+			body:   pointBody,
+			scopes: []string{config.ScopePickupPointsWrite},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("CreatePickupPoint", mock.Anything, isTestPoint).Return(nil)
+			},
+			expectedStatusCode: http.StatusCreated,
+			expectedAudit:      config.AuditOperationPickupPointCreate,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.PickupPointResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "p1", resp.Data.ID)
+				assert.Equal(t, "airport", resp.Data.Zone)
+			},
+		},
+		{
+			name:               "create - missing name",
+			method:             http.MethodPost,
+			path:               "/pickup-points",
+			body:               `{"location":{"type":"Point","coordinates":[28.81,40.98]}}`,
+			scopes:             []string{config.ScopePickupPointsWrite},
+			mockSetup:          func(m *MockPickupPointService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "name", resp.Details[0].Field)
+			},
+		},
+		{
+			name:               "create - coordinates out of range",
+			method:             http.MethodPost,
+			path:               "/pickup-points",
+			body:               `{"name":"Gate","location":{"type":"Point","coordinates":[40.98,128.81]}}`,
+			scopes:             []string{config.ScopePickupPointsWrite},
+			mockSetup:          func(m *MockPickupPointService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "location.coordinates", resp.Details[0].Field)
+			},
+		},
+		{
+			name:               "create - missing write scope",
+			method:             http.MethodPost,
+			path:               "/pickup-points",
+			body:               pointBody,
+			scopes:             []string{config.ScopeLocationsRead},
+			mockSetup:          func(m *MockPickupPointService) {},
+			expectedStatusCode: http.StatusForbidden,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "update - success",
+			method: http.MethodPut,
+			path:   "/pickup-points/p1",
+			body:   pointBody,
+			scopes: []string{config.ScopePickupPointsWrite},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("UpdatePickupPoint", mock.Anything, mock.MatchedBy(func(p *models.PickupPoint) bool {
+					return p.ID == "p1" && p.Name == "Terminal 1 Gate 4"
+				})).Return(testPickupPoint(), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedAudit:      config.AuditOperationPickupPointUpdate,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+		{
+			name:   "update - not found",
+			method: http.MethodPut,
+			path:   "/pickup-points/p1",
+			body:   pointBody,
+			scopes: []string{config.ScopePickupPointsWrite},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("UpdatePickupPoint", mock.Anything, mock.Anything).Return(nil, repository.ErrPickupPointNotFound)
+			},
+			expectedStatusCode: http.StatusNotFound,
+			expectedAudit:      config.AuditOperationPickupPointUpdate,
+			expectedOutcome:    config.AuditOutcomeFailed,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.ErrorResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "pickup_point_not_found", resp.Code)
+			},
+		},
+		{
+			name:   "delete - success",
+			method: http.MethodDelete,
+			path:   "/pickup-points/p1",
+			scopes: []string{config.ScopePickupPointsWrite},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("DeletePickupPoint", mock.Anything, "p1").Return(testPickupPoint(), nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedAudit:      config.AuditOperationPickupPointDelete,
+			expectedOutcome:    config.AuditOutcomeSucceeded,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.PickupPointResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Equal(t, "p1", resp.Data.ID)
+			},
+		},
+		{
+			name:   "list - by zone",
+			method: http.MethodGet,
+			path:   "/pickup-points?zone=airport",
+			scopes: []string{config.ScopeLocationsRead},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("ListPickupPoints", mock.Anything, "airport").Return([]*models.PickupPoint{testPickupPoint()}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.PickupPointListResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Len(t, resp.Data, 1)
+			},
+		},
+		{
+			name:   "search - success",
+			method: http.MethodPost,
+			path:   "/pickup-points/search",
+			body:   `{"location":{"type":"Point","coordinates":[28.8101,40.9801]},"radius":150}`,
+			scopes: []string{config.ScopeLocationsRead},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("SearchPickupPoints", mock.Anything, 40.9801, 28.8101, 150.0, "").
+					Return([]*models.NearbyPickupPoint{{PickupPoint: *testPickupPoint(), Distance: 14}}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchPickupPointsResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.Len(t, resp.Data, 1)
+				assert.Equal(t, 14.0, resp.Data[0].Distance)
+				assert.Equal(t, "Terminal 1 Gate 4", resp.Data[0].Name)
+			},
+		},
+		{
+			name:   "search - none nearby",
+			method: http.MethodPost,
+			path:   "/pickup-points/search",
+			body:   `{"location":{"type":"Point","coordinates":[28.8101,40.9801]},"radius":150,"zone":"airport"}`,
+			scopes: []string{config.ScopeLocationsRead},
+			mockSetup: func(m *MockPickupPointService) {
+				m.On("SearchPickupPoints", mock.Anything, 40.9801, 28.8101, 150.0, "airport").Return([]*models.NearbyPickupPoint{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			assertResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				var resp dto.SearchPickupPointsResponse
+				unmarshalJSON(t, recorder.Body.Bytes(), &resp)
+				assert.True(t, resp.Success)
+				assert.Empty(t, resp.Data)
+			},
+		},
+		{
+			name:               "search - radius too large",
+			method:             http.MethodPost,
+			path:               "/pickup-points/search",
+			body:               `{"location":{"type":"Point","coordinates":[28.8101,40.9801]},"radius":5000}`,
+			scopes:             []string{config.ScopeLocationsRead},
+			mockSetup:          func(m *MockPickupPointService) {},
+			expectedStatusCode: http.StatusBadRequest,
+			assertResponse:     func(t *testing.T, recorder *httptest.ResponseRecorder) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockService := &MockPickupPointService{}
+			tt.mockSetup(mockService)
+			mockAudit := &MockAuditService{}
+			if tt.expectedAudit != "" {
+				mockAudit.On("Record", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+					return e.Operation == tt.expectedAudit && e.Outcome == tt.expectedOutcome && e.Actor == "caller" && e.Target == "p1"
+				})).Return(nil)
+			}
+			router := setupPickupPointRouter(mockService, mockAudit, tt.scopes...)
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			tt.assertResponse(t, recorder)
+			mockService.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
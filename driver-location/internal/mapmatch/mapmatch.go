@@ -0,0 +1,271 @@
+// Package mapmatch snaps driver locations to the nearest segment of a road
+// network, so that drivers reported inside buildings or beside the road are
+// placed on it, and on the carriageway they are driving along.
+package mapmatch
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// cellSize is the edge length, in degrees, of the grid used to find the nearest segment.
+const cellSize = 0.01
+
+// minHeadingSpeed is the speed, in metres per second, below which a reported
+// heading is too unreliable to choose between carriageways.
+const minHeadingSpeed = 1.0
+
+var ErrEmptyNetwork = errors.New("road network has no segments")
+
+type segment struct {
+	id      string
+	fromLat float64
+	fromLon float64
+	toLat   float64
+	toLon   float64
+	oneway  bool
+}
+
+type cell struct {
+	x int
+	y int
+}
+
+// Network is an in-memory road network indexed for nearest segment lookups.
+type Network struct {
+	segments []segment
+	grid     map[cell][]int
+}
+
+// SegmentID returns the ID of the segment from one road node to another.
+// The matching service derives the same IDs from the same file.
+func SegmentID(fromID, toID string) string {
+	return fromID + ":" + toID
+}
+
+// LoadNetwork reads a road network from an edge list file.
+func LoadNetwork(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open road network: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ParseNetwork(f)
+}
+
+// ParseNetwork reads a road network from CSV with the header
+//
+//	from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+//
+// which is the road graph format of the matching service. Speeds are not
+// needed for snapping and are ignored.
+func ParseNetwork(r io.Reader) (*Network, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 8
+
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read road network header: %w", err)
+	}
+
+	n := &Network{grid: make(map[cell][]int)}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read road network: %w", err)
+		}
+
+		values, err := parseFloats(record[1], record[2], record[4], record[5])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		n.add(segment{
+			id:      SegmentID(strings.TrimSpace(record[0]), strings.TrimSpace(record[3])),
+			fromLat: values[0],
+			fromLon: values[1],
+			toLat:   values[2],
+			toLon:   values[3],
+			oneway:  strings.EqualFold(strings.TrimSpace(record[7]), "true"),
+		})
+	}
+
+	if len(n.segments) == 0 {
+		return nil, ErrEmptyNetwork
+	}
+
+	return n, nil
+}
+
+// Segments returns the number of segments in the network.
+func (n *Network) Segments() int {
+	return len(n.segments)
+}
+
+// add indexes a segment in every cell its bounding box overlaps, so that
+// every point of the segment is found from the cell it lies in.
+func (n *Network) add(s segment) {
+	i := len(n.segments)
+	n.segments = append(n.segments, s)
+
+	lo := cellOf(math.Min(s.fromLat, s.toLat), math.Min(s.fromLon, s.toLon))
+	hi := cellOf(math.Max(s.fromLat, s.toLat), math.Max(s.fromLon, s.toLon))
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			c := cell{x: x, y: y}
+			n.grid[c] = append(n.grid[c], i)
+		}
+	}
+}
+
+// Match is a point snapped onto a road segment.
+type Match struct {
+	Latitude  float64
+	Longitude float64
+	SegmentID string
+	// Distance is how far, in metres, the point was moved.
+	Distance float64
+}
+
+// Snap returns the closest point on the road network to lat, lon, or false
+// when no segment lies within maxDistance metres. When heading is not nil,
+// one-way segments running against it are skipped, which keeps a driver on
+// its own side of a dual carriageway.
+func (n *Network) Snap(lat, lon float64, heading *float64, maxDistance float64) (Match, bool) {
+	origin := cellOf(lat, lon)
+	metersPerLonDegree := geo.MetersPerLonDegree(lat)
+	rows, cols := searchExtent(maxDistance, metersPerLonDegree)
+	seen := make(map[int]bool)
+	best := Match{Distance: math.Inf(1)}
+
+	for x := origin.x - cols; x <= origin.x+cols; x++ {
+		for y := origin.y - rows; y <= origin.y+rows; y++ {
+			for _, i := range n.grid[cell{x: x, y: y}] {
+				if seen[i] {
+					continue
+				}
+				seen[i] = true
+
+				s := n.segments[i]
+				// Positions are handled in metres north and east of the point.
+				ax := (s.fromLon - lon) * metersPerLonDegree
+				ay := (s.fromLat - lat) * geo.MetersPerDegree
+				bx := (s.toLon - lon) * metersPerLonDegree
+				by := (s.toLat - lat) * geo.MetersPerDegree
+
+				if heading != nil && s.oneway && against(*heading, bx-ax, by-ay) {
+					continue
+				}
+
+				x, y := project(ax, ay, bx, by)
+				if d := math.Hypot(x, y); d < best.Distance {
+					best = Match{
+						Latitude:  lat + y/geo.MetersPerDegree,
+						Longitude: lon + x/metersPerLonDegree,
+						SegmentID: s.id,
+						Distance:  d,
+					}
+				}
+			}
+		}
+	}
+
+	if best.Distance > maxDistance {
+		return Match{}, false
+	}
+	return best, true
+}
+
+// searchExtent returns how many grid rows and columns on each side of a
+// point must be scanned to find every segment within maxDistance metres.
+// Columns narrow toward the poles, so their number is capped at half the
+// globe, which is reached at the poles themselves.
+func searchExtent(maxDistance, metersPerLonDegree float64) (rows, cols int) {
+	rows = int(math.Ceil(maxDistance / (cellSize * geo.MetersPerDegree)))
+	cols = int(math.Ceil(math.Min(maxDistance/(cellSize*metersPerLonDegree), 180/cellSize)))
+	return rows, cols
+}
+
+// project returns the point of the segment from a to b closest to the origin.
+func project(ax, ay, bx, by float64) (float64, float64) {
+	dx, dy := bx-ax, by-ay
+	length := dx*dx + dy*dy
+	if length == 0 {
+		return ax, ay
+	}
+	t := math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	return ax + t*dx, ay + t*dy
+}
+
+// against reports whether heading, in degrees clockwise from north, points
+// away from the direction east, north by more than a right angle.
+func against(heading, east, north float64) bool {
+	direction := math.Atan2(east, north)
+	return math.Cos(heading*math.Pi/180-direction) < 0
+}
+
+// Matcher snaps the locations reported by drivers to the road network.
+type Matcher struct {
+	network     *Network
+	maxDistance float64
+}
+
+// NewMatcher creates a matcher that leaves locations further than
+// maxDistance metres from every road where they are.
+func NewMatcher(network *Network, maxDistance float64) *Matcher {
+	return &Matcher{network: network, maxDistance: maxDistance}
+}
+
+// Match sets the snapped location and road segment of location. The smoothed
+// location is snapped when there is one, since it jumps between carriageways
+// less than the raw fix. Locations off the road network keep their position
+// as snapped location, without a segment, so that snapped searches find them.
+func (m *Matcher) Match(location *models.DriverLocation) {
+	position := location.Location
+	if location.SmoothedLocation != nil {
+		position = *location.SmoothedLocation
+	}
+	lat, lon := position.Coordinates[1], position.Coordinates[0]
+
+	var heading *float64
+	if location.Speed == nil || *location.Speed >= minHeadingSpeed {
+		heading = location.Heading
+	}
+
+	match, ok := m.network.Snap(lat, lon, heading, m.maxDistance)
+	if !ok {
+		location.SnappedLocation = &models.GeoJSON{Type: "Point", Coordinates: []float64{lon, lat}}
+		location.RoadSegmentID = ""
+		return
+	}
+	location.SnappedLocation = &models.GeoJSON{Type: "Point", Coordinates: []float64{match.Longitude, match.Latitude}}
+	location.RoadSegmentID = match.SegmentID
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{x: int(math.Floor(lon / cellSize)), y: int(math.Floor(lat / cellSize))}
+}
+
+func parseFloats(values ...string) ([]float64, error) {
+	result := make([]float64, len(values))
+	for i, v := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %w", v, err)
+		}
+		result[i] = f
+	}
+	return result, nil
+}
@@ -0,0 +1,289 @@
+package mapmatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// testNetwork is a dual carriageway running east along latitude 41, whose
+// eastbound and westbound roads lie about 22 m apart, and a two-way side
+// street leaving it to the north.
+const testNetwork = `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+e1,41.0000,29.000,e2,41.0000,29.020,70,true
+w2,41.0002,29.020,w1,41.0002,29.000,70,true
+s1,41.0002,29.010,s2,41.0100,29.010,30,false`
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func parseTestNetwork(t *testing.T) *Network {
+	n, err := ParseNetwork(strings.NewReader(testNetwork))
+	require.NoError(t, err)
+	return n
+}
+
+func TestParseNetwork(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		expectedSegments int
+		expectedError    bool
+	}{
+		{
+			name:             "success",
+			input:            testNetwork,
+			expectedSegments: 3,
+		},
+		{
+			name:          "failure - no segments",
+			input:         `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway`,
+			expectedError: true,
+		},
+		{
+			name: "failure - invalid coordinate",
+			input: `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,north,29.0,b,41.0,29.01,50,false`,
+			expectedError: true,
+		},
+		{
+			name: "failure - missing column",
+			input: `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,41.0,29.0,b,41.0,29.01,50`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			n, err := ParseNetwork(strings.NewReader(tt.input))
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedSegments, n.Segments())
+		})
+	}
+}
+
+func TestNetwork_Snap(t *testing.T) {
+	n := parseTestNetwork(t)
+
+	tests := []struct {
+		name              string
+		lat               float64
+		lon               float64
+		heading           *float64
+		expectedOK        bool
+		expectedSegment   string
+		expectedLatitude  float64
+		expectedLongitude float64
+	}{
+		{
+			name:              "nearest carriageway without a heading",
+			lat:               40.99995,
+			lon:               29.005,
+			expectedOK:        true,
+			expectedSegment:   "e1:e2",
+			expectedLatitude:  41.0,
+			expectedLongitude: 29.005,
+		},
+		{
+			name:              "heading selects the westbound carriageway",
+			lat:               40.99995,
+			lon:               29.005,
+			heading:           ptr(270),
+			expectedOK:        true,
+			expectedSegment:   "w2:w1",
+			expectedLatitude:  41.0002,
+			expectedLongitude: 29.005,
+		},
+		{
+			name:              "two-way street in either direction",
+			lat:               41.005,
+			lon:               29.0102,
+			heading:           ptr(180),
+			expectedOK:        true,
+			expectedSegment:   "s1:s2",
+			expectedLatitude:  41.005,
+			expectedLongitude: 29.010,
+		},
+		{
+			name:              "past the end of a segment",
+			lat:               40.99995,
+			lon:               29.0205,
+			expectedOK:        true,
+			expectedSegment:   "e1:e2",
+			expectedLatitude:  41.0,
+			expectedLongitude: 29.020,
+		},
+		{
+			name:       "too far from every road",
+			lat:        41.005,
+			lon:        29.003,
+			expectedOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			match, ok := n.Snap(tt.lat, tt.lon, tt.heading, 50)
+
+			// Assert
+			require.Equal(t, tt.expectedOK, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, tt.expectedSegment, match.SegmentID)
+			assert.InDelta(t, tt.expectedLatitude, match.Latitude, 1e-7)
+			assert.InDelta(t, tt.expectedLongitude, match.Longitude, 1e-7)
+			assert.Less(t, match.Distance, 50.0)
+		})
+	}
+}
+
+func TestNetwork_Snap_AcrossCells(t *testing.T) {
+	// Setup: a long road whose endpoints are several grid cells away.
+	n, err := ParseNetwork(strings.NewReader(`from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,41.0,29.0,b,41.0,29.1,50,false`))
+	require.NoError(t, err)
+
+	// Execute
+	match, ok := n.Snap(41.0001, 29.055, nil, 50)
+
+	// Assert
+	require.True(t, ok)
+	assert.Equal(t, "a:b", match.SegmentID)
+	assert.InDelta(t, 11.1, match.Distance, 0.1)
+}
+
+func TestNetwork_Snap_NearThePoles(t *testing.T) {
+	// Setup: a road crossing the meridian just south of the north pole.
+	n, err := ParseNetwork(strings.NewReader(`from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,89.9996,-10,b,89.9996,10,50,false`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		lat        float64
+		lon        float64
+		expectedOK bool
+	}{
+		{name: "north pole", lat: 90, lon: 0, expectedOK: true},
+		{name: "south pole", lat: -90, lon: 0, expectedOK: false},
+		{name: "near the north pole", lat: 89.99, lon: 0, expectedOK: false},
+		{name: "near the south pole", lat: -89.99, lon: 0, expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			match, ok := n.Snap(tt.lat, tt.lon, nil, 50)
+
+			// Assert
+			require.Equal(t, tt.expectedOK, ok)
+			if ok {
+				assert.Equal(t, "a:b", match.SegmentID)
+				assert.Less(t, match.Distance, 50.0)
+			}
+		})
+	}
+}
+
+func TestSearchExtent(t *testing.T) {
+	rows, cols := searchExtent(50, geo.MetersPerDegree)
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, 1, cols)
+
+	// At the pole a degree of longitude has no length; the columns stop at half the globe.
+	rows, cols = searchExtent(50, 0)
+	assert.Equal(t, 1, rows)
+	assert.Equal(t, 18000, cols)
+}
+
+func TestMatcher_Match(t *testing.T) {
+	m := NewMatcher(parseTestNetwork(t), 50)
+
+	tests := []struct {
+		name            string
+		location        func() *models.DriverLocation
+		expectedSegment string
+		expectedLatLon  []float64
+	}{
+		{
+			name: "heading of a moving driver",
+			location: func() *models.DriverLocation {
+				l := models.NewDriverLocation(40.99995, 29.005)
+				l.Heading, l.Speed = ptr(270), ptr(10)
+				return l
+			},
+			expectedSegment: "w2:w1",
+			expectedLatLon:  []float64{29.005, 41.0002},
+		},
+		{
+			name: "heading of a stopped driver is ignored",
+			location: func() *models.DriverLocation {
+				l := models.NewDriverLocation(40.99995, 29.005)
+				l.Heading, l.Speed = ptr(270), ptr(0)
+				return l
+			},
+			expectedSegment: "e1:e2",
+			expectedLatLon:  []float64{29.005, 41.0},
+		},
+		{
+			name: "smoothed location is snapped",
+			location: func() *models.DriverLocation {
+				l := models.NewDriverLocation(40.99995, 29.005)
+				l.SmoothedLocation = &models.GeoJSON{Type: "Point", Coordinates: []float64{29.006, 41.00025}}
+				return l
+			},
+			expectedSegment: "w2:w1",
+			expectedLatLon:  []float64{29.006, 41.0002},
+		},
+		{
+			name: "off the road network",
+			location: func() *models.DriverLocation {
+				l := models.NewDriverLocation(41.005, 29.003)
+				l.RoadSegmentID = "e1:e2"
+				return l
+			},
+			expectedLatLon: []float64{29.003, 41.005},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			location := tt.location()
+			raw := location.Location
+
+			// Execute
+			m.Match(location)
+
+			// Assert
+			require.NotNil(t, location.SnappedLocation)
+			assert.Equal(t, tt.expectedSegment, location.RoadSegmentID)
+			assert.InDelta(t, tt.expectedLatLon[0], location.SnappedLocation.Coordinates[0], 1e-7)
+			assert.InDelta(t, tt.expectedLatLon[1], location.SnappedLocation.Coordinates[1], 1e-7)
+			assert.Equal(t, raw, location.Location)
+		})
+	}
+}
+
+func TestAgainst(t *testing.T) {
+	assert.False(t, against(90, 1, 0))
+	assert.True(t, against(270, 1, 0))
+	assert.False(t, against(10, 0, 1))
+	assert.True(t, against(200, 1, 1))
+}
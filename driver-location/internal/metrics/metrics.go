@@ -0,0 +1,76 @@
+// Package metrics defines the Prometheus collectors of the service. HTTP
+// metrics share their names and labels with the matching service.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	mongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_operation_duration_seconds",
+		Help:    "Latency of MongoDB operations, by operation and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+
+	importRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "import_rows_total",
+		Help: "Number of driver locations imported, by source and outcome.",
+	}, []string{"source", "outcome"})
+
+	spoofingFlags = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "spoofing_flags_total",
+		Help: "Number of driver location updates flagged as implausible, by flag type and whether the update was rejected.",
+	}, []string{"type", "rejected"})
+)
+
+// ObserveHTTPRequest records a handled request. route is the route template,
+// not the raw path, to keep the label set bounded.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveMongoOperation records the latency of a repository operation started at start.
+func ObserveMongoOperation(operation string, start time.Time, err error) {
+	mongoOperationDuration.WithLabelValues(operation, outcome(err)).Observe(time.Since(start).Seconds())
+}
+
+// AddImportRows counts the rows of a bulk import.
+func AddImportRows(source string, successful, failed int) {
+	importRows.WithLabelValues(source, "successful").Add(float64(successful))
+	importRows.WithLabelValues(source, "failed").Add(float64(failed))
+}
+
+// AddSpoofingFlag counts a flag raised against a driver location update.
+func AddSpoofingFlag(flagType string, rejected bool) {
+	spoofingFlags.WithLabelValues(flagType, strconv.FormatBool(rejected)).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	return OutcomeOK
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/health/live", "200"))
+
+	ObserveHTTPRequest("GET", "/health/live", 200, 5*time.Millisecond)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("GET", "/health/live", "200")))
+}
+
+func TestObserveMongoOperation(t *testing.T) {
+	ObserveMongoOperation("test_op", time.Now(), errors.New("failed"))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(mongoOperationDuration, "mongo_operation_duration_seconds"))
+}
+
+func TestAddImportRows(t *testing.T) {
+	successful := testutil.ToFloat64(importRows.WithLabelValues("csv", "successful"))
+	failed := testutil.ToFloat64(importRows.WithLabelValues("csv", "failed"))
+
+	AddImportRows("csv", 8, 2)
+
+	assert.Equal(t, successful+8, testutil.ToFloat64(importRows.WithLabelValues("csv", "successful")))
+	assert.Equal(t, failed+2, testutil.ToFloat64(importRows.WithLabelValues("csv", "failed")))
+}
+
+func TestAddSpoofingFlag(t *testing.T) {
+	before := testutil.ToFloat64(spoofingFlags.WithLabelValues("teleport", "true"))
+
+	AddSpoofingFlag("teleport", true)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(spoofingFlags.WithLabelValues("teleport", "true")))
+}
@@ -1,25 +1,54 @@
 package middleware
 
 import (
-	"crypto/subtle"
-	"net/http"
+	"errors"
 
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/apierror"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
-	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
-// AuthMiddleware creates Gin middleware for authentication.
-func AuthMiddleware(cfg config.Config) gin.HandlerFunc {
+// AuthMiddleware creates Gin middleware that authenticates the X-API-Key
+// header and stores the matching key in the context for RequireScope.
+func AuthMiddleware(keys service.APIKeyService, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		apiKey := c.GetHeader("X-API-Key")
+		key, err := keys.Authenticate(c.Request.Context(), c.GetHeader("X-API-Key"))
+		if errors.Is(err, service.ErrInvalidAPIKey) {
+			apierror.Respond(c, apierror.ErrUnauthorized)
+			return
+		}
+		if err != nil {
+			logging.FromContext(c.Request.Context(), logger).Error("failed to authenticate api key", zap.Error(err))
+			apierror.Respond(c, apierror.ErrInternal)
+			return
+		}
+
+		c.Set(config.APIKeyContextKey, key)
+		ctx := c.Request.Context()
+		keyLogger := logging.FromContext(ctx, logger).With(zap.String("api_key_id", key.ID))
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, keyLogger))
+
+		c.Next()
+	}
+}
+
+// RequireScope creates Gin middleware that rejects requests whose API key was
+// not granted scope. It must run after AuthMiddleware.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := c.Value(config.APIKeyContextKey).(*models.APIKey)
+		if !ok {
+			apierror.Respond(c, apierror.ErrUnauthorized)
+			return
+		}
 
-		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(cfg.ApiKey)) != 1 {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+		if !key.HasScope(scope) {
+			apierror.Respond(c, apierror.ErrForbidden)
 			return
 		}
 
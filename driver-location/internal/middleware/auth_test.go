@@ -1,25 +1,62 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/service"
 )
 
 const testAPIKey = "test-api-key-123"
 
+// newTestAPIKeyService returns a key service that accepts testAPIKey as its
+// bootstrap key and stores issued keys in a temporary file.
+func newTestAPIKeyService(t *testing.T) service.APIKeyService {
+	repo, err := repository.NewFileAPIKeyRepository(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+	return service.NewAPIKeyService(repo, testAPIKey, time.Minute, zap.NewNop())
+}
+
+func issueTestKey(t *testing.T, keys service.APIKeyService, scopes ...string) *models.IssuedAPIKey {
+	issued, err := keys.IssueKey(context.Background(), "test", scopes, nil)
+	require.NoError(t, err)
+	return issued
+}
+
+// failingKeyRepository fails every lookup, as a store that is down would.
+type failingKeyRepository struct {
+	repository.APIKeyRepository
+}
+
+func (failingKeyRepository) FindKeyByHash(context.Context, string) (*models.APIKey, error) {
+	return nil, errors.New("connection refused")
+}
+
 func TestAuthMiddleware(t *testing.T) {
-	cfg := config.Config{
-		ApiKey: testAPIKey,
-	}
+	keys := newTestAPIKeyService(t)
+	issued := issueTestKey(t, keys, config.ScopeLocationsRead)
+	revoked := issueTestKey(t, keys, config.ScopeLocationsRead)
+	_, err := keys.RevokeKey(context.Background(), revoked.APIKey.ID)
+	require.NoError(t, err)
+	rotated := issueTestKey(t, keys, config.ScopeLocationsRead)
+	_, err = keys.RotateKey(context.Background(), rotated.APIKey.ID, time.Hour)
+	require.NoError(t, err)
 
 	tests := []struct {
 		name               string
@@ -36,6 +73,31 @@ func TestAuthMiddleware(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			shouldCallNext:     true,
 		},
+		{
+			name: "success - issued API key",
+			setupAuth: func() string {
+				return issued.Secret
+			},
+			expectedStatusCode: http.StatusOK,
+			shouldCallNext:     true,
+		},
+		{
+			name: "success - rotated API key within overlap",
+			setupAuth: func() string {
+				return rotated.Secret
+			},
+			expectedStatusCode: http.StatusOK,
+			shouldCallNext:     true,
+		},
+		{
+			name: "failure - revoked API key",
+			setupAuth: func() string {
+				return revoked.Secret
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      config.ErrUnauthorized,
+			shouldCallNext:     false,
+		},
 		{
 			name: "failure - missing API key header",
 			setupAuth: func() string {
@@ -88,7 +150,10 @@ func TestAuthMiddleware(t *testing.T) {
 			// Setup
 			apiKey := tt.setupAuth()
 			router := gin.New()
-			router.Use(AuthMiddleware(cfg))
+			router.Use(AuthMiddleware(keys, zap.NewNop()))
+			router.GET("/test", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
 
 			// Execute
 			recorder := httptest.NewRecorder()
@@ -99,6 +164,7 @@ func TestAuthMiddleware(t *testing.T) {
 			router.ServeHTTP(recorder, req)
 
 			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
 			var resp dto.ErrorResponse
 			err := json.Unmarshal(recorder.Body.Bytes(), &resp)
 			if err == nil {
@@ -112,9 +178,7 @@ func TestAuthMiddleware(t *testing.T) {
 func TestAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	cfg := config.Config{
-		ApiKey: testAPIKey,
-	}
+	keys := newTestAPIKeyService(t)
 
 	tests := []struct {
 		name               string
@@ -136,7 +200,7 @@ func TestAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 				return ""
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       fmt.Sprintf(`{"success":false,"error":"%s"}`, config.ErrUnauthorized),
+			expectedBody:       fmt.Sprintf(`{"success":false,"code":"unauthorized","error":"%s"}`, config.ErrUnauthorized),
 		},
 		{
 			name: "failure - protected route blocked with invalid API key",
@@ -144,7 +208,7 @@ func TestAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 				return "invalid-key"
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       fmt.Sprintf(`{"success":false,"error":"%s"}`, config.ErrUnauthorized),
+			expectedBody:       fmt.Sprintf(`{"success":false,"code":"unauthorized","error":"%s"}`, config.ErrUnauthorized),
 		},
 	}
 
@@ -152,7 +216,7 @@ func TestAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup
 			router := gin.New()
-			router.Use(AuthMiddleware(cfg))
+			router.Use(AuthMiddleware(keys, zap.NewNop()))
 			router.GET("/protected", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{
 					"message": "success",
@@ -174,3 +238,83 @@ func TestAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddleware_StoreUnavailable(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	keys := service.NewAPIKeyService(failingKeyRepository{}, testAPIKey, time.Minute, zap.NewNop())
+	router := gin.New()
+	router.Use(AuthMiddleware(keys, zap.NewNop()))
+	router.GET("/protected", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// Execute
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("X-API-Key", config.APIKeyPrefix+"unknown")
+	router.ServeHTTP(recorder, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := newTestAPIKeyService(t)
+	reader := issueTestKey(t, keys, config.ScopeLocationsRead)
+
+	tests := []struct {
+		name               string
+		apiKey             string
+		scope              string
+		expectedStatusCode int
+		expectedCode       string
+	}{
+		{
+			name:               "success - key has scope",
+			apiKey:             reader.Secret,
+			scope:              config.ScopeLocationsRead,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "success - bootstrap key has every scope",
+			apiKey:             testAPIKey,
+			scope:              config.ScopeKeysAdmin,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "failure - key lacks scope",
+			apiKey:             reader.Secret,
+			scope:              config.ScopeLocationsWrite,
+			expectedStatusCode: http.StatusForbidden,
+			expectedCode:       "insufficient_scope",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := gin.New()
+			router.Use(AuthMiddleware(keys, zap.NewNop()))
+			router.GET("/protected", RequireScope(tt.scope), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+			req.Header.Set("X-API-Key", tt.apiKey)
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+			if tt.expectedCode != "" {
+				var resp dto.ErrorResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expectedCode, resp.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/idempotency"
+)
+
+// IdempotencyMiddleware replays the stored response of requests to routes
+// that repeat the Idempotency-Key header of an earlier request with the same
+// payload. Keys are scoped to the caller, so it must run after
+// AuthMiddleware.
+func IdempotencyMiddleware(store idempotency.Store, routes []string, maxBodyBytes int64, logger *zap.Logger) gin.HandlerFunc {
+	return idempotency.Middleware(store, routes, clientID, maxBodyBytes, logger)
+}
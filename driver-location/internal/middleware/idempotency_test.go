@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/idempotency"
+)
+
+// setupIdempotencyRouter counts the requests that reach the handler, which
+// echoes the body and fails with a 500 when the body is "fail". Bodies are
+// limited to 16 bytes.
+func setupIdempotencyRouter(calls *int) *gin.Engine {
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(config.APIKeyContextKey, &models.APIKey{ID: c.GetHeader("X-Test-Key")})
+	})
+	router.Use(IdempotencyMiddleware(idempotency.NewMemoryStore(time.Hour), []string{"POST /locations/batch"}, 16, zap.NewNop()))
+	handler := func(c *gin.Context) {
+		*calls++
+		body, _ := io.ReadAll(c.Request.Body)
+		if string(body) == "fail" {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusCreated, "%s #%d", body, *calls)
+	}
+	router.POST("/locations/batch", handler)
+	router.POST("/locations/search", handler)
+	return router
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		apiKey         string
+		path           string
+		key            string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}
+
+	tests := []struct {
+		name          string
+		requests      []request
+		expectedCalls int
+	}{
+		{
+			name: "retry is replayed",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "x", http.StatusCreated, "x #1"},
+				{"a", "/locations/batch", "k1", "x", http.StatusCreated, "x #1"},
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "different payload is rejected",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "x", http.StatusCreated, "x #1"},
+				{"a", "/locations/batch", "k1", "y", http.StatusUnprocessableEntity, `"code":"idempotency_key_reused"`},
+			},
+			expectedCalls: 1,
+		},
+		{
+			name: "keys are scoped to the api key",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "x", http.StatusCreated, "x #1"},
+				{"b", "/locations/batch", "k1", "x", http.StatusCreated, "x #2"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "server errors are not stored",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "fail", http.StatusInternalServerError, ""},
+				{"a", "/locations/batch", "k1", "fail", http.StatusInternalServerError, ""},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "requests without a key are processed",
+			requests: []request{
+				{"a", "/locations/batch", "", "x", http.StatusCreated, "x #1"},
+				{"a", "/locations/batch", "", "x", http.StatusCreated, "x #2"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "other routes ignore the key",
+			requests: []request{
+				{"a", "/locations/search", "k1", "x", http.StatusCreated, "x #1"},
+				{"a", "/locations/search", "k1", "x", http.StatusCreated, "x #2"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "oversized body is rejected",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "0123456789abcdefg", http.StatusRequestEntityTooLarge, `"code":"request_too_large"`},
+			},
+			expectedCalls: 0,
+		},
+		{
+			name: "oversized responses are not stored",
+			requests: []request{
+				{"a", "/locations/batch", "k1", "0123456789abcd", http.StatusCreated, "0123456789abcd #1"},
+				{"a", "/locations/batch", "k1", "0123456789abcd", http.StatusCreated, "0123456789abcd #2"},
+			},
+			expectedCalls: 2,
+		},
+		{
+			name: "invalid key",
+			requests: []request{
+				{"a", "/locations/batch", "bad key", "x", http.StatusBadRequest, `"code":"invalid_idempotency_key"`},
+			},
+			expectedCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			calls := 0
+			router := setupIdempotencyRouter(&calls)
+
+			for i, r := range tt.requests {
+				// Execute
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, r.path, strings.NewReader(r.body))
+				req.Header.Set("X-Test-Key", r.apiKey)
+				if r.key != "" {
+					req.Header.Set(idempotency.Header, r.key)
+				}
+				router.ServeHTTP(recorder, req)
+
+				// Assert
+				assert.Equal(t, r.expectedStatus, recorder.Code, "request %d", i)
+				assert.Contains(t, recorder.Body.String(), r.expectedBody, "request %d", i)
+			}
+			assert.Equal(t, tt.expectedCalls, calls)
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_ReplayedHeader(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	calls := 0
+	router := setupIdempotencyRouter(&calls)
+
+	send := func() *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/locations/batch", strings.NewReader("x"))
+		req.Header.Set(idempotency.Header, "k1")
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	// Execute
+	first := send()
+	retry := send()
+
+	// Assert
+	assert.Empty(t, first.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, "true", retry.Header().Get(idempotency.ReplayedHeader))
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+}
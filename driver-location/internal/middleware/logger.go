@@ -8,6 +8,7 @@ import (
 	"go.uber.org/zap/zapcore"
 
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
 // NewLogger initializes a logger.
@@ -38,7 +39,7 @@ func LoggerMiddleware(logger *zap.Logger) gin.HandlerFunc {
 			path = path + "?" + raw
 		}
 
-		logger.Info("Request completed",
+		logging.FromContext(c.Request.Context(), logger).Info("Request completed",
 			zap.Int("status_code", c.Writer.Status()),
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
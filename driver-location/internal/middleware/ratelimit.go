@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+)
+
+// RateLimitMiddleware limits the requests of each API key to each route. It
+// must run after AuthMiddleware. Requests are let through when the store
+// fails, so that an unavailable Redis does not take the API down.
+func RateLimitMiddleware(store ratelimit.Store, limits ratelimit.Limits, logger *zap.Logger) gin.HandlerFunc {
+	return ratelimit.Middleware(store, limits, clientID, logger)
+}
+
+// clientID identifies the caller by its API key, or by its IP address before
+// authentication.
+func clientID(c *gin.Context) string {
+	if key, ok := c.Get(config.APIKeyContextKey); ok {
+		return "key:" + key.(*models.APIKey).ID
+	}
+	return "ip:" + c.ClientIP()
+}
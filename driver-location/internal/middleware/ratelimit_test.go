@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+)
+
+// failingStore is a rate limit store whose backend is down.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func setupRateLimitRouter(store ratelimit.Store) *gin.Engine {
+	limits := ratelimit.Limits{
+		Default: ratelimit.Limit{Rate: 1, Burst: 2},
+		Routes: map[string]ratelimit.Limit{
+			"POST /locations": {Rate: 1, Burst: 1},
+		},
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(config.APIKeyContextKey, &models.APIKey{ID: c.GetHeader("X-Test-Key")})
+	})
+	router.Use(RateLimitMiddleware(store, limits, zap.NewNop()))
+	router.POST("/locations", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/locations/search", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		requests       []string
+		expectedStatus []int
+	}{
+		{
+			name:           "route limit",
+			requests:       []string{"a /locations", "a /locations"},
+			expectedStatus: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:           "default limit",
+			requests:       []string{"a /locations/search", "a /locations/search", "a /locations/search"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:           "separate buckets per key and route",
+			requests:       []string{"a /locations", "b /locations", "a /locations/search"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := setupRateLimitRouter(ratelimit.NewMemoryStore())
+
+			for i, r := range tt.requests {
+				// Execute
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, r[2:], nil)
+				req.Header.Set("X-Test-Key", r[:1])
+				router.ServeHTTP(recorder, req)
+
+				// Assert
+				assert.Equal(t, tt.expectedStatus[i], recorder.Code, "request %d", i)
+				if recorder.Code == http.StatusTooManyRequests {
+					assert.Equal(t, "1", recorder.Header().Get(ratelimit.RetryAfterHeader))
+					assert.Equal(t, "0", recorder.Header().Get(ratelimit.RemainingHeader))
+					assert.Contains(t, recorder.Body.String(), `"code":"rate_limited"`)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_StoreUnavailable(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := setupRateLimitRouter(failingStore{})
+
+	// Execute
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/locations", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
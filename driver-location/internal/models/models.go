@@ -1,15 +1,111 @@
 package models
 
-import "go.mongodb.org/mongo-driver/v2/bson"
+import (
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
 
 type GeoJSON struct {
 	Type        string    `bson:"type"`
 	Coordinates []float64 `bson:"coordinates"`
 }
 
+type Vehicle struct {
+	Type                 string `bson:"type"`
+	Capacity             int    `bson:"capacity"`
+	WheelchairAccessible bool   `bson:"wheelchair_accessible"`
+	PetFriendly          bool   `bson:"pet_friendly"`
+}
+
 type DriverLocation struct {
-	ID       bson.ObjectID `bson:"_id,omitempty"`
-	Location GeoJSON       `bson:"location"`
+	ID bson.ObjectID `bson:"_id,omitempty"`
+	// DriverID ties the location to a driver, whose document is then updated
+	// in place instead of a new location being added.
+	DriverID string   `bson:"driver_id,omitempty"`
+	Location GeoJSON  `bson:"location"`
+	Vehicle  *Vehicle `bson:"vehicle,omitempty"`
+	// Heading is the direction of travel in degrees clockwise from north,
+	// Speed is in metres per second and Accuracy is the horizontal accuracy
+	// of the fix in metres. Each is set only when the device reports it.
+	Heading  *float64 `bson:"heading,omitempty"`
+	Speed    *float64 `bson:"speed,omitempty"`
+	Accuracy *float64 `bson:"accuracy,omitempty"`
+	// Rating is the driver's rating out of 5 and AcceptanceRate the share of
+	// offered trips, from 0 to 1, the driver accepted. IdleSince is when the
+	// driver last became available for a trip. Each is set only when the
+	// driver app reports it.
+	Rating         *float64  `bson:"rating,omitempty"`
+	AcceptanceRate *float64  `bson:"acceptance_rate,omitempty"`
+	IdleSince      time.Time `bson:"idle_since,omitempty"`
+	// UpdatedAt is when the driver last reported a location.
+	UpdatedAt time.Time `bson:"updated_at,omitempty"`
+	// StaticSince is when the driver last reported different coordinates.
+	StaticSince time.Time `bson:"static_since,omitempty"`
+	// Flags holds the latest flag of each type raised against the driver.
+	Flags map[string]DriverFlag `bson:"flags,omitempty"`
+	// FlaggedAt is when the most recent flag was raised.
+	FlaggedAt time.Time `bson:"flagged_at,omitempty"`
+	// SmoothedLocation is the location with GPS jitter filtered out, when
+	// smoothing is enabled.
+	SmoothedLocation *GeoJSON `bson:"smoothed_location,omitempty"`
+	// Filter is the smoothing state of a driver.
+	Filter *KalmanState `bson:"filter,omitempty"`
+	// SnappedLocation is the location moved onto the nearest road, when map
+	// matching is enabled, and RoadSegmentID is the road segment it lies on.
+	// Locations off the road network keep their position and have no segment.
+	SnappedLocation *GeoJSON `bson:"snapped_location,omitempty"`
+	RoadSegmentID   string   `bson:"road_segment_id,omitempty"`
+}
+
+// KalmanState is the velocity of a driver estimated by the smoothing filter
+// and its uncertainty. The covariance is shared by the north and east axes.
+type KalmanState struct {
+	// VelocityNorth and VelocityEast are in metres per second.
+	VelocityNorth    float64   `bson:"velocity_north"`
+	VelocityEast     float64   `bson:"velocity_east"`
+	PositionVariance float64   `bson:"position_variance"`
+	Covariance       float64   `bson:"covariance"`
+	VelocityVariance float64   `bson:"velocity_variance"`
+	UpdatedAt        time.Time `bson:"updated_at"`
+}
+
+// DriverFlag records that a driver sent location updates that look spoofed.
+type DriverFlag struct {
+	Detail     string    `bson:"detail"`
+	DetectedAt time.Time `bson:"detected_at"`
+	// Count is how many updates raised the flag.
+	Count int `bson:"count"`
+}
+
+// Violation is a spoofing check failed by a location update. Type is the
+// type of the flag it raises against the driver.
+type Violation struct {
+	Type   string
+	Detail string
+}
+
+// ActiveFlags returns the types of the flags raised at or after since, sorted.
+func ActiveFlags(flags map[string]DriverFlag, since time.Time) []string {
+	var active []string
+	for flagType, flag := range flags {
+		if !flag.DetectedAt.Before(since) {
+			active = append(active, flagType)
+		}
+	}
+	slices.Sort(active)
+	return active
+}
+
+// Latitude returns the latitude of the location.
+func (l DriverLocation) Latitude() float64 {
+	return l.Location.Coordinates[1]
+}
+
+// Longitude returns the longitude of the location.
+func (l DriverLocation) Longitude() float64 {
+	return l.Location.Coordinates[0]
 }
 
 func NewDriverLocation(lat, lon float64) *DriverLocation {
@@ -22,9 +118,23 @@ func NewDriverLocation(lat, lon float64) *DriverLocation {
 }
 
 type BulkResult struct {
-	Total      int
-	Successful int
-	Failed     int
+	Total      int `bson:"total"`
+	Successful int `bson:"successful"`
+	Failed     int `bson:"failed"`
+}
+
+// SearchFilter restricts a search to drivers whose vehicle satisfies every set field.
+type SearchFilter struct {
+	VehicleTypes         []string
+	MinCapacity          int
+	WheelchairAccessible bool
+	PetFriendly          bool
+	// MaxAccuracy excludes locations whose reported accuracy is worse than
+	// this many metres. Locations without a reported accuracy are kept.
+	MaxAccuracy float64
+	// Position is a config.Position value selecting whether drivers are
+	// searched by their raw, smoothed or snapped location. Empty means raw.
+	Position string
 }
 
 type SearchResult struct {
@@ -32,4 +142,109 @@ type SearchResult struct {
 	Latitude  float64
 	Longitude float64
 	Distance  float64
+	Vehicle   *Vehicle
+	Heading   *float64
+	Speed     *float64
+	Accuracy  *float64
+	Rating    *float64
+	// AcceptanceRate is the share of offered trips the driver accepted.
+	AcceptanceRate *float64
+	// IdleSince is when the driver became available, if reported, and
+	// IdleSeconds how long ago that was at the time of the search.
+	IdleSince   time.Time
+	IdleSeconds *float64
+	// Smoothed is the smoothed location of the driver, if it has one.
+	Smoothed *GeoJSON
+	// Snapped is the location of the driver on the road network, if it has
+	// one, and RoadSegmentID the segment it lies on.
+	Snapped       *GeoJSON
+	RoadSegmentID string
+	Flags         map[string]DriverFlag
+	// ActiveFlags lists the types of the flags that still apply.
+	ActiveFlags []string
+}
+
+// PickupPoint is a curated spot where drivers can stop to pick up riders.
+// Zone is a free-form label grouping the points of an area, such as an airport.
+type PickupPoint struct {
+	ID        string    `bson:"_id"`
+	Name      string    `bson:"name"`
+	Zone      string    `bson:"zone,omitempty"`
+	Location  GeoJSON   `bson:"location"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// NearbyPickupPoint is a pickup point found by a search and its distance in
+// metres from the searched point.
+type NearbyPickupPoint struct {
+	PickupPoint `bson:",inline"`
+	Distance    float64 `bson:"distance"`
+}
+
+// DependencyCheck is the outcome of probing a dependency for readiness.
+type DependencyCheck struct {
+	Name    string
+	Latency time.Duration
+	Err     error
+}
+
+// APIKey is a client credential. Only the SHA-256 hash of the key is stored;
+// Prefix keeps its first characters so it can be recognised in listings.
+type APIKey struct {
+	ID        string     `bson:"_id" json:"id"`
+	Name      string     `bson:"name" json:"name"`
+	Prefix    string     `bson:"prefix" json:"prefix"`
+	Hash      string     `bson:"hash" json:"hash"`
+	Scopes    []string   `bson:"scopes" json:"scopes"`
+	CreatedAt time.Time  `bson:"created_at" json:"created_at"`
+	ExpiresAt *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// Active reports whether the key can be used at now.
+func (k APIKey) Active(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// HasScope reports whether the key was granted scope.
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IssuedAPIKey is a newly created key together with its secret, which is
+// only available at creation time.
+type IssuedAPIKey struct {
+	APIKey *APIKey
+	Secret string
+}
+
+// AuditEntry records an attempted administrative or bulk operation: who
+// performed it, from where, whether it succeeded, and for bulk operations how
+// many rows were written, including by an attempt that failed part way.
+type AuditEntry struct {
+	ID        bson.ObjectID `bson:"_id,omitempty"`
+	Timestamp time.Time     `bson:"timestamp"`
+	// Actor is the ID of the API key that performed the operation.
+	Actor     string `bson:"actor"`
+	Operation string `bson:"operation"`
+	// Outcome is one of the AuditOutcome values.
+	Outcome string `bson:"outcome"`
+	// Target is the ID of the API key an administrative operation acted on.
+	Target    string      `bson:"target,omitempty"`
+	Result    *BulkResult `bson:"result,omitempty"`
+	SourceIP  string      `bson:"source_ip"`
+	RequestID string      `bson:"request_id,omitempty"`
+}
+
+// AuditFilter selects audit entries. Empty fields match every entry.
+type AuditFilter struct {
+	Actor     string
+	Operation string
+	From      time.Time
+	To        time.Time
+	Limit     int
 }
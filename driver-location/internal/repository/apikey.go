@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+var (
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+	ErrDuplicateAPIKey = errors.New("api key already exists")
+)
+
+type APIKeyRepository interface {
+	CreateKey(ctx context.Context, key *models.APIKey) error
+	FindKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	FindKeyByID(ctx context.Context, id string) (*models.APIKey, error)
+	ListKeys(ctx context.Context) ([]*models.APIKey, error)
+	// ExpireKey moves the expiry of a key to at, unless it already expires earlier.
+	ExpireKey(ctx context.Context, id string, at time.Time) error
+	RevokeKey(ctx context.Context, id string, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAPIKeyRepository(ctx context.Context, collection *mongo.Collection) (APIKeyRepository, error) {
+	hashIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+
+	if _, err := collection.Indexes().CreateOne(ctx, hashIndex); err != nil {
+		return nil, fmt.Errorf("failed to create api key hash index: %w", err)
+	}
+
+	return &apiKeyRepository{collection: collection}, nil
+}
+
+func (r apiKeyRepository) CreateKey(ctx context.Context, key *models.APIKey) error {
+	start := time.Now()
+	_, err := r.collection.InsertOne(ctx, key)
+	metrics.ObserveMongoOperation("insert_one", start, err)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicateAPIKey
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return nil
+}
+
+func (r apiKeyRepository) FindKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return r.findOne(ctx, bson.D{{Key: "hash", Value: hash}})
+}
+
+func (r apiKeyRepository) FindKeyByID(ctx context.Context, id string) (*models.APIKey, error) {
+	return r.findOne(ctx, bson.D{{Key: "_id", Value: id}})
+}
+
+func (r apiKeyRepository) findOne(ctx context.Context, filter bson.D) (*models.APIKey, error) {
+	start := time.Now()
+	var key models.APIKey
+	err := r.collection.FindOne(ctx, filter).Decode(&key)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one", start, nil)
+		return nil, ErrAPIKeyNotFound
+	}
+	metrics.ObserveMongoOperation("find_one", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find api key: %w", err)
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) ListKeys(ctx context.Context) ([]*models.APIKey, error) {
+	start := time.Now()
+	cursor, err := r.collection.Find(ctx, bson.D{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		metrics.ObserveMongoOperation("find", start, err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := []*models.APIKey{}
+	err = cursor.All(ctx, &keys)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r apiKeyRepository) ExpireKey(ctx context.Context, id string, at time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$exists", Value: false}}}},
+			bson.D{{Key: "expires_at", Value: bson.D{{Key: "$gt", Value: at}}}},
+		}},
+	}
+	return r.update(ctx, id, filter, bson.D{{Key: "expires_at", Value: at}})
+}
+
+func (r apiKeyRepository) RevokeKey(ctx context.Context, id string, at time.Time) error {
+	filter := bson.D{
+		{Key: "_id", Value: id},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	return r.update(ctx, id, filter, bson.D{{Key: "revoked_at", Value: at}})
+}
+
+// update applies set to the key matching filter. When nothing matches, it
+// tells a missing key apart from one that is already in the desired state.
+func (r apiKeyRepository) update(ctx context.Context, id string, filter, set bson.D) error {
+	start := time.Now()
+	result, err := r.collection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: set}})
+	metrics.ObserveMongoOperation("update_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		_, err = r.FindKeyByID(ctx, id)
+		return err
+	}
+	return nil
+}
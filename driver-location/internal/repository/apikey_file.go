@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// fileAPIKeyRepository keeps API keys in a JSON file, for deployments without
+// a shared database for credentials. The whole file is rewritten on every
+// change, so it suits the handful of keys a deployment has.
+type fileAPIKeyRepository struct {
+	path string
+	mu   sync.RWMutex
+	keys []*models.APIKey
+}
+
+// NewFileAPIKeyRepository loads the keys in path. A missing file is created on the first write.
+func NewFileAPIKeyRepository(path string) (APIKeyRepository, error) {
+	r := &fileAPIKeyRepository{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api key file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &r.keys); err != nil {
+		return nil, fmt.Errorf("failed to parse api key file: %w", err)
+	}
+	return r, nil
+}
+
+func (r *fileAPIKeyRepository) CreateKey(_ context.Context, key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, k := range r.keys {
+		if k.ID == key.ID || k.Hash == key.Hash {
+			return ErrDuplicateAPIKey
+		}
+	}
+
+	stored := *key
+	return r.save(append(r.keys, &stored))
+}
+
+func (r *fileAPIKeyRepository) FindKeyByHash(_ context.Context, hash string) (*models.APIKey, error) {
+	return r.find(func(k *models.APIKey) bool { return k.Hash == hash })
+}
+
+func (r *fileAPIKeyRepository) FindKeyByID(_ context.Context, id string) (*models.APIKey, error) {
+	return r.find(func(k *models.APIKey) bool { return k.ID == id })
+}
+
+func (r *fileAPIKeyRepository) find(match func(*models.APIKey) bool) (*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, k := range r.keys {
+		if match(k) {
+			key := *k
+			return &key, nil
+		}
+	}
+	return nil, ErrAPIKeyNotFound
+}
+
+func (r *fileAPIKeyRepository) ListKeys(_ context.Context) ([]*models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*models.APIKey, len(r.keys))
+	for i, k := range r.keys {
+		key := *k
+		keys[i] = &key
+	}
+	return keys, nil
+}
+
+func (r *fileAPIKeyRepository) ExpireKey(_ context.Context, id string, at time.Time) error {
+	return r.update(id, func(k *models.APIKey) {
+		if k.ExpiresAt == nil || k.ExpiresAt.After(at) {
+			k.ExpiresAt = &at
+		}
+	})
+}
+
+func (r *fileAPIKeyRepository) RevokeKey(_ context.Context, id string, at time.Time) error {
+	return r.update(id, func(k *models.APIKey) {
+		if k.RevokedAt == nil {
+			k.RevokedAt = &at
+		}
+	})
+}
+
+// update applies change to a copy of the key and saves the result.
+func (r *fileAPIKeyRepository) update(id string, change func(*models.APIKey)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]*models.APIKey, len(r.keys))
+	copy(keys, r.keys)
+	for i, k := range keys {
+		if k.ID == id {
+			key := *k
+			change(&key)
+			keys[i] = &key
+			return r.save(keys)
+		}
+	}
+	return ErrAPIKeyNotFound
+}
+
+// save writes keys to a temporary file and renames it over the key file so
+// readers never see a partial write. It must be called with mu held.
+func (r *fileAPIKeyRepository) save(keys []*models.APIKey) error {
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode api keys: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("failed to write api key file: %w", err)
+	}
+
+	r.keys = keys
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+func TestFileAPIKeyRepository(t *testing.T) {
+	// Setup
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	repo, err := NewFileAPIKeyRepository(path)
+	require.NoError(t, err)
+
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	key := &models.APIKey{ID: "k1", Name: "matching", Prefix: "dlk_abcdefgh", Hash: "hash-1", Scopes: []string{"locations:read"}, CreatedAt: created}
+
+	// Execute
+	require.NoError(t, repo.CreateKey(ctx, key))
+	duplicateErr := repo.CreateKey(ctx, &models.APIKey{ID: "k2", Hash: "hash-1"})
+	require.NoError(t, repo.ExpireKey(ctx, "k1", created.Add(time.Hour)))
+	require.NoError(t, repo.ExpireKey(ctx, "k1", created.Add(2*time.Hour)))
+	require.NoError(t, repo.RevokeKey(ctx, "k1", created.Add(time.Minute)))
+	missingErr := repo.RevokeKey(ctx, "missing", created)
+
+	// Assert
+	assert.ErrorIs(t, duplicateErr, ErrDuplicateAPIKey)
+	assert.ErrorIs(t, missingErr, ErrAPIKeyNotFound)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	reloaded, err := NewFileAPIKeyRepository(path)
+	require.NoError(t, err)
+	stored, err := reloaded.FindKeyByHash(ctx, "hash-1")
+	require.NoError(t, err)
+	assert.Equal(t, "matching", stored.Name)
+	assert.Equal(t, created.Add(time.Hour), *stored.ExpiresAt, "a later expiry must not extend the key")
+	assert.Equal(t, created.Add(time.Minute), *stored.RevokedAt)
+
+	keys, err := reloaded.ListKeys(ctx)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// AuditRepository stores audit entries. It is append-only: entries can be
+// added and read, but never changed or removed.
+type AuditRepository interface {
+	Append(ctx context.Context, entry *models.AuditEntry) error
+	// Find returns the entries matching filter, newest first.
+	Find(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error)
+}
+
+type auditRepository struct {
+	collection *mongo.Collection
+}
+
+func NewAuditRepository(ctx context.Context, collection *mongo.Collection) (AuditRepository, error) {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "actor", Value: 1}, {Key: "timestamp", Value: -1}}},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create audit indexes: %w", err)
+	}
+
+	return &auditRepository{collection: collection}, nil
+}
+
+func (r auditRepository) Append(ctx context.Context, entry *models.AuditEntry) error {
+	start := time.Now()
+	result, err := r.collection.InsertOne(ctx, entry)
+	metrics.ObserveMongoOperation("insert_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	if id, ok := result.InsertedID.(bson.ObjectID); ok {
+		entry.ID = id
+	}
+	return nil
+}
+
+func (r auditRepository) Find(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(filter.Limit))
+
+	start := time.Now()
+	cursor, err := r.collection.Find(ctx, buildAuditQuery(filter), opts)
+	if err != nil {
+		metrics.ObserveMongoOperation("find", start, err)
+		return nil, fmt.Errorf("failed to find audit entries: %w", err)
+	}
+
+	entries := []*models.AuditEntry{}
+	err = cursor.All(ctx, &entries)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audit entries: %w", err)
+	}
+	return entries, nil
+}
+
+func buildAuditQuery(filter models.AuditFilter) bson.D {
+	query := bson.D{}
+	if filter.Actor != "" {
+		query = append(query, bson.E{Key: "actor", Value: filter.Actor})
+	}
+	if filter.Operation != "" {
+		query = append(query, bson.E{Key: "operation", Value: filter.Operation})
+	}
+
+	timestamp := bson.D{}
+	if !filter.From.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$gte", Value: filter.From})
+	}
+	if !filter.To.IsZero() {
+		timestamp = append(timestamp, bson.E{Key: "$lte", Value: filter.To})
+	}
+	if len(timestamp) > 0 {
+		query = append(query, bson.E{Key: "timestamp", Value: timestamp})
+	}
+	return query
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+var ErrPickupPointNotFound = errors.New("pickup point not found")
+
+type PickupPointRepository interface {
+	CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error
+	FindPickupPoint(ctx context.Context, id string) (*models.PickupPoint, error)
+	// ListPickupPoints returns the points of zone, or every point when zone
+	// is empty, ordered by name.
+	ListPickupPoints(ctx context.Context, zone string, limit int) ([]*models.PickupPoint, error)
+	// UpdatePickupPoint replaces the name, zone and location of a point.
+	UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) error
+	// DeletePickupPoint removes a point and returns it.
+	DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error)
+	// NearbyPickupPoints returns the points within radius metres, nearest first.
+	NearbyPickupPoints(ctx context.Context, longitude, latitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error)
+}
+
+type pickupPointRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPickupPointRepository(ctx context.Context, collection *mongo.Collection) (PickupPointRepository, error) {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "zone", Value: 1}, {Key: "name", Value: 1}}},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create pickup point indexes: %w", err)
+	}
+
+	return &pickupPointRepository{collection: collection}, nil
+}
+
+func (r pickupPointRepository) CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	start := time.Now()
+	_, err := r.collection.InsertOne(ctx, point)
+	metrics.ObserveMongoOperation("insert_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to insert pickup point: %w", err)
+	}
+	return nil
+}
+
+func (r pickupPointRepository) FindPickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	start := time.Now()
+	var point models.PickupPoint
+	err := r.collection.FindOne(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&point)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one", start, nil)
+		return nil, ErrPickupPointNotFound
+	}
+	metrics.ObserveMongoOperation("find_one", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pickup point: %w", err)
+	}
+	return &point, nil
+}
+
+func (r pickupPointRepository) ListPickupPoints(ctx context.Context, zone string, limit int) ([]*models.PickupPoint, error) {
+	filter := bson.D{}
+	if zone != "" {
+		filter = append(filter, bson.E{Key: "zone", Value: zone})
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	start := time.Now()
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		metrics.ObserveMongoOperation("find", start, err)
+		return nil, fmt.Errorf("failed to list pickup points: %w", err)
+	}
+
+	points := []*models.PickupPoint{}
+	err = cursor.All(ctx, &points)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pickup points: %w", err)
+	}
+	return points, nil
+}
+
+func (r pickupPointRepository) UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	set := bson.D{
+		{Key: "name", Value: point.Name},
+		{Key: "location", Value: point.Location},
+		{Key: "updated_at", Value: point.UpdatedAt},
+	}
+	update := bson.D{}
+	if point.Zone != "" {
+		set = append(set, bson.E{Key: "zone", Value: point.Zone})
+	} else {
+		update = append(update, bson.E{Key: "$unset", Value: bson.D{{Key: "zone", Value: ""}}})
+	}
+	update = append(update, bson.E{Key: "$set", Value: set})
+
+	start := time.Now()
+	result, err := r.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: point.ID}}, update)
+	metrics.ObserveMongoOperation("update_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to update pickup point: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrPickupPointNotFound
+	}
+	return nil
+}
+
+func (r pickupPointRepository) DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	start := time.Now()
+	var point models.PickupPoint
+	err := r.collection.FindOneAndDelete(ctx, bson.D{{Key: "_id", Value: id}}).Decode(&point)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one_and_delete", start, nil)
+		return nil, ErrPickupPointNotFound
+	}
+	metrics.ObserveMongoOperation("find_one_and_delete", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete pickup point: %w", err)
+	}
+	return &point, nil
+}
+
+func (r pickupPointRepository) NearbyPickupPoints(ctx context.Context, longitude, latitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error) {
+	geoNear := bson.D{
+		{Key: "near", Value: bson.D{
+			{Key: "type", Value: "Point"},
+			{Key: "coordinates", Value: bson.A{longitude, latitude}},
+		}},
+		{Key: "distanceField", Value: "distance"},
+		{Key: "maxDistance", Value: radius},
+		{Key: "spherical", Value: true},
+	}
+	if zone != "" {
+		geoNear = append(geoNear, bson.E{Key: "query", Value: bson.D{{Key: "zone", Value: zone}}})
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: geoNear}},
+		{{Key: "$limit", Value: config.MaxPickupPointResults}},
+	}
+
+	start := time.Now()
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		metrics.ObserveMongoOperation("aggregate", start, err)
+		return nil, fmt.Errorf("failed to search pickup points: %w", err)
+	}
+
+	points := []*models.NearbyPickupPoint{}
+	err = cursor.All(ctx, &points)
+	metrics.ObserveMongoOperation("aggregate", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode pickup points: %w", err)
+	}
+	return points, nil
+}
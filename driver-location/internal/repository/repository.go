@@ -2,7 +2,9 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -10,14 +12,47 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
 )
 
+var (
+	ErrIndexMissing   = errors.New("geospatial index is missing")
+	ErrDriverNotFound = errors.New("driver not found")
+	// ErrConcurrentUpdate reports that a driver was updated since it was read.
+	ErrConcurrentUpdate = errors.New("driver was updated concurrently")
+)
+
 type DriverLocationRepository interface {
 	Create(ctx context.Context, location *models.DriverLocation) error
 	CreateMany(ctx context.Context, locations []*models.DriverLocation) (int, error)
-	Search(ctx context.Context, longitude, latitude, radius float64) ([]*models.SearchResult, error)
+	// FindByDriverID returns the current location of a driver.
+	FindByDriverID(ctx context.Context, driverID string) (*models.DriverLocation, error)
+	// UpdateDriver moves the driver of location, creating it if needed, and
+	// raises flags against it at at. The driver is only written if it was last
+	// updated at lastUpdatedAt, or never when lastUpdatedAt is zero, and
+	// ErrConcurrentUpdate is returned otherwise.
+	UpdateDriver(ctx context.Context, location *models.DriverLocation, lastUpdatedAt time.Time, flags []models.Violation, at time.Time) error
+	// FlagDriver raises flags against a driver without moving it.
+	FlagDriver(ctx context.Context, driverID string, flags []models.Violation, at time.Time) error
+	// FindFlagged returns up to limit drivers flagged at or after since, most
+	// recently flagged first.
+	FindFlagged(ctx context.Context, since time.Time, limit int) ([]*models.DriverLocation, error)
+	Search(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error)
+	// Count returns the number of locations Search would find, without its
+	// MaxSearchResults limit.
+	Count(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) (int64, error)
 	Ping(ctx context.Context) error
+	CheckIndexes(ctx context.Context) error
+	// BackfillSmoothedLocations gives locations stored without a smoothed
+	// location their raw one, so that smoothed searches find them, and
+	// returns how many were updated.
+	BackfillSmoothedLocations(ctx context.Context) (int64, error)
+	// BackfillSnappedLocations runs snap on the locations stored without a
+	// snapped location and stores the snapped location it sets, so that
+	// snapped searches find them, and returns how many were updated.
+	BackfillSnappedLocations(ctx context.Context, snap func(*models.DriverLocation)) (int64, error)
 }
 
 type driverLocationRepository struct {
@@ -31,20 +66,42 @@ func NewDriverLocationRepository(ctx context.Context, collection *mongo.Collecti
 		logger:     logger,
 	}
 
-	twodsphere := mongo.IndexModel{
-		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	twodsphere := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		// Only smoothed and snapped locations are indexed, since 2dsphere indexes skip
+		// documents without the field.
+		{Keys: bson.D{{Key: "smoothed_location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "snapped_location", Value: "2dsphere"}}},
 	}
 
-	_, err := collection.Indexes().CreateOne(ctx, twodsphere)
+	_, err := collection.Indexes().CreateMany(ctx, twodsphere)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create geospatial index: %w", err)
 	}
 
+	driverIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "driver_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.D{{Key: "driver_id", Value: bson.D{{Key: "$exists", Value: true}}}}),
+		},
+		{
+			Keys:    bson.D{{Key: "flagged_at", Value: -1}},
+			Options: options.Index().SetSparse(true),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, driverIndexes); err != nil {
+		return nil, fmt.Errorf("failed to create driver indexes: %w", err)
+	}
+
 	return repo, nil
 }
 
 func (d driverLocationRepository) Create(ctx context.Context, location *models.DriverLocation) error {
+	start := time.Now()
 	_, err := d.collection.InsertOne(ctx, location)
+	metrics.ObserveMongoOperation("insert_one", start, err)
 	if err != nil {
 		return fmt.Errorf("failed to insert driver location: %w", err)
 	}
@@ -58,7 +115,9 @@ func (d driverLocationRepository) CreateMany(ctx context.Context, locations []*m
 	}
 
 	opts := options.InsertMany().SetOrdered(false)
+	start := time.Now()
 	result, err := d.collection.InsertMany(ctx, docs, opts)
+	metrics.ObserveMongoOperation("insert_many", start, err)
 
 	insertedCount := 0
 	if result != nil {
@@ -72,22 +131,185 @@ func (d driverLocationRepository) CreateMany(ctx context.Context, locations []*m
 	return insertedCount, nil
 }
 
-func (d driverLocationRepository) Search(ctx context.Context, longitude, latitude, radius float64) ([]*models.SearchResult, error) {
+func (d driverLocationRepository) FindByDriverID(ctx context.Context, driverID string) (*models.DriverLocation, error) {
+	start := time.Now()
+	var location models.DriverLocation
+	err := d.collection.FindOne(ctx, bson.D{{Key: "driver_id", Value: driverID}}).Decode(&location)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one", start, nil)
+		return nil, ErrDriverNotFound
+	}
+	metrics.ObserveMongoOperation("find_one", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find driver: %w", err)
+	}
+	return &location, nil
+}
+
+func (d driverLocationRepository) UpdateDriver(ctx context.Context, location *models.DriverLocation, lastUpdatedAt time.Time, flags []models.Violation, at time.Time) error {
+	set := bson.D{
+		{Key: "location", Value: location.Location},
+		{Key: "updated_at", Value: location.UpdatedAt},
+		{Key: "static_since", Value: location.StaticSince},
+	}
+	if location.Vehicle != nil {
+		set = append(set, bson.E{Key: "vehicle", Value: location.Vehicle})
+	}
+	// The rating and acceptance rate change rarely, so an update without them
+	// keeps the previous ones.
+	if location.Rating != nil {
+		set = append(set, bson.E{Key: "rating", Value: *location.Rating})
+	}
+	if location.AcceptanceRate != nil {
+		set = append(set, bson.E{Key: "acceptance_rate", Value: *location.AcceptanceRate})
+	}
+	// Motion fields describe the latest fix only, so those it lacks are cleared.
+	// So is the idle time, which a driver on a trip no longer reports.
+	unset := bson.D{}
+	if !location.IdleSince.IsZero() {
+		set = append(set, bson.E{Key: "idle_since", Value: location.IdleSince})
+	} else {
+		unset = append(unset, bson.E{Key: "idle_since", Value: ""})
+	}
+	for _, field := range []struct {
+		key   string
+		value *float64
+	}{
+		{"heading", location.Heading},
+		{"speed", location.Speed},
+		{"accuracy", location.Accuracy},
+	} {
+		if field.value != nil {
+			set = append(set, bson.E{Key: field.key, Value: *field.value})
+		} else {
+			unset = append(unset, bson.E{Key: field.key, Value: ""})
+		}
+	}
+	if location.SmoothedLocation != nil {
+		set = append(set,
+			bson.E{Key: "smoothed_location", Value: location.SmoothedLocation},
+			bson.E{Key: "filter", Value: location.Filter},
+		)
+	}
+	if location.SnappedLocation != nil {
+		set = append(set, bson.E{Key: "snapped_location", Value: location.SnappedLocation})
+		if location.RoadSegmentID != "" {
+			set = append(set, bson.E{Key: "road_segment_id", Value: location.RoadSegmentID})
+		} else {
+			unset = append(unset, bson.E{Key: "road_segment_id", Value: ""})
+		}
+	}
+
+	filter := bson.D{{Key: "driver_id", Value: location.DriverID}}
+	if lastUpdatedAt.IsZero() {
+		filter = append(filter, bson.E{Key: "updated_at", Value: bson.D{{Key: "$exists", Value: false}}})
+	} else {
+		filter = append(filter, bson.E{Key: "updated_at", Value: lastUpdatedAt})
+	}
+	update := flagUpdate(set, flags, at)
+	if len(unset) > 0 {
+		update = append(update, bson.E{Key: "$unset", Value: unset})
+	}
+	opts := options.UpdateOne().SetUpsert(true)
+
+	start := time.Now()
+	_, err := d.collection.UpdateOne(ctx, filter, update, opts)
+	// When the driver was updated since it was read, the filter no longer
+	// matches and the upsert collides with it on the unique driver_id index.
+	if mongo.IsDuplicateKeyError(err) {
+		metrics.ObserveMongoOperation("update_one", start, nil)
+		return ErrConcurrentUpdate
+	}
+	metrics.ObserveMongoOperation("update_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to update driver location: %w", err)
+	}
+	return nil
+}
+
+func (d driverLocationRepository) FlagDriver(ctx context.Context, driverID string, flags []models.Violation, at time.Time) error {
+	if len(flags) == 0 {
+		return nil
+	}
+
+	start := time.Now()
+	result, err := d.collection.UpdateOne(ctx, bson.D{{Key: "driver_id", Value: driverID}}, flagUpdate(bson.D{}, flags, at))
+	metrics.ObserveMongoOperation("update_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to flag driver: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrDriverNotFound
+	}
+	return nil
+}
+
+// flagUpdate adds to set the fields raising flags at at, keeping one flag
+// per type with the number of times it was raised.
+func flagUpdate(set bson.D, flags []models.Violation, at time.Time) bson.D {
+	inc := bson.D{}
+	for _, f := range flags {
+		set = append(set,
+			bson.E{Key: "flags." + f.Type + ".detail", Value: f.Detail},
+			bson.E{Key: "flags." + f.Type + ".detected_at", Value: at},
+		)
+		inc = append(inc, bson.E{Key: "flags." + f.Type + ".count", Value: 1})
+	}
+	if len(flags) > 0 {
+		set = append(set, bson.E{Key: "flagged_at", Value: at})
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	if len(inc) > 0 {
+		update = append(update, bson.E{Key: "$inc", Value: inc})
+	}
+	return update
+}
+
+func (d driverLocationRepository) FindFlagged(ctx context.Context, since time.Time, limit int) ([]*models.DriverLocation, error) {
+	filter := bson.D{{Key: "flagged_at", Value: bson.D{{Key: "$gte", Value: since}}}}
+	opts := options.Find().SetSort(bson.D{{Key: "flagged_at", Value: -1}}).SetLimit(int64(limit))
+
+	start := time.Now()
+	cursor, err := d.collection.Find(ctx, filter, opts)
+	if err != nil {
+		metrics.ObserveMongoOperation("find", start, err)
+		return nil, fmt.Errorf("failed to find flagged drivers: %w", err)
+	}
+
+	drivers := []*models.DriverLocation{}
+	err = cursor.All(ctx, &drivers)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode flagged drivers: %w", err)
+	}
+	return drivers, nil
+}
+
+func (d driverLocationRepository) Search(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error) {
+	geoNear := bson.D{
+		{Key: "near", Value: bson.D{
+			{Key: "type", Value: "Point"},
+			{Key: "coordinates", Value: bson.A{longitude, latitude}},
+		}},
+		{Key: "key", Value: positionField(filter.Position)},
+		{Key: "distanceField", Value: "distance"},
+		{Key: "maxDistance", Value: radius},
+		{Key: "spherical", Value: true},
+	}
+	if query := buildSearchQuery(filter); len(query) > 0 {
+		geoNear = append(geoNear, bson.E{Key: "query", Value: query})
+	}
+
 	pipeline := mongo.Pipeline{
-		{{Key: "$geoNear", Value: bson.D{
-			{Key: "near", Value: bson.D{
-				{Key: "type", Value: "Point"},
-				{Key: "coordinates", Value: bson.A{longitude, latitude}},
-			}},
-			{Key: "distanceField", Value: "distance"},
-			{Key: "maxDistance", Value: radius},
-			{Key: "spherical", Value: true},
-		}}},
+		{{Key: "$geoNear", Value: geoNear}},
 		{{Key: "$limit", Value: config.MaxSearchResults}},
 	}
 
+	start := time.Now()
 	cursor, err := d.collection.Aggregate(ctx, pipeline)
 	if err != nil {
+		metrics.ObserveMongoOperation("aggregate", start, err)
 		return nil, fmt.Errorf("failed to aggregate driver locations: %w", err)
 	}
 	defer func() {
@@ -97,28 +319,212 @@ func (d driverLocationRepository) Search(ctx context.Context, longitude, latitud
 	}()
 
 	var results []struct {
-		ID       bson.ObjectID  `bson:"_id"`
-		Location models.GeoJSON `bson:"location"`
-		Distance float64        `bson:"distance"`
+		ID       bson.ObjectID                `bson:"_id"`
+		DriverID string                       `bson:"driver_id"`
+		Location models.GeoJSON               `bson:"location"`
+		Distance float64                      `bson:"distance"`
+		Vehicle  *models.Vehicle              `bson:"vehicle"`
+		Heading  *float64                     `bson:"heading"`
+		Speed    *float64                     `bson:"speed"`
+		Accuracy *float64                     `bson:"accuracy"`
+		Rating   *float64                     `bson:"rating"`
+		Accept   *float64                     `bson:"acceptance_rate"`
+		Idle     time.Time                    `bson:"idle_since"`
+		Smoothed *models.GeoJSON              `bson:"smoothed_location"`
+		Snapped  *models.GeoJSON              `bson:"snapped_location"`
+		Segment  string                       `bson:"road_segment_id"`
+		Flags    map[string]models.DriverFlag `bson:"flags"`
 	}
 
-	if err := cursor.All(ctx, &results); err != nil {
+	err = cursor.All(ctx, &results)
+	metrics.ObserveMongoOperation("aggregate", start, err)
+	if err != nil {
 		return nil, fmt.Errorf("failed to decode aggregation results: %w", err)
 	}
 
 	searchResults := make([]*models.SearchResult, len(results))
 	for i, r := range results {
 		searchResults[i] = &models.SearchResult{
-			DriverID:  r.ID.Hex(),
-			Latitude:  r.Location.Coordinates[1],
-			Longitude: r.Location.Coordinates[0],
-			Distance:  r.Distance,
+			DriverID:       r.DriverID,
+			Latitude:       r.Location.Coordinates[1],
+			Longitude:      r.Location.Coordinates[0],
+			Distance:       r.Distance,
+			Vehicle:        r.Vehicle,
+			Heading:        r.Heading,
+			Speed:          r.Speed,
+			Accuracy:       r.Accuracy,
+			Rating:         r.Rating,
+			AcceptanceRate: r.Accept,
+			IdleSince:      r.Idle,
+			Smoothed:       r.Smoothed,
+			Snapped:        r.Snapped,
+			RoadSegmentID:  r.Segment,
+			Flags:          r.Flags,
+		}
+		// Locations added without a driver are identified by their document.
+		if searchResults[i].DriverID == "" {
+			searchResults[i].DriverID = r.ID.Hex()
 		}
 	}
 
 	return searchResults, nil
 }
 
+func (d driverLocationRepository) Count(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) (int64, error) {
+	// $geoNear cannot be counted, so the circle is matched with $geoWithin,
+	// whose radius is in radians.
+	within := bson.D{{Key: "$geoWithin", Value: bson.D{
+		{Key: "$centerSphere", Value: bson.A{bson.A{longitude, latitude}, radius / geo.EarthRadiusMeters}},
+	}}}
+	query := append(bson.D{{Key: positionField(filter.Position), Value: within}}, buildSearchQuery(filter)...)
+
+	start := time.Now()
+	count, err := d.collection.CountDocuments(ctx, query)
+	metrics.ObserveMongoOperation("count_documents", start, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count driver locations: %w", err)
+	}
+	return count, nil
+}
+
+// buildSearchQuery translates a search filter into a $geoNear query document.
+func buildSearchQuery(filter models.SearchFilter) bson.D {
+	query := bson.D{}
+	if len(filter.VehicleTypes) > 0 {
+		query = append(query, bson.E{Key: "vehicle.type", Value: bson.D{{Key: "$in", Value: filter.VehicleTypes}}})
+	}
+	if filter.MinCapacity > 0 {
+		query = append(query, bson.E{Key: "vehicle.capacity", Value: bson.D{{Key: "$gte", Value: filter.MinCapacity}}})
+	}
+	if filter.WheelchairAccessible {
+		query = append(query, bson.E{Key: "vehicle.wheelchair_accessible", Value: true})
+	}
+	if filter.PetFriendly {
+		query = append(query, bson.E{Key: "vehicle.pet_friendly", Value: true})
+	}
+	if filter.MaxAccuracy > 0 {
+		query = append(query, bson.E{Key: "$or", Value: bson.A{
+			bson.D{{Key: "accuracy", Value: bson.D{{Key: "$lte", Value: filter.MaxAccuracy}}}},
+			bson.D{{Key: "accuracy", Value: bson.D{{Key: "$exists", Value: false}}}},
+		}})
+	}
+	return query
+}
+
 func (d driverLocationRepository) Ping(ctx context.Context) error {
-	return d.collection.Database().Client().Ping(ctx, nil)
+	start := time.Now()
+	err := d.collection.Database().Client().Ping(ctx, nil)
+	metrics.ObserveMongoOperation("ping", start, err)
+	return err
+}
+
+func (d driverLocationRepository) BackfillSmoothedLocations(ctx context.Context) (int64, error) {
+	filter := bson.D{{Key: "smoothed_location", Value: bson.D{{Key: "$exists", Value: false}}}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.D{{Key: "smoothed_location", Value: "$location"}}}}}
+
+	start := time.Now()
+	result, err := d.collection.UpdateMany(ctx, filter, update)
+	metrics.ObserveMongoOperation("update_many", start, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill smoothed locations: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+func (d driverLocationRepository) BackfillSnappedLocations(ctx context.Context, snap func(*models.DriverLocation)) (int64, error) {
+	missing := bson.D{{Key: "snapped_location", Value: bson.D{{Key: "$exists", Value: false}}}}
+
+	start := time.Now()
+	cursor, err := d.collection.Find(ctx, missing)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unsnapped locations: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			d.logger.Error("failed to close cursor", zap.Error(err))
+		}
+	}()
+
+	var updated int64
+	writes := make([]mongo.WriteModel, 0, config.BackfillBatchSize)
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		start := time.Now()
+		result, err := d.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		metrics.ObserveMongoOperation("bulk_write", start, err)
+		if err != nil {
+			return fmt.Errorf("failed to backfill snapped locations: %w", err)
+		}
+		updated += result.ModifiedCount
+		writes = writes[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var location models.DriverLocation
+		if err := cursor.Decode(&location); err != nil {
+			return updated, fmt.Errorf("failed to decode unsnapped location: %w", err)
+		}
+		snap(&location)
+		if location.SnappedLocation == nil {
+			continue
+		}
+
+		set := bson.D{{Key: "snapped_location", Value: location.SnappedLocation}}
+		if location.RoadSegmentID != "" {
+			set = append(set, bson.E{Key: "road_segment_id", Value: location.RoadSegmentID})
+		}
+		// A location snapped by an update since it was read is left alone.
+		filter := append(bson.D{{Key: "_id", Value: location.ID}}, missing...)
+		writes = append(writes, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.D{{Key: "$set", Value: set}}))
+		if len(writes) == config.BackfillBatchSize {
+			if err := flush(); err != nil {
+				return updated, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return updated, fmt.Errorf("failed to read unsnapped locations: %w", err)
+	}
+	if err := flush(); err != nil {
+		return updated, err
+	}
+	return updated, nil
+}
+
+// positionField returns the field holding the location searched for position.
+func positionField(position string) string {
+	switch position {
+	case config.PositionSmoothed:
+		return "smoothed_location"
+	case config.PositionSnapped:
+		return "snapped_location"
+	default:
+		return "location"
+	}
+}
+
+// CheckIndexes returns ErrIndexMissing unless the 2dsphere index on location exists.
+func (d driverLocationRepository) CheckIndexes(ctx context.Context) error {
+	start := time.Now()
+	specs, err := d.collection.Indexes().ListSpecifications(ctx)
+	metrics.ObserveMongoOperation("list_indexes", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	for _, spec := range specs {
+		var keys bson.D
+		if err := bson.Unmarshal(spec.KeysDocument, &keys); err != nil {
+			return fmt.Errorf("failed to decode index keys: %w", err)
+		}
+		if len(keys) == 1 && keys[0].Key == "location" && keys[0].Value == "2dsphere" {
+			return nil
+		}
+	}
+
+	return ErrIndexMissing
 }
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, expired and revoked keys alike.
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrAPIKeyInactive is returned when rotating a key that is expired or revoked.
+	ErrAPIKeyInactive = errors.New("api key is expired or revoked")
+)
+
+type APIKeyService interface {
+	// Authenticate returns the active key matching secret.
+	Authenticate(ctx context.Context, secret string) (*models.APIKey, error)
+	IssueKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*models.IssuedAPIKey, error)
+	// RotateKey issues a replacement for a key and lets the old one expire
+	// after overlap, so clients can switch without downtime. The replacement
+	// of an expiring key is given the same lifetime, counted from now, and
+	// the overlap never outlives the old key.
+	RotateKey(ctx context.Context, id string, overlap time.Duration) (*models.IssuedAPIKey, error)
+	RevokeKey(ctx context.Context, id string) (*models.APIKey, error)
+	ListKeys(ctx context.Context) ([]*models.APIKey, error)
+}
+
+type apiKeyService struct {
+	repo         repository.APIKeyRepository
+	bootstrapKey string
+	cache        *apiKeyCache
+	logger       *zap.Logger
+	now          func() time.Time
+}
+
+// NewAPIKeyService creates the key service. A non-empty bootstrapKey is
+// accepted with every scope, so the first stored keys can be issued.
+func NewAPIKeyService(repo repository.APIKeyRepository, bootstrapKey string, cacheTTL time.Duration, logger *zap.Logger) APIKeyService {
+	return apiKeyService{
+		repo:         repo,
+		bootstrapKey: bootstrapKey,
+		cache:        &apiKeyCache{ttl: cacheTTL, entries: map[string]apiKeyCacheEntry{}},
+		logger:       logger,
+		now:          time.Now,
+	}
+}
+
+func (s apiKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if s.bootstrapKey != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(s.bootstrapKey)) == 1 {
+		return &models.APIKey{ID: config.BootstrapAPIKeyID, Name: config.BootstrapAPIKeyID, Scopes: config.AllScopes}, nil
+	}
+
+	// Keys are looked up by hash, so comparing the secret itself is not needed.
+	hash := hashAPIKey(secret)
+	now := s.now()
+
+	key, ok := s.cache.get(hash, now)
+	if !ok {
+		var err error
+		key, err = s.repo.FindKeyByHash(ctx, hash)
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, ErrInvalidAPIKey
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.cache.put(hash, key, now)
+	}
+
+	if !key.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+func (s apiKeyService) IssueKey(ctx context.Context, name string, scopes []string, expiresAt *time.Time) (*models.IssuedAPIKey, error) {
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		ID:        uuid.NewString(),
+		Name:      name,
+		Prefix:    secret[:config.APIKeyDisplayLength],
+		Hash:      hashAPIKey(secret),
+		Scopes:    scopes,
+		CreatedAt: s.now().UTC(),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.CreateKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("issued api key",
+		zap.String("key_id", key.ID),
+		zap.String("name", key.Name),
+		zap.Strings("scopes", key.Scopes),
+	)
+	return &models.IssuedAPIKey{APIKey: key, Secret: secret}, nil
+}
+
+func (s apiKeyService) RotateKey(ctx context.Context, id string, overlap time.Duration) (*models.IssuedAPIKey, error) {
+	old, err := s.repo.FindKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !old.Active(s.now()) {
+		return nil, ErrAPIKeyInactive
+	}
+
+	now := s.now()
+	var expiresAt *time.Time
+	if old.ExpiresAt != nil {
+		replacementExpiry := now.Add(old.ExpiresAt.Sub(old.CreatedAt)).UTC()
+		expiresAt = &replacementExpiry
+	}
+
+	issued, err := s.IssueKey(ctx, old.Name, old.Scopes, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	oldExpiry := now.Add(overlap).UTC()
+	if old.ExpiresAt != nil && old.ExpiresAt.Before(oldExpiry) {
+		oldExpiry = *old.ExpiresAt
+	}
+	if err := s.repo.ExpireKey(ctx, id, oldExpiry); err != nil {
+		return nil, fmt.Errorf("failed to expire rotated api key: %w", err)
+	}
+	s.cache.forget(old.Hash)
+
+	logging.FromContext(ctx, s.logger).Info("rotated api key",
+		zap.String("key_id", id),
+		zap.String("replacement_id", issued.APIKey.ID),
+		zap.Duration("overlap", overlap),
+	)
+	return issued, nil
+}
+
+func (s apiKeyService) RevokeKey(ctx context.Context, id string) (*models.APIKey, error) {
+	if err := s.repo.RevokeKey(ctx, id, s.now().UTC()); err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.FindKeyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.forget(key.Hash)
+
+	logging.FromContext(ctx, s.logger).Info("revoked api key", zap.String("key_id", id))
+	return key, nil
+}
+
+func (s apiKeyService) ListKeys(ctx context.Context) ([]*models.APIKey, error) {
+	return s.repo.ListKeys(ctx)
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	return config.APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyCache keeps recently used keys so that authenticating a request does
+// not hit the store every time. Revocations made through another instance
+// take effect once the entry expires.
+type apiKeyCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]apiKeyCacheEntry
+}
+
+type apiKeyCacheEntry struct {
+	key      *models.APIKey
+	cachedAt time.Time
+}
+
+func (c *apiKeyCache) get(hash string, now time.Time) (*models.APIKey, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[hash]
+	if !ok || now.Sub(entry.cachedAt) >= c.ttl {
+		delete(c.entries, hash)
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func (c *apiKeyCache) put(hash string, key *models.APIKey, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[hash] = apiKeyCacheEntry{key: key, cachedAt: now}
+}
+
+func (c *apiKeyCache) forget(hash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, hash)
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+)
+
+const testBootstrapKey = "bootstrap-key"
+
+// countingKeyRepository counts lookups by hash to observe the cache.
+type countingKeyRepository struct {
+	repository.APIKeyRepository
+	lookups int
+}
+
+func (r *countingKeyRepository) FindKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	r.lookups++
+	return r.APIKeyRepository.FindKeyByHash(ctx, hash)
+}
+
+// setupAPIKeyTest returns a key service backed by a temporary file, with a
+// clock the test can move.
+func setupAPIKeyTest(t *testing.T) (apiKeyService, *countingKeyRepository, *time.Time) {
+	fileRepo, err := repository.NewFileAPIKeyRepository(filepath.Join(t.TempDir(), "keys.json"))
+	require.NoError(t, err)
+
+	repo := &countingKeyRepository{APIKeyRepository: fileRepo}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc := NewAPIKeyService(repo, testBootstrapKey, time.Minute, zap.NewNop()).(apiKeyService)
+	svc.now = func() time.Time { return now }
+	return svc, repo, &now
+}
+
+func TestAPIKeyService_IssueKey(t *testing.T) {
+	// Setup
+	svc, repo, _ := setupAPIKeyTest(t)
+	ctx := context.Background()
+
+	// Execute
+	issued, err := svc.IssueKey(ctx, "matching", []string{config.ScopeLocationsRead}, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Secret, config.APIKeyPrefix))
+	assert.Equal(t, issued.Secret[:config.APIKeyDisplayLength], issued.APIKey.Prefix)
+
+	stored, err := repo.FindKeyByID(ctx, issued.APIKey.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored.Hash, issued.Secret)
+	assert.Equal(t, hashAPIKey(issued.Secret), stored.Hash)
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	svc, _, now := setupAPIKeyTest(t)
+	ctx := context.Background()
+
+	expiresAt := now.Add(time.Hour)
+	active, err := svc.IssueKey(ctx, "active", []string{config.ScopeLocationsRead}, nil)
+	require.NoError(t, err)
+	expiring, err := svc.IssueKey(ctx, "expiring", []string{config.ScopeLocationsRead}, &expiresAt)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		secret        string
+		advance       time.Duration
+		expectedID    string
+		expectedError error
+	}{
+		{
+			name:       "success - bootstrap key",
+			secret:     testBootstrapKey,
+			expectedID: config.BootstrapAPIKeyID,
+		},
+		{
+			name:       "success - issued key",
+			secret:     active.Secret,
+			expectedID: active.APIKey.ID,
+		},
+		{
+			name:       "success - key before expiry",
+			secret:     expiring.Secret,
+			expectedID: expiring.APIKey.ID,
+		},
+		{
+			name:          "failure - key after expiry",
+			secret:        expiring.Secret,
+			advance:       2 * time.Hour,
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:          "failure - unknown key",
+			secret:        config.APIKeyPrefix + "unknown",
+			expectedError: ErrInvalidAPIKey,
+		},
+		{
+			name:          "failure - empty key",
+			secret:        "",
+			expectedError: ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			original := *now
+			*now = now.Add(tt.advance)
+			defer func() { *now = original }()
+
+			// Execute
+			key, err := svc.Authenticate(ctx, tt.secret)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, key)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedID, key.ID)
+			}
+		})
+	}
+}
+
+func TestAPIKeyService_AuthenticateCachesKeys(t *testing.T) {
+	// Setup
+	svc, repo, now := setupAPIKeyTest(t)
+	ctx := context.Background()
+	issued, err := svc.IssueKey(ctx, "matching", []string{config.ScopeLocationsRead}, nil)
+	require.NoError(t, err)
+
+	// Execute
+	for range 3 {
+		_, err = svc.Authenticate(ctx, issued.Secret)
+		require.NoError(t, err)
+	}
+	*now = now.Add(2 * time.Minute)
+	_, err = svc.Authenticate(ctx, issued.Secret)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.lookups)
+}
+
+func TestAPIKeyService_RotateKey(t *testing.T) {
+	// Setup
+	svc, _, now := setupAPIKeyTest(t)
+	ctx := context.Background()
+	old, err := svc.IssueKey(ctx, "gateway", []string{config.ScopeLocationsWrite}, nil)
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, old.Secret)
+	require.NoError(t, err)
+
+	// Execute
+	replacement, err := svc.RotateKey(ctx, old.APIKey.ID, time.Hour)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, old.APIKey.Name, replacement.APIKey.Name)
+	assert.Equal(t, old.APIKey.Scopes, replacement.APIKey.Scopes)
+	assert.NotEqual(t, old.Secret, replacement.Secret)
+
+	_, err = svc.Authenticate(ctx, old.Secret)
+	assert.NoError(t, err, "old key must keep working during the overlap")
+	_, err = svc.Authenticate(ctx, replacement.Secret)
+	assert.NoError(t, err)
+
+	*now = now.Add(2 * time.Hour)
+	_, err = svc.Authenticate(ctx, old.Secret)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey)
+	_, err = svc.Authenticate(ctx, replacement.Secret)
+	assert.NoError(t, err)
+
+	_, err = svc.RotateKey(ctx, old.APIKey.ID, time.Hour)
+	assert.ErrorIs(t, err, ErrAPIKeyInactive)
+}
+
+func TestAPIKeyService_RotateExpiringKey(t *testing.T) {
+	// Setup
+	svc, _, now := setupAPIKeyTest(t)
+	ctx := context.Background()
+	expiresAt := now.Add(30 * 24 * time.Hour)
+	old, err := svc.IssueKey(ctx, "gateway", []string{config.ScopeLocationsWrite}, &expiresAt)
+	require.NoError(t, err)
+	*now = now.Add(29*24*time.Hour + 12*time.Hour)
+
+	// Execute
+	replacement, err := svc.RotateKey(ctx, old.APIKey.ID, 24*time.Hour)
+
+	// Assert
+	require.NoError(t, err)
+	require.NotNil(t, replacement.APIKey.ExpiresAt)
+	assert.Equal(t, now.Add(30*24*time.Hour), *replacement.APIKey.ExpiresAt, "replacement keeps the lifetime of the old key")
+
+	rotated, err := svc.repo.FindKeyByID(ctx, old.APIKey.ID)
+	require.NoError(t, err)
+	require.NotNil(t, rotated.ExpiresAt)
+	assert.Equal(t, expiresAt, *rotated.ExpiresAt, "overlap must not extend the old key")
+}
+
+func TestAPIKeyService_RevokeKey(t *testing.T) {
+	// Setup
+	svc, _, _ := setupAPIKeyTest(t)
+	ctx := context.Background()
+	issued, err := svc.IssueKey(ctx, "ops", []string{config.ScopeLocationsImport}, nil)
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, issued.Secret)
+	require.NoError(t, err)
+
+	// Execute
+	revoked, err := svc.RevokeKey(ctx, issued.APIKey.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+	_, err = svc.Authenticate(ctx, issued.Secret)
+	assert.ErrorIs(t, err, ErrInvalidAPIKey, "revocation must bypass the cache")
+
+	_, err = svc.RevokeKey(ctx, "missing")
+	assert.ErrorIs(t, err, repository.ErrAPIKeyNotFound)
+}
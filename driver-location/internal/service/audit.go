@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type AuditService interface {
+	// Record appends entry to the audit log, stamping it with the current time.
+	Record(ctx context.Context, entry *models.AuditEntry) error
+	List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error)
+}
+
+type auditService struct {
+	repo   repository.AuditRepository
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewAuditService(repo repository.AuditRepository, logger *zap.Logger) AuditService {
+	return auditService{repo: repo, logger: logger, now: time.Now}
+}
+
+func (s auditService) Record(ctx context.Context, entry *models.AuditEntry) error {
+	entry.Timestamp = s.now().UTC()
+	if err := s.repo.Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record %s audit entry: %w", entry.Operation, err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("audit entry recorded",
+		zap.String("operation", entry.Operation),
+		zap.String("actor", entry.Actor),
+		zap.String("target", entry.Target),
+	)
+	return nil
+}
+
+func (s auditService) List(ctx context.Context, filter models.AuditFilter) ([]*models.AuditEntry, error) {
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit entries: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type PickupPointService interface {
+	// CreatePickupPoint stores a new point, assigning its ID and timestamps.
+	CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error
+	ListPickupPoints(ctx context.Context, zone string) ([]*models.PickupPoint, error)
+	// UpdatePickupPoint replaces the name, zone and location of the point
+	// with the ID of point and returns the updated point.
+	UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) (*models.PickupPoint, error)
+	// DeletePickupPoint removes a point and returns it.
+	DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error)
+	// SearchPickupPoints returns the points within radius metres of a
+	// location, nearest first, restricted to zone when it is set.
+	SearchPickupPoints(ctx context.Context, latitude, longitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error)
+}
+
+type pickupPointService struct {
+	repo   repository.PickupPointRepository
+	logger *zap.Logger
+	now    func() time.Time
+}
+
+func NewPickupPointService(repo repository.PickupPointRepository, logger *zap.Logger) PickupPointService {
+	return pickupPointService{repo: repo, logger: logger, now: time.Now}
+}
+
+func (s pickupPointService) CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	now := s.now().UTC()
+	point.ID = uuid.NewString()
+	point.CreatedAt = now
+	point.UpdatedAt = now
+
+	if err := s.repo.CreatePickupPoint(ctx, point); err != nil {
+		return fmt.Errorf("failed to create pickup point: %w", err)
+	}
+
+	logging.FromContext(ctx, s.logger).Info("created pickup point",
+		zap.String("pickup_point_id", point.ID),
+		zap.String("name", point.Name),
+		zap.String("zone", point.Zone),
+	)
+	return nil
+}
+
+func (s pickupPointService) ListPickupPoints(ctx context.Context, zone string) ([]*models.PickupPoint, error) {
+	points, err := s.repo.ListPickupPoints(ctx, zone, config.MaxPickupPoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pickup points: %w", err)
+	}
+	return points, nil
+}
+
+func (s pickupPointService) UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) (*models.PickupPoint, error) {
+	point.UpdatedAt = s.now().UTC()
+	if err := s.repo.UpdatePickupPoint(ctx, point); err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).Info("updated pickup point", zap.String("pickup_point_id", point.ID))
+	return s.repo.FindPickupPoint(ctx, point.ID)
+}
+
+func (s pickupPointService) DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	point, err := s.repo.DeletePickupPoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logging.FromContext(ctx, s.logger).Info("deleted pickup point", zap.String("pickup_point_id", id))
+	return point, nil
+}
+
+func (s pickupPointService) SearchPickupPoints(ctx context.Context, latitude, longitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error) {
+	points, err := s.repo.NearbyPickupPoints(ctx, longitude, latitude, radius, zone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search pickup points: %w", err)
+	}
+	return points, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+)
+
+// MockPickupPointRepository is a mock implementation of repository.PickupPointRepository
+type MockPickupPointRepository struct {
+	mock.Mock
+}
+
+func (m *MockPickupPointRepository) CreatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	args := m.Called(ctx, point)
+	return args.Error(0)
+}
+
+func (m *MockPickupPointRepository) FindPickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointRepository) ListPickupPoints(ctx context.Context, zone string, limit int) ([]*models.PickupPoint, error) {
+	args := m.Called(ctx, zone, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointRepository) UpdatePickupPoint(ctx context.Context, point *models.PickupPoint) error {
+	args := m.Called(ctx, point)
+	return args.Error(0)
+}
+
+func (m *MockPickupPointRepository) DeletePickupPoint(ctx context.Context, id string) (*models.PickupPoint, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PickupPoint), args.Error(1)
+}
+
+func (m *MockPickupPointRepository) NearbyPickupPoints(ctx context.Context, longitude, latitude, radius float64, zone string) ([]*models.NearbyPickupPoint, error) {
+	args := m.Called(ctx, longitude, latitude, radius, zone)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.NearbyPickupPoint), args.Error(1)
+}
+
+func setupPickupPointTest(now time.Time) (*MockPickupPointRepository, pickupPointService) {
+	mockRepo := &MockPickupPointRepository{}
+	svc := NewPickupPointService(mockRepo, zap.NewNop()).(pickupPointService)
+	svc.now = func() time.Time { return now }
+	return mockRepo, svc
+}
+
+func TestPickupPointService_CreatePickupPoint(t *testing.T) {
+	// Setup
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mockRepo, svc := setupPickupPointTest(now)
+	mockRepo.On("CreatePickupPoint", mock.Anything, mock.AnythingOfType("*models.PickupPoint")).Return(nil)
+	point := &models.PickupPoint{Name: "Terminal 1 Gate 4", Location: models.GeoJSON{Type: "Point", Coordinates: []float64{28.81, 40.98}}}
+
+	// Execute
+	err := svc.CreatePickupPoint(context.Background(), point)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEmpty(t, point.ID)
+	assert.Equal(t, now, point.CreatedAt)
+	assert.Equal(t, now, point.UpdatedAt)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPickupPointService_ListPickupPoints(t *testing.T) {
+	// Setup
+	mockRepo, svc := setupPickupPointTest(time.Now())
+	mockRepo.On("ListPickupPoints", mock.Anything, "airport", config.MaxPickupPoints).Return([]*models.PickupPoint{{ID: "p1"}}, nil)
+
+	// Execute
+	points, err := svc.ListPickupPoints(context.Background(), "airport")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, points, 1)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPickupPointService_UpdatePickupPoint(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		repoErr       error
+		expectedError error
+	}{
+		{name: "success"},
+		{name: "not found", repoErr: repository.ErrPickupPointNotFound, expectedError: repository.ErrPickupPointNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo, svc := setupPickupPointTest(now)
+			mockRepo.On("UpdatePickupPoint", mock.Anything, mock.MatchedBy(func(p *models.PickupPoint) bool {
+				return p.ID == "p1" && p.UpdatedAt.Equal(now)
+			})).Return(tt.repoErr)
+			if tt.repoErr == nil {
+				mockRepo.On("FindPickupPoint", mock.Anything, "p1").Return(&models.PickupPoint{ID: "p1", Name: "Gate 5", UpdatedAt: now}, nil)
+			}
+
+			// Execute
+			updated, err := svc.UpdatePickupPoint(context.Background(), &models.PickupPoint{ID: "p1", Name: "Gate 5"})
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, updated)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "Gate 5", updated.Name)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPickupPointService_SearchPickupPoints(t *testing.T) {
+	// Setup
+	mockRepo, svc := setupPickupPointTest(time.Now())
+	mockRepo.On("NearbyPickupPoints", mock.Anything, 28.81, 40.98, 150.0, "").
+		Return([]*models.NearbyPickupPoint{{PickupPoint: models.PickupPoint{ID: "p1"}, Distance: 12}}, nil)
+
+	// Execute
+	points, err := svc.SearchPickupPoints(context.Background(), 40.98, 28.81, 150, "")
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, points, 1)
+	assert.Equal(t, 12.0, points[0].Distance)
+	mockRepo.AssertExpectations(t)
+}
@@ -3,48 +3,123 @@ package service
 import (
 	"context"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
+	"time"
 
 	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/mapmatch"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/metrics"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/smoothing"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/spoofing"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+var (
+	// ErrInvalidCSV is returned when imported CSV data cannot be parsed.
+	ErrInvalidCSV = errors.New("invalid CSV data")
+	// ErrImplausibleLocation is returned when a driver location update is
+	// rejected by spoofing detection.
+	ErrImplausibleLocation = errors.New("implausible location")
+	// ErrSmoothingDisabled is returned when smoothed locations are searched
+	// while smoothing is disabled.
+	ErrSmoothingDisabled = errors.New("smoothing is disabled")
+	// ErrMapMatchingDisabled is returned when snapped locations are searched
+	// while map matching is disabled.
+	ErrMapMatchingDisabled = errors.New("map matching is disabled")
 )
 
 type Service interface {
 	CreateDriverLocation(ctx context.Context, location *models.DriverLocation) error
+	// CreateDriverLocationBulk and ImportDriverLocationsFromCSV return the
+	// rows written even when they fail part way; the result is nil only if
+	// nothing was attempted.
 	CreateDriverLocationBulk(ctx context.Context, locations []*models.DriverLocation) (*models.BulkResult, error)
-	SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64) ([]*models.SearchResult, error)
+	SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error)
+	// CountDriverLocations returns the number of drivers SearchDriverLocation
+	// would find, without its result limit.
+	CountDriverLocations(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) (int64, error)
 	ImportDriverLocationsFromCSV(ctx context.Context, reader io.Reader) (*models.BulkResult, error)
-	HealthCheck(ctx context.Context) error
+	// ListFlaggedDrivers returns the drivers with flags raised within the flag TTL.
+	ListFlaggedDrivers(ctx context.Context) ([]*models.DriverLocation, error)
+	CheckReadiness(ctx context.Context) []models.DependencyCheck
 }
 
 type service struct {
-	repo   repository.DriverLocationRepository
-	logger *zap.Logger
+	repo     repository.DriverLocationRepository
+	detector *spoofing.Detector
+	// smoother is nil when smoothing is disabled.
+	smoother *smoothing.Filter
+	// matcher is nil when map matching is disabled.
+	matcher *mapmatch.Matcher
+	// flagTTL is how long a flag raised against a driver applies.
+	flagTTL time.Duration
+	logger  *zap.Logger
+	now     func() time.Time
 }
 
-func NewService(repo repository.DriverLocationRepository, logger *zap.Logger) Service {
+// NewService creates the location service. smoother and matcher may be nil
+// to disable smoothing and map matching.
+func NewService(repo repository.DriverLocationRepository, detector *spoofing.Detector, smoother *smoothing.Filter, matcher *mapmatch.Matcher, flagTTL time.Duration, logger *zap.Logger) Service {
 	return &service{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		detector: detector,
+		smoother: smoother,
+		matcher:  matcher,
+		flagTTL:  flagTTL,
+		logger:   logger,
+		now:      time.Now,
 	}
 }
 
-func (s service) HealthCheck(ctx context.Context) error {
-	if err := s.repo.Ping(ctx); err != nil {
-		s.logger.Error("health check failed", zap.Error(err))
-		return fmt.Errorf("health check failed: %w", err)
+// log returns the request-scoped logger of ctx, falling back to the service logger.
+func (s service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// CheckReadiness probes MongoDB and the geospatial index that search depends on.
+func (s service) CheckReadiness(ctx context.Context) []models.DependencyCheck {
+	checks := []models.DependencyCheck{
+		s.check(ctx, config.DependencyMongoDB, s.repo.Ping),
+		s.check(ctx, config.DependencyGeoIndex, s.repo.CheckIndexes),
+	}
+
+	for _, check := range checks {
+		if check.Err != nil {
+			s.log(ctx).Error("readiness check failed", zap.String("dependency", check.Name), zap.Error(check.Err))
+		}
+	}
+
+	return checks
+}
+
+func (s service) check(ctx context.Context, name string, probe func(context.Context) error) models.DependencyCheck {
+	start := time.Now()
+	err := probe(ctx)
+	return models.DependencyCheck{
+		Name:    name,
+		Latency: time.Since(start),
+		Err:     err,
 	}
-	return nil
 }
 
 func (s service) CreateDriverLocation(ctx context.Context, location *models.DriverLocation) error {
+	if location.DriverID != "" {
+		return s.updateDriverLocation(ctx, location)
+	}
+
+	s.startSmoothing(location)
+	s.snap(location)
 	err := s.repo.Create(ctx, location)
 	if err != nil {
-		s.logger.Error("failed to create driver location",
+		s.log(ctx).Error("failed to create driver location",
 			zap.Error(err),
 		)
 		return fmt.Errorf("failed to create driver location: %w", err)
@@ -53,16 +128,116 @@ func (s service) CreateDriverLocation(ctx context.Context, location *models.Driv
 	return nil
 }
 
+// updateDriverLocation moves a driver after checking the update against its
+// previous location. Implausible updates are flagged against the driver and,
+// depending on the spoofing mode, rejected. The update is checked again
+// against the new location when the driver moved in the meantime.
+func (s service) updateDriverLocation(ctx context.Context, location *models.DriverLocation) error {
+	var err error
+	for range config.MaxDriverUpdateAttempts {
+		err = s.tryUpdateDriverLocation(ctx, location)
+		if !errors.Is(err, repository.ErrConcurrentUpdate) {
+			return err
+		}
+		s.log(ctx).Warn("driver was updated concurrently, retrying", zap.String("driver_id", location.DriverID))
+	}
+	return fmt.Errorf("failed to update driver location: %w", err)
+}
+
+// tryUpdateDriverLocation reads, checks and writes a driver once, returning
+// repository.ErrConcurrentUpdate when the driver changed in between.
+func (s service) tryUpdateDriverLocation(ctx context.Context, location *models.DriverLocation) error {
+	now := s.now().UTC()
+
+	prev, err := s.repo.FindByDriverID(ctx, location.DriverID)
+	if err != nil && !errors.Is(err, repository.ErrDriverNotFound) {
+		s.log(ctx).Error("failed to find driver", zap.Error(err), zap.String("driver_id", location.DriverID))
+		return fmt.Errorf("failed to find driver: %w", err)
+	}
+
+	location.UpdatedAt = now
+	location.StaticSince = now
+	if prev != nil && spoofing.SameCoordinates(prev, location) && !prev.StaticSince.IsZero() {
+		location.StaticSince = prev.StaticSince
+	}
+
+	violations := s.detector.Check(prev, location, now)
+	rejected := s.detector.Rejects(violations)
+	for _, v := range violations {
+		metrics.AddSpoofingFlag(v.Type, rejected)
+		s.log(ctx).Warn("implausible driver location update",
+			zap.String("driver_id", location.DriverID),
+			zap.String("flag", v.Type),
+			zap.String("detail", v.Detail),
+			zap.Bool("rejected", rejected),
+		)
+	}
+
+	if rejected {
+		if err := s.repo.FlagDriver(ctx, location.DriverID, violations, now); err != nil {
+			s.log(ctx).Error("failed to flag driver", zap.Error(err), zap.String("driver_id", location.DriverID))
+		}
+		return fmt.Errorf("%w: %s", ErrImplausibleLocation, violations[0].Detail)
+	}
+
+	if s.smoother != nil {
+		s.smoother.Smooth(prev, location, now)
+	}
+	s.snap(location)
+
+	var lastUpdatedAt time.Time
+	if prev != nil {
+		lastUpdatedAt = prev.UpdatedAt
+	}
+	if err := s.repo.UpdateDriver(ctx, location, lastUpdatedAt, violations, now); err != nil {
+		if errors.Is(err, repository.ErrConcurrentUpdate) {
+			return err
+		}
+		s.log(ctx).Error("failed to update driver location",
+			zap.Error(err),
+			zap.String("driver_id", location.DriverID),
+		)
+		return fmt.Errorf("failed to update driver location: %w", err)
+	}
+
+	return nil
+}
+
+// startSmoothing gives a location that is not tied to a driver its raw
+// position as smoothed location, so that it is found by smoothed searches.
+func (s service) startSmoothing(location *models.DriverLocation) {
+	if s.smoother == nil {
+		return
+	}
+	location.SmoothedLocation = &models.GeoJSON{
+		Type:        location.Location.Type,
+		Coordinates: slices.Clone(location.Location.Coordinates),
+	}
+}
+
+// snap moves location onto the road network when map matching is enabled.
+func (s service) snap(location *models.DriverLocation) {
+	if s.matcher != nil {
+		s.matcher.Match(location)
+	}
+}
+
 func (s service) CreateDriverLocationBulk(ctx context.Context, locations []*models.DriverLocation) (*models.BulkResult, error) {
-	successCount, err := s.repo.CreateMany(ctx, locations)
-	if err != nil {
-		s.logger.Error("failed to create driver locations due to general error",
-			zap.Error(err))
-		return nil, fmt.Errorf("failed to create driver locations: %w", err)
+	return s.createBulk(ctx, config.ImportSourceBulk, locations)
+}
+
+// createBulk inserts locations and counts the imported rows under source.
+func (s service) createBulk(ctx context.Context, source string, locations []*models.DriverLocation) (*models.BulkResult, error) {
+	for _, location := range locations {
+		s.startSmoothing(location)
+		s.snap(location)
 	}
 
+	successCount, err := s.repo.CreateMany(ctx, locations)
+
 	totalCount := len(locations)
 	failCount := totalCount - successCount
+	metrics.AddImportRows(source, successCount, failCount)
 
 	result := &models.BulkResult{
 		Total:      totalCount,
@@ -70,8 +245,16 @@ func (s service) CreateDriverLocationBulk(ctx context.Context, locations []*mode
 		Failed:     failCount,
 	}
 
+	if err != nil {
+		s.log(ctx).Error("failed to create driver locations due to general error",
+			zap.Error(err),
+			zap.Int("successful", successCount),
+		)
+		return result, fmt.Errorf("failed to create driver locations: %w", err)
+	}
+
 	if failCount > 0 {
-		s.logger.Warn("some driver locations failed to be created in bulk operation",
+		s.log(ctx).Warn("some driver locations failed to be created in bulk operation",
 			zap.Int("total", totalCount),
 			zap.Int("successful", successCount),
 			zap.Int("failed", failCount),
@@ -81,10 +264,14 @@ func (s service) CreateDriverLocationBulk(ctx context.Context, locations []*mode
 	return result, nil
 }
 
-func (s service) SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64) ([]*models.SearchResult, error) {
-	results, err := s.repo.Search(ctx, longitude, latitude, radius)
+func (s service) SearchDriverLocation(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error) {
+	if err := s.checkPosition(filter.Position); err != nil {
+		return nil, err
+	}
+
+	results, err := s.repo.Search(ctx, longitude, latitude, radius, filter)
 	if err != nil {
-		s.logger.Error("failed to search driver locations",
+		s.log(ctx).Error("failed to search driver locations",
 			zap.Error(err),
 			zap.Float64("latitude", latitude),
 			zap.Float64("longitude", longitude),
@@ -93,15 +280,80 @@ func (s service) SearchDriverLocation(ctx context.Context, latitude, longitude,
 		return nil, fmt.Errorf("failed to search driver locations: %w", err)
 	}
 
+	now := s.now()
+	since := now.Add(-s.flagTTL)
+	for _, r := range results {
+		r.ActiveFlags = models.ActiveFlags(r.Flags, since)
+		if !r.IdleSince.IsZero() {
+			// Clocks of driver apps may run ahead of this one.
+			idle := max(now.Sub(r.IdleSince).Seconds(), 0)
+			r.IdleSeconds = &idle
+		}
+	}
+
 	return results, nil
 }
 
+func (s service) CountDriverLocations(ctx context.Context, latitude, longitude, radius float64, filter models.SearchFilter) (int64, error) {
+	if err := s.checkPosition(filter.Position); err != nil {
+		return 0, err
+	}
+
+	count, err := s.repo.Count(ctx, longitude, latitude, radius, filter)
+	if err != nil {
+		s.log(ctx).Error("failed to count driver locations",
+			zap.Error(err),
+			zap.Float64("latitude", latitude),
+			zap.Float64("longitude", longitude),
+			zap.Float64("radius", radius),
+		)
+		return 0, fmt.Errorf("failed to count driver locations: %w", err)
+	}
+	return count, nil
+}
+
+// checkPosition returns an error when the locations searched for position
+// are not stored.
+func (s service) checkPosition(position string) error {
+	if position == config.PositionSmoothed && s.smoother == nil {
+		return ErrSmoothingDisabled
+	}
+	if position == config.PositionSnapped && s.matcher == nil {
+		return ErrMapMatchingDisabled
+	}
+	return nil
+}
+
+func (s service) ListFlaggedDrivers(ctx context.Context) ([]*models.DriverLocation, error) {
+	since := s.now().Add(-s.flagTTL)
+	drivers, err := s.repo.FindFlagged(ctx, since, config.MaxFlaggedDrivers)
+	if err != nil {
+		s.log(ctx).Error("failed to list flagged drivers", zap.Error(err))
+		return nil, fmt.Errorf("failed to list flagged drivers: %w", err)
+	}
+
+	// Only the flags that still apply are listed.
+	for _, d := range drivers {
+		for flagType, flag := range d.Flags {
+			if flag.DetectedAt.Before(since) {
+				delete(d.Flags, flagType)
+			}
+		}
+	}
+
+	return drivers, nil
+}
+
 func (s service) ImportDriverLocationsFromCSV(ctx context.Context, reader io.Reader) (*models.BulkResult, error) {
 	csvReader := csv.NewReader(reader)
 	records, err := csvReader.ReadAll()
 	if err != nil {
-		s.logger.Error("Failed to read CSV data", zap.Error(err))
-		return nil, fmt.Errorf("failed to read CSV data: %w", err)
+		s.log(ctx).Error("Failed to read CSV data", zap.Error(err))
+		return nil, fmt.Errorf("%w: failed to read CSV data: %w", ErrInvalidCSV, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: missing header row", ErrInvalidCSV)
 	}
 
 	// Assuming the first row is a header, so we skip it.
@@ -109,25 +361,67 @@ func (s service) ImportDriverLocationsFromCSV(ctx context.Context, reader io.Rea
 
 	var locations []*models.DriverLocation
 	for _, record := range records {
+		if len(record) < 2 {
+			return nil, fmt.Errorf("%w: expected latitude and longitude columns", ErrInvalidCSV)
+		}
+
 		lat, err := strconv.ParseFloat(record[0], 64)
 		if err != nil {
-			s.logger.Error("Failed to parse latitude from CSV record",
+			s.log(ctx).Error("Failed to parse latitude from CSV record",
 				zap.Error(err),
 				zap.Strings("record", record),
 			)
-			return nil, fmt.Errorf("failed to parse latitude: %w", err)
+			return nil, fmt.Errorf("%w: failed to parse latitude: %w", ErrInvalidCSV, err)
 		}
 
 		lon, err := strconv.ParseFloat(record[1], 64)
 		if err != nil {
-			s.logger.Error("Failed to parse longitude from CSV record",
+			s.log(ctx).Error("Failed to parse longitude from CSV record",
+				zap.Error(err),
+				zap.Strings("record", record),
+			)
+			return nil, fmt.Errorf("%w: failed to parse longitude: %w", ErrInvalidCSV, err)
+		}
+		location := models.NewDriverLocation(lat, lon)
+		if err := parseMotion(record, location); err != nil {
+			s.log(ctx).Error("Failed to parse motion from CSV record",
 				zap.Error(err),
 				zap.Strings("record", record),
 			)
-			return nil, fmt.Errorf("failed to parse longitude: %w", err)
+			return nil, err
 		}
-		locations = append(locations, models.NewDriverLocation(lat, lon))
+		locations = append(locations, location)
+	}
+
+	return s.createBulk(ctx, config.ImportSourceCSV, locations)
+}
+
+// parseMotion sets the heading, speed and accuracy of location from the
+// optional columns following latitude and longitude in record. Empty or
+// missing cells are left unset.
+func parseMotion(record []string, location *models.DriverLocation) error {
+	columns := []struct {
+		name   string
+		target **float64
+		valid  func(float64) bool
+	}{
+		{"heading", &location.Heading, func(v float64) bool { return v >= 0 && v < 360 }},
+		{"speed", &location.Speed, func(v float64) bool { return v >= 0 && v <= 100 }},
+		{"accuracy", &location.Accuracy, func(v float64) bool { return v > 0 && v <= 10000 }},
 	}
 
-	return s.CreateDriverLocationBulk(ctx, locations)
+	for i, column := range columns {
+		if 2+i >= len(record) || record[2+i] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(record[2+i], 64)
+		if err != nil {
+			return fmt.Errorf("%w: failed to parse %s: %w", ErrInvalidCSV, column.name, err)
+		}
+		if !column.valid(v) {
+			return fmt.Errorf("%w: %s out of range: %v", ErrInvalidCSV, column.name, v)
+		}
+		*column.target = &v
+	}
+	return nil
 }
@@ -5,12 +5,19 @@ import (
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/mapmatch"
 	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/repository"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/smoothing"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/spoofing"
 )
 
 // MockRepository is a mock implementation of repository.DriverLocationRepository
@@ -32,49 +39,138 @@ func (m *MockRepository) CreateMany(ctx context.Context, locations []*models.Dri
 	return args.Int(0), args.Error(1)
 }
 
-func (m *MockRepository) Search(ctx context.Context, longitude, latitude, radius float64) ([]*models.SearchResult, error) {
-	args := m.Called(ctx, longitude, latitude, radius)
+func (m *MockRepository) Search(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) ([]*models.SearchResult, error) {
+	args := m.Called(ctx, longitude, latitude, radius, filter)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).([]*models.SearchResult), args.Error(1)
 }
 
+func (m *MockRepository) FindByDriverID(ctx context.Context, driverID string) (*models.DriverLocation, error) {
+	args := m.Called(ctx, driverID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DriverLocation), args.Error(1)
+}
+
+func (m *MockRepository) UpdateDriver(ctx context.Context, location *models.DriverLocation, lastUpdatedAt time.Time, flags []models.Violation, at time.Time) error {
+	args := m.Called(ctx, location, lastUpdatedAt, flags, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FlagDriver(ctx context.Context, driverID string, flags []models.Violation, at time.Time) error {
+	args := m.Called(ctx, driverID, flags, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindFlagged(ctx context.Context, since time.Time, limit int) ([]*models.DriverLocation, error) {
+	args := m.Called(ctx, since, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DriverLocation), args.Error(1)
+}
+
+func (m *MockRepository) Count(ctx context.Context, longitude, latitude, radius float64, filter models.SearchFilter) (int64, error) {
+	args := m.Called(ctx, longitude, latitude, radius, filter)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) BackfillSmoothedLocations(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) BackfillSnappedLocations(ctx context.Context, snap func(*models.DriverLocation)) (int64, error) {
+	args := m.Called(ctx, snap)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockRepository) Ping(ctx context.Context) error {
 	args := m.Called(ctx)
 	return args.Error(0)
 }
 
+func (m *MockRepository) CheckIndexes(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
 // Test helpers
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+const testFlagTTL = 24 * time.Hour
+
 func setupTest() (*MockRepository, Service, context.Context) {
+	return setupSpoofingTest(config.SpoofingModeFlag)
+}
+
+// setupSpoofingTest returns a service detecting spoofing in mode, whose clock
+// is stopped at testNow.
+func setupSpoofingTest(mode string) (*MockRepository, Service, context.Context) {
 	mockRepo := NewMockRepository()
-	logger := zap.NewNop()
-	svc := NewService(mockRepo, logger)
+	detector := spoofing.NewDetector(spoofing.Config{
+		Mode:            mode,
+		MaxSpeed:        200 / 3.6,
+		MaxJumpDistance: 10000,
+		JumpWindow:      5 * time.Minute,
+		StaticDuration:  time.Hour,
+	})
+	svc := &service{
+		repo:     mockRepo,
+		detector: detector,
+		flagTTL:  testFlagTTL,
+		logger:   zap.NewNop(),
+		now:      func() time.Time { return testNow },
+	}
 	ctx := context.Background()
 	return mockRepo, svc, ctx
 }
 
-func TestHealthCheck(t *testing.T) {
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// testDriver returns the location of driver d1 last updated at updatedAt.
+func testDriver(lat, lon float64, updatedAt, staticSince time.Time) *models.DriverLocation {
+	location := models.NewDriverLocation(lat, lon)
+	location.DriverID = "d1"
+	location.UpdatedAt = updatedAt
+	location.StaticSince = staticSince
+	return location
+}
+
+func TestCheckReadiness(t *testing.T) {
 	tests := []struct {
-		name          string
-		mockSetup     func(*MockRepository, context.Context)
-		expectedError bool
-		errorContains string
+		name           string
+		mockSetup      func(*MockRepository, context.Context)
+		expectedErrors map[string]string
 	}{
 		{
-			name: "success",
+			name: "ready",
 			mockSetup: func(m *MockRepository, ctx context.Context) {
 				m.On("Ping", ctx).Return(nil).Once()
+				m.On("CheckIndexes", ctx).Return(nil).Once()
 			},
-			expectedError: false,
+			expectedErrors: map[string]string{},
 		},
 		{
 			name: "failure - db down",
 			mockSetup: func(m *MockRepository, ctx context.Context) {
 				m.On("Ping", ctx).Return(errors.New("db down")).Once()
+				m.On("CheckIndexes", ctx).Return(errors.New("db down")).Once()
 			},
-			expectedError: true,
-			errorContains: "health check failed",
+			expectedErrors: map[string]string{"mongodb": "db down", "geo_index": "db down"},
+		},
+		{
+			name: "failure - index missing",
+			mockSetup: func(m *MockRepository, ctx context.Context) {
+				m.On("Ping", ctx).Return(nil).Once()
+				m.On("CheckIndexes", ctx).Return(repository.ErrIndexMissing).Once()
+			},
+			expectedErrors: map[string]string{"geo_index": repository.ErrIndexMissing.Error()},
 		},
 	}
 
@@ -85,15 +181,17 @@ func TestHealthCheck(t *testing.T) {
 			tt.mockSetup(mockRepo, ctx)
 
 			// Execute
-			err := svc.HealthCheck(ctx)
+			checks := svc.CheckReadiness(ctx)
 
 			// Assert
-			if tt.expectedError {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.errorContains)
-			} else {
-				assert.NoError(t, err)
+			assert.Len(t, checks, 2)
+			errs := map[string]string{}
+			for _, check := range checks {
+				if check.Err != nil {
+					errs[check.Name] = check.Err.Error()
+				}
 			}
+			assert.Equal(t, tt.expectedErrors, errs)
 			mockRepo.AssertExpectations(t)
 		})
 	}
@@ -147,6 +245,352 @@ func TestCreateDriverLocation(t *testing.T) {
 	}
 }
 
+func TestCreateDriverLocation_Driver(t *testing.T) {
+	// About 11 km north of the previous location.
+	const farLatitude = 40.1
+
+	tests := []struct {
+		name                string
+		mode                string
+		prev                *models.DriverLocation
+		findErr             error
+		next                *models.DriverLocation
+		expectedFlags       []string
+		expectedStaticSince time.Time
+		expectedRejection   bool
+		expectedError       bool
+	}{
+		{
+			name:                "new driver",
+			mode:                config.SpoofingModeReject,
+			findErr:             repository.ErrDriverNotFound,
+			next:                testDriver(40.0, 29.0, time.Time{}, time.Time{}),
+			expectedStaticSince: testNow,
+		},
+		{
+			name:                "plausible move",
+			mode:                config.SpoofingModeReject,
+			prev:                testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-time.Minute)),
+			next:                testDriver(40.01, 29.0, time.Time{}, time.Time{}),
+			expectedStaticSince: testNow,
+		},
+		{
+			name:                "teleport is flagged",
+			mode:                config.SpoofingModeFlag,
+			prev:                testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-time.Minute)),
+			next:                testDriver(farLatitude, 29.0, time.Time{}, time.Time{}),
+			expectedFlags:       []string{spoofing.FlagTeleport, spoofing.FlagImplausibleSpeed},
+			expectedStaticSince: testNow,
+		},
+		{
+			name:              "teleport is rejected",
+			mode:              config.SpoofingModeReject,
+			prev:              testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-time.Minute)),
+			next:              testDriver(farLatitude, 29.0, time.Time{}, time.Time{}),
+			expectedFlags:     []string{spoofing.FlagTeleport, spoofing.FlagImplausibleSpeed},
+			expectedRejection: true,
+			expectedError:     true,
+		},
+		{
+			name:                "static location is flagged but not rejected",
+			mode:                config.SpoofingModeReject,
+			prev:                testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-2*time.Hour)),
+			next:                testDriver(40.0, 29.0, time.Time{}, time.Time{}),
+			expectedFlags:       []string{spoofing.FlagStaticLocation},
+			expectedStaticSince: testNow.Add(-2 * time.Hour),
+		},
+		{
+			name:                "detection off",
+			mode:                config.SpoofingModeOff,
+			prev:                testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-time.Minute)),
+			next:                testDriver(farLatitude, 29.0, time.Time{}, time.Time{}),
+			expectedStaticSince: testNow,
+		},
+		{
+			name:          "failure - find error",
+			mode:          config.SpoofingModeFlag,
+			findErr:       errors.New("db error"),
+			next:          testDriver(40.0, 29.0, time.Time{}, time.Time{}),
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo, svc, ctx := setupSpoofingTest(tt.mode)
+			mockRepo.On("FindByDriverID", ctx, "d1").Return(tt.prev, tt.findErr).Once()
+			flagsMatch := mock.MatchedBy(func(flags []models.Violation) bool {
+				var types []string
+				for _, f := range flags {
+					types = append(types, f.Type)
+				}
+				return assert.ObjectsAreEqual(tt.expectedFlags, types)
+			})
+			switch {
+			case tt.expectedRejection:
+				mockRepo.On("FlagDriver", ctx, "d1", flagsMatch, testNow).Return(nil).Once()
+			case tt.findErr == nil || errors.Is(tt.findErr, repository.ErrDriverNotFound):
+				var lastUpdatedAt time.Time
+				if tt.prev != nil {
+					lastUpdatedAt = tt.prev.UpdatedAt
+				}
+				mockRepo.On("UpdateDriver", ctx, tt.next, lastUpdatedAt, flagsMatch, testNow).Return(nil).Once()
+			}
+
+			// Execute
+			err := svc.CreateDriverLocation(ctx, tt.next)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Equal(t, tt.expectedRejection, errors.Is(err, ErrImplausibleLocation))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, testNow, tt.next.UpdatedAt)
+				assert.Equal(t, tt.expectedStaticSince, tt.next.StaticSince)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateDriverLocation_ConcurrentUpdate(t *testing.T) {
+	// About 11 km north of the location read first.
+	const farLatitude = 40.1
+
+	tests := []struct {
+		name          string
+		conflicts     int
+		expectedError bool
+	}{
+		{
+			name:      "checked again against the location that won the race",
+			conflicts: 1,
+		},
+		{
+			name:          "failure - keeps losing the race",
+			conflicts:     config.MaxDriverUpdateAttempts,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo, svc, ctx := setupSpoofingTest(config.SpoofingModeFlag)
+			stale := testDriver(40.0, 29.0, testNow.Add(-time.Minute), testNow.Add(-time.Minute))
+			// The driver moved next to the new location since it was first read.
+			current := testDriver(40.099, 29.0, testNow.Add(-30*time.Second), testNow.Add(-30*time.Second))
+			next := testDriver(farLatitude, 29.0, time.Time{}, time.Time{})
+			mockRepo.On("FindByDriverID", ctx, "d1").Return(stale, nil).Once()
+			mockRepo.On("UpdateDriver", ctx, next, stale.UpdatedAt, mock.Anything, testNow).Return(repository.ErrConcurrentUpdate).Once()
+			if tt.conflicts > 1 {
+				mockRepo.On("FindByDriverID", ctx, "d1").Return(current, nil).Times(tt.conflicts - 1)
+				mockRepo.On("UpdateDriver", ctx, next, current.UpdatedAt, mock.Anything, testNow).Return(repository.ErrConcurrentUpdate).Times(tt.conflicts - 1)
+			} else {
+				mockRepo.On("FindByDriverID", ctx, "d1").Return(current, nil).Once()
+				mockRepo.On("UpdateDriver", ctx, next, current.UpdatedAt, []models.Violation(nil), testNow).Return(nil).Once()
+			}
+
+			// Execute
+			err := svc.CreateDriverLocation(ctx, next)
+
+			// Assert
+			if tt.expectedError {
+				assert.ErrorIs(t, err, repository.ErrConcurrentUpdate)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateDriverLocation_Smoothing(t *testing.T) {
+	// Setup
+	mockRepo, _, ctx := setupSpoofingTest(config.SpoofingModeFlag)
+	svc := &service{
+		repo:     mockRepo,
+		detector: spoofing.NewDetector(spoofing.Config{Mode: config.SpoofingModeOff}),
+		smoother: smoothing.NewFilter(smoothing.Config{ProcessNoise: 0.5, MeasurementNoise: 15, ResetAfter: time.Minute}),
+		logger:   zap.NewNop(),
+		now:      func() time.Time { return testNow },
+	}
+	prev := testDriver(40.0, 29.0, testNow.Add(-5*time.Second), testNow.Add(-5*time.Second))
+	prev.SmoothedLocation = &models.GeoJSON{Type: "Point", Coordinates: []float64{29.0, 40.0}}
+	prev.Filter = &models.KalmanState{PositionVariance: 100, VelocityVariance: 10, UpdatedAt: testNow.Add(-5 * time.Second)}
+	moved := testDriver(40.0005, 29.0, time.Time{}, time.Time{})
+	unlinked := models.NewDriverLocation(41.0, 30.0)
+	mockRepo.On("FindByDriverID", ctx, "d1").Return(prev, nil).Once()
+	mockRepo.On("UpdateDriver", ctx, moved, prev.UpdatedAt, mock.Anything, testNow).Return(nil).Once()
+	mockRepo.On("Create", ctx, unlinked).Return(nil).Once()
+
+	// Execute
+	errMoved := svc.CreateDriverLocation(ctx, moved)
+	errUnlinked := svc.CreateDriverLocation(ctx, unlinked)
+
+	// Assert
+	assert.NoError(t, errMoved)
+	assert.NoError(t, errUnlinked)
+	// The smoothed location lies between the previous estimate and the fix.
+	assert.Greater(t, moved.SmoothedLocation.Coordinates[1], 40.0)
+	assert.Less(t, moved.SmoothedLocation.Coordinates[1], 40.0005)
+	assert.Equal(t, testNow, moved.Filter.UpdatedAt)
+	// Locations without a driver have no history to smooth.
+	assert.Equal(t, unlinked.Location, *unlinked.SmoothedLocation)
+	assert.NotSame(t, &unlinked.Location.Coordinates[0], &unlinked.SmoothedLocation.Coordinates[0])
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchDriverLocation_SmoothingDisabled(t *testing.T) {
+	// Setup
+	mockRepo, svc, ctx := setupTest()
+
+	// Execute
+	results, err := svc.SearchDriverLocation(ctx, 40.0, 29.0, 1000, models.SearchFilter{Position: config.PositionSmoothed})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrSmoothingDisabled)
+	assert.Nil(t, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCountDriverLocations(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        models.SearchFilter
+		mockSetup     func(*MockRepository, context.Context)
+		expectedCount int64
+		expectedErr   error
+		expectError   bool
+	}{
+		{
+			name:   "success",
+			filter: models.SearchFilter{MaxAccuracy: 50},
+			mockSetup: func(m *MockRepository, ctx context.Context) {
+				m.On("Count", ctx, 29.0, 40.0, 1000.0, models.SearchFilter{MaxAccuracy: 50}).Return(int64(250), nil)
+			},
+			expectedCount: 250,
+		},
+		{
+			name:   "repository error",
+			filter: models.SearchFilter{},
+			mockSetup: func(m *MockRepository, ctx context.Context) {
+				m.On("Count", ctx, 29.0, 40.0, 1000.0, models.SearchFilter{}).Return(int64(0), errors.New("database error"))
+			},
+			expectError: true,
+		},
+		{
+			name:        "smoothing disabled",
+			filter:      models.SearchFilter{Position: config.PositionSmoothed},
+			mockSetup:   func(m *MockRepository, ctx context.Context) {},
+			expectedErr: ErrSmoothingDisabled,
+		},
+		{
+			name:        "map matching disabled",
+			filter:      models.SearchFilter{Position: config.PositionSnapped},
+			mockSetup:   func(m *MockRepository, ctx context.Context) {},
+			expectedErr: ErrMapMatchingDisabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo, svc, ctx := setupTest()
+			tt.mockSetup(mockRepo, ctx)
+
+			// Execute
+			count, err := svc.CountDriverLocations(ctx, 40.0, 29.0, 1000, tt.filter)
+
+			// Assert
+			switch {
+			case tt.expectedErr != nil:
+				assert.ErrorIs(t, err, tt.expectedErr)
+			case tt.expectError:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCount, count)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateDriverLocation_MapMatching(t *testing.T) {
+	// Setup: a road running east along latitude 40.
+	network, err := mapmatch.ParseNetwork(strings.NewReader(`from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,40.0,29.0,b,40.0,29.01,50,false`))
+	require.NoError(t, err)
+	mockRepo, _, ctx := setupSpoofingTest(config.SpoofingModeFlag)
+	svc := &service{
+		repo:     mockRepo,
+		detector: spoofing.NewDetector(spoofing.Config{Mode: config.SpoofingModeOff}),
+		matcher:  mapmatch.NewMatcher(network, 50),
+		logger:   zap.NewNop(),
+		now:      func() time.Time { return testNow },
+	}
+	// About 20 m north of the road.
+	driver := testDriver(40.00018, 29.005, time.Time{}, time.Time{})
+	offRoad := models.NewDriverLocation(40.01, 29.005)
+	mockRepo.On("FindByDriverID", ctx, "d1").Return(nil, repository.ErrDriverNotFound).Once()
+	mockRepo.On("UpdateDriver", ctx, driver, mock.Anything, mock.Anything, testNow).Return(nil).Once()
+	mockRepo.On("Create", ctx, offRoad).Return(nil).Once()
+
+	// Execute
+	errDriver := svc.CreateDriverLocation(ctx, driver)
+	errOffRoad := svc.CreateDriverLocation(ctx, offRoad)
+
+	// Assert
+	assert.NoError(t, errDriver)
+	assert.NoError(t, errOffRoad)
+	assert.Equal(t, "a:b", driver.RoadSegmentID)
+	assert.InDelta(t, 40.0, driver.SnappedLocation.Coordinates[1], 1e-9)
+	assert.InDelta(t, 29.005, driver.SnappedLocation.Coordinates[0], 1e-9)
+	// The raw fix is kept.
+	assert.Equal(t, 40.00018, driver.Latitude())
+	// Locations off the road network keep their position.
+	assert.Empty(t, offRoad.RoadSegmentID)
+	assert.Equal(t, offRoad.Location, *offRoad.SnappedLocation)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchDriverLocation_MapMatchingDisabled(t *testing.T) {
+	// Setup
+	mockRepo, svc, ctx := setupTest()
+
+	// Execute
+	results, err := svc.SearchDriverLocation(ctx, 40.0, 29.0, 1000, models.SearchFilter{Position: config.PositionSnapped})
+
+	// Assert
+	assert.ErrorIs(t, err, ErrMapMatchingDisabled)
+	assert.Nil(t, results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListFlaggedDrivers(t *testing.T) {
+	// Setup
+	mockRepo, svc, ctx := setupTest()
+	driver := testDriver(40.0, 29.0, testNow, testNow)
+	driver.Flags = map[string]models.DriverFlag{
+		spoofing.FlagTeleport:       {DetectedAt: testNow.Add(-time.Hour), Count: 2},
+		spoofing.FlagStaticLocation: {DetectedAt: testNow.Add(-48 * time.Hour), Count: 1},
+	}
+	mockRepo.On("FindFlagged", ctx, testNow.Add(-testFlagTTL), config.MaxFlaggedDrivers).
+		Return([]*models.DriverLocation{driver}, nil).Once()
+
+	// Execute
+	drivers, err := svc.ListFlaggedDrivers(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, drivers, 1)
+	assert.Equal(t, []string{spoofing.FlagTeleport}, models.ActiveFlags(drivers[0].Flags, time.Time{}))
+	mockRepo.AssertExpectations(t)
+}
+
 func TestCreateDriverLocationBulk(t *testing.T) {
 	testLocations := []*models.DriverLocation{
 		models.NewDriverLocation(40.0, 29.0),
@@ -185,12 +629,15 @@ func TestCreateDriverLocationBulk(t *testing.T) {
 			expectedFailed:     1,
 		},
 		{
-			name:      "failure - db error",
+			name:      "failure - db error after a partial write",
 			locations: testLocations,
 			mockSetup: func(m *MockRepository, ctx context.Context, locs []*models.DriverLocation) {
-				m.On("CreateMany", ctx, locs).Return(0, errors.New("db error")).Once()
+				m.On("CreateMany", ctx, locs).Return(1, errors.New("db error")).Once()
 			},
-			expectedError: true,
+			expectedError:      true,
+			expectedTotal:      2,
+			expectedSuccessful: 1,
+			expectedFailed:     1,
 		},
 	}
 
@@ -206,14 +653,13 @@ func TestCreateDriverLocationBulk(t *testing.T) {
 			// Assert
 			if tt.expectedError {
 				assert.Error(t, err)
-				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
-				assert.NotNil(t, result)
-				assert.Equal(t, tt.expectedTotal, result.Total)
-				assert.Equal(t, tt.expectedSuccessful, result.Successful)
-				assert.Equal(t, tt.expectedFailed, result.Failed)
 			}
+			require.NotNil(t, result)
+			assert.Equal(t, tt.expectedTotal, result.Total)
+			assert.Equal(t, tt.expectedSuccessful, result.Successful)
+			assert.Equal(t, tt.expectedFailed, result.Failed)
 			mockRepo.AssertExpectations(t)
 		})
 	}
@@ -225,6 +671,7 @@ func TestSearchDriverLocation(t *testing.T) {
 		testLongitude = 29.0
 		testRadius    = 1000.0
 	)
+	testFilter := models.SearchFilter{VehicleTypes: []string{"xl"}}
 
 	tests := []struct {
 		name            string
@@ -244,7 +691,7 @@ func TestSearchDriverLocation(t *testing.T) {
 				expectedResults := []*models.SearchResult{
 					{Latitude: 40.1, Longitude: 29.1, Distance: 500},
 				}
-				m.On("Search", ctx, testLongitude, testLatitude, testRadius).Return(expectedResults, nil).Once()
+				m.On("Search", ctx, testLongitude, testLatitude, testRadius, testFilter).Return(expectedResults, nil).Once()
 			},
 			expectedError: false,
 			expectedResults: []*models.SearchResult{
@@ -257,7 +704,7 @@ func TestSearchDriverLocation(t *testing.T) {
 			longitude: testLongitude,
 			radius:    testRadius,
 			mockSetup: func(m *MockRepository, ctx context.Context) {
-				m.On("Search", ctx, testLongitude, testLatitude, testRadius).Return(nil, errors.New("db error")).Once()
+				m.On("Search", ctx, testLongitude, testLatitude, testRadius, testFilter).Return(nil, errors.New("db error")).Once()
 			},
 			expectedError:   true,
 			expectedResults: nil,
@@ -271,7 +718,7 @@ func TestSearchDriverLocation(t *testing.T) {
 			tt.mockSetup(mockRepo, ctx)
 
 			// Execute
-			results, err := svc.SearchDriverLocation(ctx, tt.latitude, tt.longitude, tt.radius)
+			results, err := svc.SearchDriverLocation(ctx, tt.latitude, tt.longitude, tt.radius, testFilter)
 
 			// Assert
 			if tt.expectedError {
@@ -286,6 +733,47 @@ func TestSearchDriverLocation(t *testing.T) {
 	}
 }
 
+func TestSearchDriverLocation_IdleSeconds(t *testing.T) {
+	tests := []struct {
+		name                string
+		idleSince           time.Time
+		expectedIdleSeconds *float64
+	}{
+		{
+			name:                "idle time is not reported",
+			expectedIdleSeconds: nil,
+		},
+		{
+			name:                "idle since ten minutes",
+			idleSince:           testNow.Add(-10 * time.Minute),
+			expectedIdleSeconds: ptr(600.0),
+		},
+		{
+			name:                "idle since in the future",
+			idleSince:           testNow.Add(time.Minute),
+			expectedIdleSeconds: ptr(0.0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockRepo, svc, ctx := setupTest()
+			mockRepo.On("Search", ctx, 29.0, 40.0, 1000.0, models.SearchFilter{}).
+				Return([]*models.SearchResult{{DriverID: "d1", IdleSince: tt.idleSince}}, nil).Once()
+
+			// Execute
+			results, err := svc.SearchDriverLocation(ctx, 40.0, 29.0, 1000, models.SearchFilter{})
+
+			// Assert
+			require.NoError(t, err)
+			require.Len(t, results, 1)
+			assert.Equal(t, tt.expectedIdleSeconds, results[0].IdleSeconds)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestImportDriverLocationsFromCSV(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -309,6 +797,36 @@ func TestImportDriverLocationsFromCSV(t *testing.T) {
 			expectedTotal:      2,
 			expectedSuccessful: 2,
 		},
+		{
+			name: "success - motion columns",
+			csvContent: `lat,lon,heading,speed,accuracy
+40.0,29.0,90,12.5,8
+41.0,30.0,,,`,
+			mockSetup: func(m *MockRepository, ctx context.Context) {
+				m.On("CreateMany", ctx, mock.MatchedBy(func(locs []*models.DriverLocation) bool {
+					return len(locs) == 2 &&
+						*locs[0].Heading == 90 && *locs[0].Speed == 12.5 && *locs[0].Accuracy == 8 &&
+						locs[1].Heading == nil && locs[1].Speed == nil && locs[1].Accuracy == nil
+				})).Return(2, nil).Once()
+			},
+			expectedError:      false,
+			expectedTotal:      2,
+			expectedSuccessful: 2,
+		},
+		{
+			name: "failure - heading out of range",
+			csvContent: `lat,lon,heading
+40.0,29.0,400`,
+			mockSetup:     func(m *MockRepository, ctx context.Context) {},
+			expectedError: true,
+		},
+		{
+			name: "failure - invalid accuracy",
+			csvContent: `lat,lon,heading,speed,accuracy
+40.0,29.0,90,10,good`,
+			mockSetup:     func(m *MockRepository, ctx context.Context) {},
+			expectedError: true,
+		},
 		{
 			name: "failure - invalid latitude",
 			csvContent: `lat,lon
@@ -316,6 +834,19 @@ invalid,29.0`,
 			mockSetup:     func(m *MockRepository, ctx context.Context) {},
 			expectedError: true,
 		},
+		{
+			name:          "failure - empty csv",
+			csvContent:    ``,
+			mockSetup:     func(m *MockRepository, ctx context.Context) {},
+			expectedError: true,
+		},
+		{
+			name: "failure - missing longitude column",
+			csvContent: `lat
+41.0`,
+			mockSetup:     func(m *MockRepository, ctx context.Context) {},
+			expectedError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -330,7 +861,7 @@ invalid,29.0`,
 
 			// Assert
 			if tt.expectedError {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrInvalidCSV)
 				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
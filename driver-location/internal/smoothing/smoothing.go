@@ -0,0 +1,113 @@
+// Package smoothing filters the GPS jitter out of driver trajectories with a
+// constant-velocity Kalman filter, so that distances to a driver do not jump
+// by tens of metres between updates.
+package smoothing
+
+import (
+	"math"
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+// initialSpeedStdDev is the uncertainty, in metres per second, of the
+// velocity of a driver the filter has just started tracking.
+const initialSpeedStdDev = 10.0
+
+// Config sets the noise model of the filter.
+type Config struct {
+	// ProcessNoise is the standard deviation of the acceleration of a
+	// driver, in metres per second squared. Higher values follow turns and
+	// stops faster but smooth less.
+	ProcessNoise float64
+	// MeasurementNoise is the standard deviation, in metres, of GPS fixes
+	// that do not report their accuracy.
+	MeasurementNoise float64
+	// ResetAfter is the gap between updates after which the filter restarts
+	// from the raw fix, since the previous estimate says little by then.
+	ResetAfter time.Duration
+}
+
+// Filter smooths the locations reported by drivers.
+type Filter struct {
+	cfg Config
+}
+
+func NewFilter(cfg Config) *Filter {
+	return &Filter{cfg: cfg}
+}
+
+// Smooth sets the smoothed location and filter state of next, reported at
+// now, from the previous location of the driver. The filter starts from the
+// raw fix when the driver has no previous state or it is too old.
+func (f *Filter) Smooth(prev, next *models.DriverLocation, now time.Time) {
+	noise := f.cfg.MeasurementNoise
+	if next.Accuracy != nil && *next.Accuracy > 0 {
+		noise = *next.Accuracy
+	}
+	r := noise * noise
+
+	if prev == nil || prev.Filter == nil || prev.SmoothedLocation == nil || now.Sub(prev.Filter.UpdatedAt) > f.cfg.ResetAfter {
+		next.SmoothedLocation = point(next.Latitude(), next.Longitude())
+		next.Filter = &models.KalmanState{
+			PositionVariance: r,
+			VelocityVariance: initialSpeedStdDev * initialSpeedStdDev,
+			UpdatedAt:        now,
+		}
+		return
+	}
+
+	state := *prev.Filter
+	dt := math.Max(now.Sub(state.UpdatedAt).Seconds(), 0)
+
+	// Positions are handled in metres north and east of the previous
+	// estimate, which is flat enough over the distances between updates.
+	lat0 := prev.SmoothedLocation.Coordinates[1]
+	lon0 := prev.SmoothedLocation.Coordinates[0]
+	metersPerLonDegree := geo.MetersPerLonDegree(lat0)
+	north := (next.Latitude() - lat0) * geo.MetersPerDegree
+	east := geo.NormalizeLongitude(next.Longitude()-lon0) * metersPerLonDegree
+
+	// Both axes share the covariance, since the noise is the same along each.
+	pp, pv, vv := predict(state.PositionVariance, state.Covariance, state.VelocityVariance, dt, f.cfg.ProcessNoise*f.cfg.ProcessNoise)
+	s := pp + r
+	kp, kv := pp/s, pv/s
+
+	predictedNorth := state.VelocityNorth * dt
+	predictedEast := state.VelocityEast * dt
+	residualNorth := north - predictedNorth
+	residualEast := east - predictedEast
+
+	smoothedNorth := predictedNorth + kp*residualNorth
+	smoothedEast := predictedEast + kp*residualEast
+
+	next.SmoothedLocation = point(lat0+smoothedNorth/geo.MetersPerDegree, lon0+smoothedEast/metersPerLonDegree)
+	next.Filter = &models.KalmanState{
+		VelocityNorth:    state.VelocityNorth + kv*residualNorth,
+		VelocityEast:     state.VelocityEast + kv*residualEast,
+		PositionVariance: (1 - kp) * pp,
+		Covariance:       (1 - kp) * pv,
+		VelocityVariance: vv - kv*pv,
+		UpdatedAt:        now,
+	}
+}
+
+// predict advances the covariance of one axis by dt seconds under white
+// acceleration noise of variance q.
+func predict(pp, pv, vv, dt, q float64) (float64, float64, float64) {
+	dt2 := dt * dt
+	return pp + 2*dt*pv + dt2*vv + q*dt2*dt2/4,
+		pv + dt*vv + q*dt2*dt/2,
+		vv + q*dt2
+}
+
+// point returns a GeoJSON point, clamping the latitude to the poles and
+// wrapping the longitude, since the estimate of a driver near a pole or the
+// antimeridian can overshoot them and MongoDB rejects such points.
+func point(lat, lon float64) *models.GeoJSON {
+	return &models.GeoJSON{
+		Type:        "Point",
+		Coordinates: []float64{geo.NormalizeLongitude(lon), math.Max(-90, math.Min(90, lat))},
+	}
+}
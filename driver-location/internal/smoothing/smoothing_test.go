@@ -0,0 +1,155 @@
+package smoothing
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+var testStart = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testFilter() *Filter {
+	return NewFilter(Config{ProcessNoise: 0.5, MeasurementNoise: 15, ResetAfter: 2 * time.Minute})
+}
+
+// track feeds fixes reported every interval through the filter and returns
+// the last location.
+func track(f *Filter, fixes [][2]float64, interval time.Duration) *models.DriverLocation {
+	var prev *models.DriverLocation
+	for i, fix := range fixes {
+		next := models.NewDriverLocation(fix[0], fix[1])
+		f.Smooth(prev, next, testStart.Add(time.Duration(i)*interval))
+		prev = next
+	}
+	return prev
+}
+
+func TestFilter_Smooth_FirstFix(t *testing.T) {
+	// Setup
+	next := models.NewDriverLocation(41.0, 29.0)
+
+	// Execute
+	testFilter().Smooth(nil, next, testStart)
+
+	// Assert
+	require.NotNil(t, next.SmoothedLocation)
+	assert.Equal(t, []float64{29.0, 41.0}, next.SmoothedLocation.Coordinates)
+	assert.Equal(t, testStart, next.Filter.UpdatedAt)
+	assert.Zero(t, next.Filter.VelocityNorth)
+}
+
+func TestFilter_Smooth_Jitter(t *testing.T) {
+	// Setup: a parked driver whose fixes scatter with a 15 m deviation.
+	const lat, lon = 41.0, 29.0
+	rng := rand.New(rand.NewPCG(1, 2))
+	f := testFilter()
+
+	// Execute
+	var prev *models.DriverLocation
+	var rawError, smoothedError float64
+	for i := range 200 {
+		next := models.NewDriverLocation(lat+rng.NormFloat64()*15/geo.MetersPerDegree, lon)
+		f.Smooth(prev, next, testStart.Add(time.Duration(i)*time.Second))
+		prev = next
+		// Errors are compared once the filter has settled.
+		if i >= 20 {
+			rawError += geo.Distance(lat, lon, next.Latitude(), next.Longitude())
+			smoothedError += geo.Distance(lat, lon, next.SmoothedLocation.Coordinates[1], next.SmoothedLocation.Coordinates[0])
+		}
+	}
+
+	// Assert
+	assert.Less(t, smoothedError, rawError*0.6)
+}
+
+func TestFilter_Smooth_ConstantVelocity(t *testing.T) {
+	// Setup: a driver heading north at 10 m/s.
+	const lat, lon = 41.0, 29.0
+	step := 50 / geo.MetersPerDegree
+	var fixes [][2]float64
+	for i := range 30 {
+		fixes = append(fixes, [2]float64{lat + float64(i)*step, lon})
+	}
+
+	// Execute
+	last := track(testFilter(), fixes, 5*time.Second)
+
+	// Assert
+	smoothed := last.SmoothedLocation.Coordinates
+	assert.Less(t, geo.Distance(last.Latitude(), last.Longitude(), smoothed[1], smoothed[0]), 5.0)
+	assert.InDelta(t, 10, last.Filter.VelocityNorth, 1)
+	assert.InDelta(t, 0, last.Filter.VelocityEast, 1)
+}
+
+func TestFilter_Smooth_Edges(t *testing.T) {
+	tests := []struct {
+		name  string
+		fixes [][2]float64
+	}{
+		{
+			name:  "heading north over the pole",
+			fixes: [][2]float64{{89.9990, 0}, {89.9992, 0}, {89.9994, 0}, {89.9996, 0}, {89.9998, 0}, {90, 0}, {90, 0}},
+		},
+		{
+			name:  "heading east across the antimeridian",
+			fixes: [][2]float64{{0, 179.9990}, {0, 179.9995}, {0, -180}, {0, -179.9995}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			last := track(testFilter(), tt.fixes, time.Second)
+
+			// Assert
+			smoothed := last.SmoothedLocation.Coordinates
+			assert.GreaterOrEqual(t, smoothed[1], -90.0)
+			assert.LessOrEqual(t, smoothed[1], 90.0)
+			assert.GreaterOrEqual(t, smoothed[0], -180.0)
+			assert.Less(t, smoothed[0], 180.0)
+			assert.Less(t, geo.Distance(last.Latitude(), last.Longitude(), smoothed[1], smoothed[0]), 100.0)
+		})
+	}
+}
+
+func TestFilter_Smooth_ResetAfterGap(t *testing.T) {
+	// Setup
+	f := testFilter()
+	prev := models.NewDriverLocation(41.0, 29.0)
+	f.Smooth(nil, prev, testStart)
+	next := models.NewDriverLocation(41.01, 29.0)
+
+	// Execute
+	f.Smooth(prev, next, testStart.Add(10*time.Minute))
+
+	// Assert
+	assert.Equal(t, []float64{29.0, 41.01}, next.SmoothedLocation.Coordinates)
+	assert.Zero(t, next.Filter.VelocityNorth)
+}
+
+func TestFilter_Smooth_ReportedAccuracy(t *testing.T) {
+	// Setup
+	f := testFilter()
+	prev := models.NewDriverLocation(41.0, 29.0)
+	f.Smooth(nil, prev, testStart)
+	precise := models.NewDriverLocation(41.0+30/geo.MetersPerDegree, 29.0)
+	accuracy := 1.0
+	precise.Accuracy = &accuracy
+	coarse := models.NewDriverLocation(41.0+30/geo.MetersPerDegree, 29.0)
+
+	// Execute
+	f.Smooth(prev, precise, testStart.Add(time.Second))
+	f.Smooth(prev, coarse, testStart.Add(time.Second))
+
+	// Assert: an accurate fix moves the estimate further towards itself.
+	preciseMove := geo.Distance(41.0, 29.0, precise.SmoothedLocation.Coordinates[1], 29.0)
+	coarseMove := geo.Distance(41.0, 29.0, coarse.SmoothedLocation.Coordinates[1], 29.0)
+	assert.Greater(t, preciseMove, 29.0)
+	assert.Less(t, coarseMove, preciseMove)
+}
@@ -0,0 +1,115 @@
+// Package spoofing detects location updates that a real vehicle could not
+// have produced, such as a driver covering ten kilometres in a minute or
+// reporting the exact same coordinates for hours.
+package spoofing
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+const (
+	// FlagImplausibleSpeed is raised when the speed between two updates
+	// exceeds what a vehicle can drive.
+	FlagImplausibleSpeed = "implausible_speed"
+	// FlagTeleport is raised when a driver moves further than a jump allows
+	// within a short time, whatever the computed speed.
+	FlagTeleport = "teleport"
+	// FlagStaticLocation is raised when a driver reports bit-identical
+	// coordinates for a long time, which real GPS noise does not produce.
+	FlagStaticLocation = "static_location"
+)
+
+// minSpeedCheckDistance is the distance below which speeds are not checked,
+// so that GPS jitter between close updates is not mistaken for driving.
+const minSpeedCheckDistance = 100.0
+
+// Config sets the thresholds of the checks.
+type Config struct {
+	// Mode is a config.SpoofingMode value. In reject mode, updates with an
+	// implausible speed or a teleport are rejected; static locations are
+	// always only flagged, since a parked driver is not an error.
+	Mode string
+	// MaxSpeed is the highest plausible speed in metres per second.
+	MaxSpeed float64
+	// MaxJumpDistance is the furthest a driver may move, in metres, between
+	// updates less than JumpWindow apart.
+	MaxJumpDistance float64
+	JumpWindow      time.Duration
+	// StaticDuration is how long identical coordinates are accepted.
+	StaticDuration time.Duration
+}
+
+// Detector checks location updates against the previous location of the driver.
+type Detector struct {
+	cfg Config
+}
+
+func NewDetector(cfg Config) *Detector {
+	return &Detector{cfg: cfg}
+}
+
+// Check returns the checks failed by next, reported at now, given the
+// previous location of the driver. It returns nil when there is no previous
+// location or detection is off.
+func (d *Detector) Check(prev, next *models.DriverLocation, now time.Time) []models.Violation {
+	if prev == nil || d.cfg.Mode == config.SpoofingModeOff || prev.UpdatedAt.IsZero() {
+		return nil
+	}
+
+	var violations []models.Violation
+	elapsed := now.Sub(prev.UpdatedAt)
+	distance := geo.Distance(prev.Latitude(), prev.Longitude(), next.Latitude(), next.Longitude())
+
+	if distance > d.cfg.MaxJumpDistance && elapsed < d.cfg.JumpWindow {
+		violations = append(violations, models.Violation{
+			Type:   FlagTeleport,
+			Detail: fmt.Sprintf("moved %.0f m in %s", distance, elapsed.Round(time.Second)),
+		})
+	}
+
+	if distance > minSpeedCheckDistance {
+		// Updates in the same second are treated as one second apart.
+		seconds := math.Max(elapsed.Seconds(), 1)
+		if speed := distance / seconds; speed > d.cfg.MaxSpeed {
+			violations = append(violations, models.Violation{
+				Type:   FlagImplausibleSpeed,
+				Detail: fmt.Sprintf("%.0f km/h between updates", speed*3.6),
+			})
+		}
+	}
+
+	if SameCoordinates(prev, next) && !prev.StaticSince.IsZero() {
+		if static := now.Sub(prev.StaticSince); static >= d.cfg.StaticDuration {
+			violations = append(violations, models.Violation{
+				Type:   FlagStaticLocation,
+				Detail: fmt.Sprintf("identical coordinates for %s", static.Round(time.Second)),
+			})
+		}
+	}
+
+	return violations
+}
+
+// Rejects reports whether an update failing violations must be rejected.
+func (d *Detector) Rejects(violations []models.Violation) bool {
+	if d.cfg.Mode != config.SpoofingModeReject {
+		return false
+	}
+	for _, v := range violations {
+		if v.Type == FlagImplausibleSpeed || v.Type == FlagTeleport {
+			return true
+		}
+	}
+	return false
+}
+
+// SameCoordinates reports whether two locations have bit-identical coordinates.
+func SameCoordinates(a, b *models.DriverLocation) bool {
+	return a.Latitude() == b.Latitude() && a.Longitude() == b.Longitude()
+}
@@ -0,0 +1,142 @@
+package spoofing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/driver-location/internal/models"
+)
+
+var testNow = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func testConfig(mode string) Config {
+	return Config{
+		Mode:            mode,
+		MaxSpeed:        200 / 3.6,
+		MaxJumpDistance: 10000,
+		JumpWindow:      5 * time.Minute,
+		StaticDuration:  time.Hour,
+	}
+}
+
+func location(lat, lon float64, updatedAt, staticSince time.Time) *models.DriverLocation {
+	l := models.NewDriverLocation(lat, lon)
+	l.UpdatedAt = updatedAt
+	l.StaticSince = staticSince
+	return l
+}
+
+func TestDetector_Check(t *testing.T) {
+	minuteAgo := testNow.Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		mode          string
+		prev          *models.DriverLocation
+		next          *models.DriverLocation
+		expectedTypes []string
+	}{
+		{
+			name: "no previous location",
+			mode: config.SpoofingModeFlag,
+			next: location(40.0, 29.0, time.Time{}, time.Time{}),
+		},
+		{
+			name: "plausible move",
+			mode: config.SpoofingModeFlag,
+			// About 1.1 km in a minute, 67 km/h.
+			prev: location(40.0, 29.0, minuteAgo, minuteAgo),
+			next: location(40.01, 29.0, time.Time{}, time.Time{}),
+		},
+		{
+			name:          "teleport",
+			mode:          config.SpoofingModeFlag,
+			prev:          location(40.0, 29.0, minuteAgo, minuteAgo),
+			next:          location(40.1, 29.0, time.Time{}, time.Time{}),
+			expectedTypes: []string{FlagTeleport, FlagImplausibleSpeed},
+		},
+		{
+			name: "long jump after the jump window",
+			mode: config.SpoofingModeFlag,
+			// About 11 km in ten minutes, 67 km/h.
+			prev: location(40.0, 29.0, testNow.Add(-10*time.Minute), minuteAgo),
+			next: location(40.1, 29.0, time.Time{}, time.Time{}),
+		},
+		{
+			name: "speeding",
+			mode: config.SpoofingModeFlag,
+			// About 5.6 km in a minute, 333 km/h.
+			prev:          location(40.0, 29.0, minuteAgo, minuteAgo),
+			next:          location(40.05, 29.0, time.Time{}, time.Time{}),
+			expectedTypes: []string{FlagImplausibleSpeed},
+		},
+		{
+			name: "jitter within a second",
+			mode: config.SpoofingModeFlag,
+			// About 11 m.
+			prev: location(40.0, 29.0, testNow, testNow),
+			next: location(40.0001, 29.0, time.Time{}, time.Time{}),
+		},
+		{
+			name:          "static location",
+			mode:          config.SpoofingModeFlag,
+			prev:          location(40.0, 29.0, minuteAgo, testNow.Add(-2*time.Hour)),
+			next:          location(40.0, 29.0, time.Time{}, time.Time{}),
+			expectedTypes: []string{FlagStaticLocation},
+		},
+		{
+			name: "recently static location",
+			mode: config.SpoofingModeFlag,
+			prev: location(40.0, 29.0, minuteAgo, testNow.Add(-30*time.Minute)),
+			next: location(40.0, 29.0, time.Time{}, time.Time{}),
+		},
+		{
+			name: "detection off",
+			mode: config.SpoofingModeOff,
+			prev: location(40.0, 29.0, minuteAgo, minuteAgo),
+			next: location(40.1, 29.0, time.Time{}, time.Time{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			detector := NewDetector(testConfig(tt.mode))
+
+			// Execute
+			violations := detector.Check(tt.prev, tt.next, testNow)
+
+			// Assert
+			var types []string
+			for _, v := range violations {
+				types = append(types, v.Type)
+				assert.NotEmpty(t, v.Detail)
+			}
+			assert.Equal(t, tt.expectedTypes, types)
+		})
+	}
+}
+
+func TestDetector_Rejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		violations []models.Violation
+		expected   bool
+	}{
+		{"reject mode - teleport", config.SpoofingModeReject, []models.Violation{{Type: FlagTeleport}}, true},
+		{"reject mode - speed", config.SpoofingModeReject, []models.Violation{{Type: FlagImplausibleSpeed}}, true},
+		{"reject mode - static only", config.SpoofingModeReject, []models.Violation{{Type: FlagStaticLocation}}, false},
+		{"reject mode - none", config.SpoofingModeReject, nil, false},
+		{"flag mode - teleport", config.SpoofingModeFlag, []models.Violation{{Type: FlagTeleport}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewDetector(testConfig(tt.mode)).Rejects(tt.violations))
+		})
+	}
+}
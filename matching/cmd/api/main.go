@@ -11,16 +11,32 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
 	files "github.com/swaggo/files"
 	"github.com/swaggo/gin-swagger"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 
 	"github.com/BarkinBalci/bitaksi-case-study/matching/docs"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/client"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/eta"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/handler"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/metrics"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/pricing"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/routing"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/scoring"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/idempotency"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/requestid"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/tracing"
 )
 
 // @securityDefinitions.apiKey BearerAuth
@@ -42,20 +58,178 @@ func main() {
 		_ = logger.Sync()
 	}()
 
+	// Initialize tracing
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:  config.ServiceName,
+		Environment:  cfg.Environment,
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		SampleRatio:  cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		logger.Fatal("failed to initialize tracing", zap.Error(err))
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Error("failed to shut down tracing", zap.Error(err))
+		}
+	}()
+
+	// Initialize MongoDB connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mongoClient, err := mongo.Connect(options.Client().ApplyURI(cfg.MongoURI).SetMonitor(tracing.NewMongoMonitor()))
+	if err != nil {
+		logger.Fatal("failed to connect to MongoDB", zap.Error(err))
+	}
+	defer func() {
+		if err = mongoClient.Disconnect(context.TODO()); err != nil {
+			logger.Error("failed to disconnect from MongoDB", zap.Error(err))
+		}
+	}()
+	database := mongoClient.Database(cfg.MongoDBName)
+
 	// Initialize Driver Location client
-	driverLocationClient := client.NewDriverLocationClient(cfg.DriverLocationBaseURL, cfg.DriverLocationApiKey)
+	switch cfg.DriverLocationPosition {
+	case config.DriverPositionRaw, config.DriverPositionSmoothed, config.DriverPositionSnapped:
+	default:
+		logger.Fatal("unsupported driver position", zap.String("position", cfg.DriverLocationPosition))
+	}
+	driverLocationClient := client.NewDriverLocationClient(cfg.DriverLocationBaseURL, cfg.DriverLocationApiKey, client.Options{
+		Timeout:            cfg.DriverLocationTimeout,
+		MaxRetries:         cfg.DriverLocationMaxRetries,
+		RetryBaseDelay:     cfg.DriverLocationRetryBaseDelay,
+		RetryMaxDelay:      cfg.DriverLocationRetryMaxDelay,
+		BreakerThreshold:   cfg.DriverLocationBreakerThreshold,
+		BreakerOpenTimeout: cfg.DriverLocationBreakerOpenTimeout,
+		HedgeDelay:         cfg.DriverLocationHedgeDelay,
+		Position:           cfg.DriverLocationPosition,
+	})
+
+	// Initialize scoring policies
+	scorers, err := scoring.LoadSelector(cfg.ScoringConfigFile, float64(cfg.SearchRadius))
+	if err != nil {
+		logger.Fatal("failed to load scoring policies", zap.Error(err))
+	}
+
+	// Initialize ETA provider
+	var etaProvider eta.Provider
+	switch cfg.ETAProvider {
+	case config.ETAProviderNone:
+	case config.ETAProviderOSRM:
+		etaProvider = eta.NewOSRMProvider(cfg.OSRMBaseURL, cfg.OSRMProfile, cfg.ETATimeout)
+	case config.ETAProviderGraph:
+		graph, err := routing.LoadGraph(cfg.RoadGraphFile)
+		if err != nil {
+			logger.Fatal("failed to load road graph", zap.Error(err))
+		}
+		logger.Info("loaded road graph", zap.Int("nodes", graph.Nodes()))
+		etaProvider = routing.NewRouter(graph, float64(cfg.RoadSnapDistance))
+	default:
+		logger.Fatal("unsupported ETA provider", zap.String("provider", cfg.ETAProvider))
+	}
+
+	// Initialize surge pricing
+	surgeHistory, err := pricing.NewMongoSurgeHistory(ctx, database.Collection(cfg.SurgeHistoryCollection), cfg.SurgeHistoryRetention)
+	if err != nil {
+		logger.Fatal("failed to initialize surge history", zap.Error(err))
+	}
+	surgeEngine := pricing.NewSurgeEngine(pricing.SurgeConfig{
+		CellSize:        cfg.SurgeCellSize,
+		DemandWindow:    cfg.SurgeDemandWindow,
+		RefreshInterval: cfg.SurgeRefreshInterval,
+		Sensitivity:     cfg.SurgeSensitivity,
+		Smoothing:       cfg.SurgeSmoothing,
+		MaxMultiplier:   cfg.SurgeMaxMultiplier,
+		HistorySize:     cfg.SurgeHistorySize,
+	}, driverLocationClient, surgeHistory, logger)
+
+	// Initialize tariffs
+	tariffs, err := pricing.LoadTariffs(cfg.TariffConfigFile)
+	if err != nil {
+		logger.Fatal("failed to load tariffs", zap.Error(err))
+	}
+
+	// Initialize token verification
+	verifier, err := auth.NewVerifier(*cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to initialize token verification", zap.Error(err))
+	}
+
+	// Initialize token issuance
+	var authHandler *handler.AuthHandler
+	if cfg.AuthUsersFile != "" {
+		users, err := auth.NewFileUserStore(cfg.AuthUsersFile)
+		if err != nil {
+			logger.Fatal("failed to load users", zap.Error(err))
+		}
+		refreshTokens, err := auth.NewMongoRefreshTokenStore(ctx, database.Collection(cfg.RefreshTokenCollection))
+		if err != nil {
+			logger.Fatal("failed to initialize refresh token store", zap.Error(err))
+		}
+		tokenService, err := auth.NewTokenService(users, refreshTokens, *cfg, logger)
+		if err != nil {
+			logger.Fatal("failed to initialize token issuance", zap.Error(err))
+		}
+		authHandler = handler.NewAuthHandler(tokenService, logger)
+	}
+
+	// Initialize rate limiting
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case config.RateLimitStoreMemory:
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case config.RateLimitStoreRedis:
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logger.Fatal("invalid Redis URL", zap.Error(err))
+		}
+		redisClient := redis.NewClient(redisOpts)
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error("failed to close Redis client", zap.Error(err))
+			}
+		}()
+		rateLimitStore = ratelimit.NewRedisStore(redisClient, config.ServiceName+":ratelimit:")
+	default:
+		logger.Fatal("unsupported rate limit store", zap.String("store", cfg.RateLimitStore))
+	}
+	rateLimits, err := ratelimit.ParseLimits(cfg.RateLimitDefault, cfg.RateLimitRoutes)
+	if err != nil {
+		logger.Fatal("invalid rate limits", zap.Error(err))
+	}
+
+	// Initialize the idempotency store
+	idempotencyStore, err := idempotency.NewMongoStore(ctx, database.Collection(cfg.IdempotencyCollection), cfg.IdempotencyTTL, metrics.ObserveMongoOperation)
+	if err != nil {
+		logger.Fatal("failed to initialize idempotency store", zap.Error(err))
+	}
 
 	// Initialize service
-	srv := service.NewService(driverLocationClient, cfg, logger)
+	srv := service.NewService(driverLocationClient, scorers, etaProvider, surgeEngine, tariffs, verifier, database, cfg, logger)
+	defer srv.Close()
 
 	// Create handlers
 	matchHandler := handler.NewMatchHandler(srv, logger)
+	pricingHandler := handler.NewPricingHandler(srv, logger)
 	healthHandler := handler.NewHealthHandler(srv)
 
 	// Create a gin router and attach middlewares
 	router := gin.New()
+	// Only the configured proxies may set the client IP through
+	// X-Forwarded-For; otherwise callers could pick their own rate limit bucket.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("invalid trusted proxies", zap.Error(err))
+	}
+	router.Use(otelgin.Middleware(config.ServiceName))
+	router.Use(requestid.Middleware(logger))
 	router.Use(middleware.LoggerMiddleware(logger))
-	router.Use(gin.Recovery())
+	router.Use(middleware.MetricsMiddleware())
+	router.Use(gin.CustomRecovery(apierror.Recover))
+	router.NoRoute(apierror.NotFound)
 
 	if cfg.SwaggerEnabled {
 		// Serve Swagger documentation
@@ -66,11 +240,25 @@ func main() {
 	// Register public routes
 	root := router.Group("/")
 	healthHandler.RegisterRoutes(root)
+	root.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	if authHandler != nil {
+		public := router.Group("/api/v1")
+		if cfg.RateLimitEnabled {
+			public.Use(middleware.RateLimitMiddleware(rateLimitStore, rateLimits, logger))
+		}
+		authHandler.RegisterRoutes(public)
+	}
 
 	// Register private routes
 	v1 := router.Group("/api/v1")
-	v1.Use(middleware.JWTAuthMiddleware(*cfg))
+	v1.Use(middleware.JWTAuthMiddleware(verifier, logger))
+	if cfg.RateLimitEnabled {
+		v1.Use(middleware.RateLimitMiddleware(rateLimitStore, rateLimits, logger))
+	}
+	v1.Use(middleware.IdempotencyMiddleware(idempotencyStore, config.IdempotentRoutes, int64(cfg.IdempotencyMaxBodyBytes), logger))
 	matchHandler.RegisterRoutes(v1)
+	pricingHandler.RegisterRoutes(v1)
 
 	// Create http server
 	httpServer := &http.Server{
@@ -94,7 +282,7 @@ func main() {
 	logger.Info("shutting down server...")
 
 	// Inform the server it has 5 seconds to finish the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	if err := httpServer.Shutdown(ctx); err != nil {
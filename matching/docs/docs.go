@@ -15,6 +15,237 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/auth/refresh": {
+            "post": {
+                "description": "Exchanges a refresh token for a new access token and refresh token. Each refresh token can be used once; reusing one revokes every token issued from the same sign-in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh a token",
+                "parameters": [
+                    {
+                        "description": "Refresh token request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/revoke": {
+            "post": {
+                "description": "Revokes the refresh token and every token issued from the same sign-in. Unknown tokens are ignored. Access tokens stay valid until they expire",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Revoke a refresh token",
+                "parameters": [
+                    {
+                        "description": "Revoke request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/token": {
+            "post": {
+                "description": "Signs a rider in with their username and password, or with a device ID and secret, and returns an access token and a refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Issue a token",
+                "parameters": [
+                    {
+                        "description": "Token request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/estimate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Estimates the fare of a trip between a pickup and a drop-off point, including the current surge",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pricing"
+                ],
+                "summary": "Estimate a fare",
+                "parameters": [
+                    {
+                        "description": "Estimate request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.EstimateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.EstimateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/match": {
             "post": {
                 "security": [
@@ -22,7 +253,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Finds the nearest available driver for the given rider location",
+                "description": "Finds the nearest available driver for the given rider location. The rider is the authenticated user; dispatchers and admins may match on behalf of another rider with rider_id. When pickup points are enabled, the rider is moved to the nearest pickup point, which is returned as pickup_point",
                 "consumes": [
                     "application/json"
                 ],
@@ -30,25 +261,214 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "match"
+                    "match"
+                ],
+                "summary": "Find nearest driver",
+                "parameters": [
+                    {
+                        "description": "Match request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MatchRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Replays the first response to retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pricing/surge": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the current surge multiplier for the pricing cell containing the given point",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pricing"
+                ],
+                "summary": "Get surge multiplier",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SurgeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/pricing/surge/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns the surge multipliers computed for a pricing cell, optionally within a time range",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "pricing"
                 ],
-                "summary": "Find nearest driver",
+                "summary": "Get surge history",
                 "parameters": [
                     {
-                        "description": "Match request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.MatchRequest"
-                        }
+                        "type": "string",
+                        "description": "Pricing cell ID",
+                        "name": "cell",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.MatchResponse"
+                            "$ref": "#/definitions/dto.SurgeHistoryResponse"
                         }
                     },
                     "400": {
@@ -63,8 +483,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ErrorResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
                         "schema": {
                             "$ref": "#/definitions/dto.ErrorResponse"
                         }
@@ -78,53 +504,265 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/health/live": {
+            "get": {
+                "description": "Reports that the process is up, without checking its dependencies",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Check if the service is alive",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthCheckResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health/ready": {
             "get": {
-                "description": "Check if the service is healthy",
+                "description": "Checks driver-location, MongoDB and the JWT configuration, and reports the status and latency of each along with the circuit breaker state",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "health"
                 ],
-                "summary": "Check if the service is healthy",
+                "summary": "Check if the service is ready",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/dto.HealthCheckResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/dto.HealthCheckResponse"
+                        }
                     }
                 }
             }
         }
     },
     "definitions": {
+        "apierror.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "example": "location.coordinates"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "must have exactly 2 elements"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "len"
+                }
+            }
+        },
+        "dto.CircuitBreaker": {
+            "type": "object",
+            "properties": {
+                "consecutive_failures": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "opened_at": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string",
+                    "example": "closed"
+                }
+            }
+        },
+        "dto.DependencyStatus": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "latency_ms": {
+                    "type": "number",
+                    "example": 3.21
+                },
+                "name": {
+                    "type": "string",
+                    "example": "driver_location"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "dto.DriverMatch": {
             "type": "object",
             "properties": {
                 "distance": {
                     "type": "number"
                 },
+                "eta_seconds": {
+                    "type": "number",
+                    "example": 312.4
+                },
                 "id": {
                     "type": "string"
                 },
                 "location": {
                     "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "pickup_point": {
+                    "description": "PickupPoint is the curated pickup point the rider was moved to, if any.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/dto.PickupPoint"
+                        }
+                    ]
+                },
+                "policy": {
+                    "type": "string",
+                    "example": "distance"
+                },
+                "rider_id": {
+                    "type": "string",
+                    "example": "rider-1"
                 }
             }
         },
         "dto.ErrorResponse": {
             "type": "object",
             "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "validation_failed"
+                },
+                "details": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apierror.FieldError"
+                    }
+                },
                 "error": {
-                    "type": "string"
+                    "type": "string",
+                    "example": "The request contains invalid fields."
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"
                 },
                 "success": {
                     "type": "boolean"
                 }
             }
         },
+        "dto.EstimateRequest": {
+            "type": "object",
+            "required": [
+                "dropoff",
+                "pickup"
+            ],
+            "properties": {
+                "dropoff": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "pickup": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                }
+            }
+        },
+        "dto.EstimateResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.FareEstimate"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.FareBreakdown": {
+            "type": "object",
+            "properties": {
+                "base_fare": {
+                    "type": "number",
+                    "example": 50
+                },
+                "distance_fare": {
+                    "type": "number",
+                    "example": 84.2
+                },
+                "minimum_applied": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "minimum_fare": {
+                    "type": "number",
+                    "example": 100
+                },
+                "subtotal": {
+                    "type": "number",
+                    "example": 161.8
+                },
+                "surge_multiplier": {
+                    "type": "number",
+                    "example": 1.2
+                },
+                "time_fare": {
+                    "type": "number",
+                    "example": 27.6
+                }
+            }
+        },
+        "dto.FareEstimate": {
+            "type": "object",
+            "properties": {
+                "breakdown": {
+                    "$ref": "#/definitions/dto.FareBreakdown"
+                },
+                "currency": {
+                    "type": "string",
+                    "example": "TRY"
+                },
+                "distance_meters": {
+                    "type": "number",
+                    "example": 4210
+                },
+                "duration_seconds": {
+                    "type": "number",
+                    "example": 552
+                },
+                "duration_source": {
+                    "type": "string",
+                    "enum": [
+                        "routing",
+                        "straight_line"
+                    ],
+                    "example": "straight_line"
+                },
+                "total": {
+                    "type": "number",
+                    "example": 194.16
+                },
+                "zone": {
+                    "type": "string",
+                    "example": "airport"
+                }
+            }
+        },
         "dto.GeoJSONPoint": {
             "type": "object",
             "required": [
@@ -151,6 +789,15 @@ const docTemplate = `{
         "dto.HealthCheckResponse": {
             "type": "object",
             "properties": {
+                "circuit_breaker": {
+                    "$ref": "#/definitions/dto.CircuitBreaker"
+                },
+                "dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DependencyStatus"
+                    }
+                },
                 "status": {
                     "type": "string",
                     "example": "ok"
@@ -165,6 +812,18 @@ const docTemplate = `{
             "properties": {
                 "location": {
                     "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "policy": {
+                    "type": "string",
+                    "example": "weighted_sum"
+                },
+                "requirements": {
+                    "$ref": "#/definitions/dto.VehicleRequirements"
+                },
+                "rider_id": {
+                    "description": "RiderID defaults to the authenticated user. Only dispatchers and admins\nmay set it to another rider.",
+                    "type": "string",
+                    "example": "rider-1"
                 }
             }
         },
@@ -178,6 +837,188 @@ const docTemplate = `{
                     "type": "boolean"
                 }
             }
+        },
+        "dto.PickupPoint": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "type": "number",
+                    "example": 42.5
+                },
+                "id": {
+                    "type": "string",
+                    "example": "5b0e8f3c-2a41-4c55-9d0e-0b8f1e2a7c11"
+                },
+                "location": {
+                    "$ref": "#/definitions/dto.GeoJSONPoint"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Terminal 1 Gate 4"
+                },
+                "zone": {
+                    "type": "string",
+                    "example": "airport"
+                }
+            }
+        },
+        "dto.RefreshTokenRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string",
+                    "example": "q8G7xPp0n3Y1v0m2b1c4d5e6f7g8h9i0j1k2l3m4n5o"
+                }
+            }
+        },
+        "dto.Surge": {
+            "type": "object",
+            "properties": {
+                "cell_id": {
+                    "type": "string",
+                    "example": "4100:2897"
+                },
+                "computed_at": {
+                    "type": "string"
+                },
+                "demand": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "multiplier": {
+                    "type": "number",
+                    "example": 1.4
+                },
+                "supply": {
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "dto.SurgeHistoryResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.Surge"
+                    }
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.SurgeResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.Surge"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.TokenData": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "expires_in": {
+                    "type": "integer",
+                    "example": 900
+                },
+                "refresh_expires_in": {
+                    "type": "integer",
+                    "example": 2592000
+                },
+                "refresh_token": {
+                    "type": "string",
+                    "example": "q8G7xPp0n3Y1v0m2b1c4d5e6f7g8h9i0j1k2l3m4n5o"
+                },
+                "token_type": {
+                    "type": "string",
+                    "example": "Bearer"
+                }
+            }
+        },
+        "dto.TokenRequest": {
+            "type": "object",
+            "required": [
+                "grant_type"
+            ],
+            "properties": {
+                "device_id": {
+                    "type": "string",
+                    "example": "ios-3f2b8c1e"
+                },
+                "device_secret": {
+                    "type": "string",
+                    "example": "device-secret"
+                },
+                "grant_type": {
+                    "type": "string",
+                    "enum": [
+                        "password",
+                        "device"
+                    ],
+                    "example": "password"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "correct-horse-battery-staple"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "rider@example.com"
+                }
+            }
+        },
+        "dto.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.TokenData"
+                },
+                "success": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.VehicleRequirements": {
+            "type": "object",
+            "properties": {
+                "min_capacity": {
+                    "type": "integer",
+                    "maximum": 8,
+                    "minimum": 1,
+                    "example": 4
+                },
+                "pet_friendly": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "vehicle_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "taxi",
+                        "xl"
+                    ]
+                },
+                "wheelchair_accessible": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
         }
     },
     "securityDefinitions": {
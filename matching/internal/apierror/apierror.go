@@ -0,0 +1,45 @@
+// Package apierror is the catalogue of errors returned by the matching API.
+// The Error type, the response format and the errors shared by both
+// services come from the shared apierror package and are re-exported here,
+// so handlers only need this package.
+package apierror
+
+import (
+	"net/http"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+// Error is an entry of the catalogue.
+type Error = apierror.Error
+
+var (
+	Respond        = apierror.Respond
+	RespondBinding = apierror.RespondBinding
+	NotFound       = apierror.NotFound
+	Recover        = apierror.Recover
+)
+
+var (
+	ErrMalformedRequest         = apierror.ErrMalformedRequest
+	ErrValidationFailed         = apierror.ErrValidationFailed
+	ErrNotFound                 = apierror.ErrNotFound
+	ErrRateLimited              = apierror.ErrRateLimited
+	ErrInvalidIdempotencyKey    = apierror.ErrInvalidIdempotencyKey
+	ErrIdempotencyKeyReused     = apierror.ErrIdempotencyKeyReused
+	ErrIdempotencyKeyInProgress = apierror.ErrIdempotencyKeyInProgress
+	ErrInternal                 = apierror.ErrInternal
+)
+
+var (
+	ErrUnknownPolicy       = Error{Code: "unknown_policy", Status: http.StatusBadRequest, Message: config.ErrUnknownPolicy}
+	ErrUnauthorized        = Error{Code: "unauthorized", Status: http.StatusUnauthorized, Message: config.ErrUnauthorized}
+	ErrTokenExpired        = Error{Code: "token_expired", Status: http.StatusUnauthorized, Message: config.ErrTokenExpired}
+	ErrInvalidCredentials  = Error{Code: "invalid_credentials", Status: http.StatusUnauthorized, Message: config.ErrInvalidCredentials}
+	ErrInvalidRefreshToken = Error{Code: "invalid_refresh_token", Status: http.StatusUnauthorized, Message: config.ErrInvalidRefreshToken}
+	ErrForbidden           = Error{Code: "forbidden", Status: http.StatusForbidden, Message: config.ErrForbidden}
+	ErrNoDriverFound       = Error{Code: "no_driver_found", Status: http.StatusNotFound, Message: config.ErrNoDriverFound}
+	ErrUnavailable         = Error{Code: "upstream_unavailable", Status: http.StatusServiceUnavailable, Message: config.ErrUnavailable}
+)
@@ -0,0 +1,148 @@
+package assignment
+
+import "math"
+
+// Solve computes a minimum cost assignment of rows to columns using the
+// Hungarian algorithm. cost[i][j] is the cost of assigning row i to column j;
+// use math.Inf(1) for pairs that must not be assigned. The returned slice holds
+// the assigned column for each row, or -1 when a row could not be assigned.
+func Solve(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+	if m == 0 {
+		return unassigned(n)
+	}
+
+	if n > m {
+		// The algorithm requires at least as many columns as rows, so solve the
+		// transposed problem and invert the result.
+		byColumn := Solve(transpose(cost))
+		result := unassigned(n)
+		for j, i := range byColumn {
+			if i >= 0 {
+				result[i] = j
+			}
+		}
+		return result
+	}
+
+	c, infeasible := finite(cost)
+
+	// Potentials and matching use 1-based indices; index 0 is a sentinel column.
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	p := make([]int, m+1)
+	way := make([]int, m+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := 0
+
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				cur := c[i0-1][j-1] - u[i0] - v[j]
+				if cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	result := unassigned(n)
+	for j := 1; j <= m; j++ {
+		if i := p[j]; i != 0 && !infeasible[i-1][j-1] {
+			result[i-1] = j - 1
+		}
+	}
+	return result
+}
+
+// finite replaces infinite costs with a penalty larger than any feasible
+// assignment so the algorithm only uses them when no alternative exists.
+func finite(cost [][]float64) ([][]float64, [][]bool) {
+	maxAbs := 0.0
+	for _, row := range cost {
+		for _, v := range row {
+			if !math.IsInf(v, 0) && !math.IsNaN(v) {
+				maxAbs = math.Max(maxAbs, math.Abs(v))
+			}
+		}
+	}
+	penalty := (maxAbs + 1) * float64(len(cost)+1) * 2
+
+	c := make([][]float64, len(cost))
+	infeasible := make([][]bool, len(cost))
+	for i, row := range cost {
+		c[i] = make([]float64, len(row))
+		infeasible[i] = make([]bool, len(row))
+		for j, v := range row {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				c[i][j] = penalty
+				infeasible[i][j] = true
+				continue
+			}
+			c[i][j] = v
+		}
+	}
+	return c, infeasible
+}
+
+func transpose(cost [][]float64) [][]float64 {
+	t := make([][]float64, len(cost[0]))
+	for j := range t {
+		t[j] = make([]float64, len(cost))
+		for i := range cost {
+			t[j][i] = cost[i][j]
+		}
+	}
+	return t
+}
+
+func unassigned(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = -1
+	}
+	return result
+}
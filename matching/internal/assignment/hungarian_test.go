@@ -0,0 +1,80 @@
+package assignment
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSolve(t *testing.T) {
+	inf := math.Inf(1)
+
+	tests := []struct {
+		name     string
+		cost     [][]float64
+		expected []int
+	}{
+		{
+			name:     "empty",
+			cost:     nil,
+			expected: nil,
+		},
+		{
+			name: "greedy is not optimal",
+			cost: [][]float64{
+				{1, 2},
+				{2, 100},
+			},
+			expected: []int{1, 0},
+		},
+		{
+			name: "square matrix",
+			cost: [][]float64{
+				{4, 1, 3},
+				{2, 0, 5},
+				{3, 2, 2},
+			},
+			expected: []int{1, 0, 2},
+		},
+		{
+			name: "more drivers than riders",
+			cost: [][]float64{
+				{9, 3, 7, 1},
+				{2, 8, 1, 6},
+			},
+			expected: []int{3, 2},
+		},
+		{
+			name: "more riders than drivers",
+			cost: [][]float64{
+				{5, 9},
+				{1, 2},
+				{7, 3},
+			},
+			expected: []int{-1, 0, 1},
+		},
+		{
+			name: "infeasible pairs stay unassigned",
+			cost: [][]float64{
+				{1, inf},
+				{2, inf},
+			},
+			expected: []int{0, -1},
+		},
+		{
+			name: "negative costs",
+			cost: [][]float64{
+				{-0.5, -0.1},
+				{-0.4, 0.2},
+			},
+			expected: []int{1, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Solve(tt.cost))
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+// JWKS caches the keys published at a JWKS URL. The set is refreshed when it
+// is older than the refresh interval and when a token names a key ID that is
+// not in the set, at most once per JWKSMinRefreshInterval. If a refresh
+// fails, the previously fetched keys keep being used.
+//
+// The set is replaced as a whole rather than modified, so readers never wait
+// for a fetch: an expired set is refreshed in the background while it is
+// still served, and concurrent refreshes share a single fetch.
+type JWKS struct {
+	url             string
+	refreshInterval time.Duration
+	httpClient      *http.Client
+	logger          *zap.Logger
+	now             func() time.Time
+
+	set     atomic.Pointer[keySet]
+	refresh singleflight.Group
+
+	mu          sync.Mutex
+	attemptedAt time.Time
+	lastErr     error
+}
+
+// keySet is a fetched key set. It is never modified once stored.
+type keySet struct {
+	keys      []publicKey
+	fetchedAt time.Time
+}
+
+func NewJWKS(url string, refreshInterval time.Duration, logger *zap.Logger) *JWKS {
+	return &JWKS{
+		url:             url,
+		refreshInterval: refreshInterval,
+		httpClient: &http.Client{
+			Timeout:   config.JWKSFetchTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Keys returns the keys that can verify a token signed with alg. When kid is
+// not empty only the key with that ID is returned.
+func (j *JWKS) Keys(ctx context.Context, kid, alg string) ([]publicKey, error) {
+	set := j.set.Load()
+	if set == nil {
+		j.refreshKeys(ctx)
+		if set = j.set.Load(); set == nil {
+			return nil, j.err()
+		}
+	} else if j.now().Sub(set.fetchedAt) >= j.refreshInterval {
+		j.refresh.DoChan(jwksRefreshKey, func() (any, error) {
+			return j.refreshOnce(ctx), nil
+		})
+	}
+
+	keys := set.match(kid, alg)
+	if len(keys) == 0 && kid != "" && j.refreshKeys(ctx) {
+		keys = j.set.Load().match(kid, alg)
+	}
+	return keys, nil
+}
+
+// Check reports whether a key set has been fetched, fetching it if needed.
+func (j *JWKS) Check(ctx context.Context) error {
+	if j.set.Load() == nil {
+		j.refreshKeys(ctx)
+	}
+	if j.set.Load() == nil {
+		return j.err()
+	}
+	return nil
+}
+
+func (j *JWKS) err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.lastErr
+}
+
+func (s *keySet) match(kid, alg string) []publicKey {
+	var keys []publicKey
+	for _, k := range s.keys {
+		if (kid == "" || k.kid == kid) && k.supports(alg) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// jwksRefreshKey is the singleflight key every refresh of a JWKS shares.
+const jwksRefreshKey = "refresh"
+
+// refreshKeys waits for a refresh of the key set, joining the one in flight
+// if any, and reports whether the keys were replaced.
+func (j *JWKS) refreshKeys(ctx context.Context) bool {
+	replaced, _, _ := j.refresh.Do(jwksRefreshKey, func() (any, error) {
+		return j.refreshOnce(ctx), nil
+	})
+	return replaced.(bool)
+}
+
+// refreshOnce fetches the key set unless the last attempt was too recent,
+// and reports whether the keys were replaced. It must only run inside
+// j.refresh. The fetch is not cancelled with ctx, since other callers may be
+// waiting for it.
+func (j *JWKS) refreshOnce(ctx context.Context) bool {
+	now := j.now()
+	j.mu.Lock()
+	if !j.attemptedAt.IsZero() && now.Sub(j.attemptedAt) < config.JWKSMinRefreshInterval {
+		j.mu.Unlock()
+		return false
+	}
+	j.attemptedAt = now
+	j.mu.Unlock()
+
+	keys, err := j.fetch(context.WithoutCancel(ctx))
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.lastErr = fmt.Errorf("fetch JWKS: %w", err)
+		j.logger.Warn("Failed to refresh JWKS", zap.String("url", j.url), zap.Error(err))
+		return false
+	}
+
+	j.set.Store(&keySet{keys: keys, fetchedAt: now})
+	j.lastErr = nil
+	return true
+}
+
+func (j *JWKS) fetch(ctx context.Context) ([]publicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, config.JWKSMaxResponseBytes)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode key set: %w", err)
+	}
+
+	keys := make([]publicKey, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if errors.Is(err, errUnsupportedJWK) {
+			continue
+		}
+		if err != nil {
+			j.logger.Warn("Skipping invalid JWK", zap.String("url", j.url), zap.Error(err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("key set contains no RS256 or ES256 signing keys")
+	}
+	return keys, nil
+}
@@ -0,0 +1,209 @@
+// Package auth verifies the bearer tokens presented to the service.
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// publicKey is a verification key with the optional key ID and algorithm it
+// was published with.
+type publicKey struct {
+	kid string
+	alg string
+	key crypto.PublicKey
+}
+
+// supports reports whether the key can verify a token signed with alg.
+func (k publicKey) supports(alg string) bool {
+	if k.alg != "" && k.alg != alg {
+		return false
+	}
+
+	switch key := k.key.(type) {
+	case *rsa.PublicKey:
+		return alg == jwt.SigningMethodRS256.Alg()
+	case *ecdsa.PublicKey:
+		return alg == jwt.SigningMethodES256.Alg() && key.Curve == elliptic.P256()
+	default:
+		return false
+	}
+}
+
+// loadPEMKeys reads the RSA and P-256 ECDSA public keys in a PEM file. Public
+// keys in PKIX or PKCS #1 form and certificates are accepted.
+func loadPEMKeys(path string) ([]publicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read public key file: %w", err)
+	}
+
+	var keys []publicKey
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		var key crypto.PublicKey
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			return nil, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s block in %s: %w", block.Type, path, err)
+		}
+
+		k := publicKey{key: key}
+		if !k.supports(jwt.SigningMethodRS256.Alg()) && !k.supports(jwt.SigningMethodES256.Alg()) {
+			return nil, fmt.Errorf("unsupported public key type %T in %s", key, path)
+		}
+		keys = append(keys, k)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no public keys found in %s", path)
+	}
+	return keys, nil
+}
+
+// jwk is a JSON Web Key as defined in RFC 7517, limited to the RSA and EC
+// members.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+var errUnsupportedJWK = errors.New("unsupported key")
+
+// publicKey converts the JWK into a verification key. Keys that are not
+// signing keys, or are of a type or curve that cannot verify RS256 or ES256
+// tokens, return errUnsupportedJWK.
+func (k jwk) publicKey() (publicKey, error) {
+	if k.Use != "" && k.Use != "sig" {
+		return publicKey{}, errUnsupportedJWK
+	}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return publicKey{}, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return publicKey{}, fmt.Errorf("key %q: invalid exponent", k.Kid)
+		}
+		return publicKey{kid: k.Kid, alg: k.Alg, key: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return publicKey{}, errUnsupportedJWK
+		}
+		x, errX := base64.RawURLEncoding.DecodeString(k.X)
+		y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return publicKey{}, fmt.Errorf("key %q: invalid coordinates", k.Kid)
+		}
+		key, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return publicKey{}, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+		return publicKey{kid: k.Kid, alg: k.Alg, key: key}, nil
+	default:
+		return publicKey{}, errUnsupportedJWK
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty value")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// signingKey is the private key access tokens are signed with.
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+// loadSigningKey reads an RSA or P-256 ECDSA private key from a PEM file in
+// PKCS #8, PKCS #1 or SEC 1 form. RSA keys sign RS256 tokens and ECDSA keys
+// ES256 tokens.
+func loadSigningKey(path, kid string) (signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return signingKey{}, fmt.Errorf("read signing key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return signingKey{}, fmt.Errorf("no PEM block found in %s", path)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return signingKey{}, fmt.Errorf("unsupported PEM block %q in %s", block.Type, path)
+	}
+	if err != nil {
+		return signingKey{}, fmt.Errorf("parse %s block in %s: %w", block.Type, path, err)
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return signingKey{kid: kid, method: jwt.SigningMethodRS256, key: k}, nil
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return signingKey{}, fmt.Errorf("unsupported curve %s in %s", k.Curve.Params().Name, path)
+		}
+		return signingKey{kid: kid, method: jwt.SigningMethodES256, key: k}, nil
+	default:
+		return signingKey{}, fmt.Errorf("unsupported private key type %T in %s", key, path)
+	}
+}
+
+// publicKey returns the key that verifies tokens signed with k.
+func (k signingKey) publicKey() publicKey {
+	return publicKey{kid: k.kid, alg: k.method.Alg(), key: k.key.Public()}
+}
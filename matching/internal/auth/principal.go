@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+var ErrMissingUserID = errors.New("token has no user_id claim")
+
+// rolePermissions lists the permissions granted by each role.
+var rolePermissions = map[string][]string{
+	config.RoleRider: {
+		config.PermissionMatchCreate,
+		config.PermissionFareEstimate,
+		config.PermissionPricingRead,
+	},
+	config.RoleDriver: {
+		config.PermissionPricingRead,
+	},
+	config.RoleDispatcher: {
+		config.PermissionMatchCreate,
+		config.PermissionMatchOnBehalf,
+		config.PermissionFareEstimate,
+		config.PermissionPricingRead,
+		config.PermissionPricingHistory,
+	},
+	config.RoleAdmin: {
+		config.PermissionMatchCreate,
+		config.PermissionMatchOnBehalf,
+		config.PermissionFareEstimate,
+		config.PermissionPricingRead,
+		config.PermissionPricingHistory,
+	},
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID      string
+	Roles       []string
+	Permissions []string
+}
+
+// NewPrincipal builds the principal of a verified token. Its permissions are
+// those of its roles and those listed in its permissions claim. Tokens without
+// a roles claim are given the rider role. Unknown roles grant nothing.
+func NewPrincipal(claims jwt.MapClaims) (*Principal, error) {
+	userID, _ := claims["user_id"].(string)
+	if userID == "" {
+		return nil, ErrMissingUserID
+	}
+
+	p := &Principal{
+		UserID: userID,
+		Roles:  stringsClaim(claims, "roles"),
+	}
+	if _, exists := claims["roles"]; !exists {
+		p.Roles = []string{config.RoleRider}
+	}
+
+	for _, role := range p.Roles {
+		p.grant(rolePermissions[role]...)
+	}
+	p.grant(stringsClaim(claims, "permissions")...)
+
+	return p, nil
+}
+
+// Can reports whether the principal holds permission.
+func (p *Principal) Can(permission string) bool {
+	return slices.Contains(p.Permissions, permission)
+}
+
+func (p *Principal) grant(permissions ...string) {
+	for _, permission := range permissions {
+		if !p.Can(permission) {
+			p.Permissions = append(p.Permissions, permission)
+		}
+	}
+}
+
+// stringsClaim returns a claim holding a string or an array of strings.
+// Other values are ignored.
+func stringsClaim(claims jwt.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
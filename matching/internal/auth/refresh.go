@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/metrics"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenUsed     = errors.New("refresh token already used")
+)
+
+// RefreshToken is a stored refresh token. Only the hash of the token is
+// kept. Every token issued by rotating another one belongs to the family of
+// the token first issued at sign-in, so that a whole chain can be revoked.
+type RefreshToken struct {
+	Hash      string     `bson:"_id"`
+	FamilyID  string     `bson:"family_id"`
+	UserID    string     `bson:"user_id"`
+	ExpiresAt time.Time  `bson:"expires_at"`
+	UsedAt    *time.Time `bson:"used_at,omitempty"`
+	RevokedAt *time.Time `bson:"revoked_at,omitempty"`
+}
+
+// RefreshTokenStore persists refresh tokens.
+type RefreshTokenStore interface {
+	Create(ctx context.Context, token *RefreshToken) error
+	Find(ctx context.Context, hash string) (*RefreshToken, error)
+	// Use marks the token as used and returns it. It returns
+	// ErrRefreshTokenUsed, along with the token, if it was already used.
+	Use(ctx context.Context, hash string, at time.Time) (*RefreshToken, error)
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+}
+
+// mongoRefreshTokenStore keeps refresh tokens in a collection, so they
+// survive restarts and are shared by every instance. A TTL index removes
+// tokens once they expire.
+type mongoRefreshTokenStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoRefreshTokenStore creates a store keeping refresh tokens in
+// collection, creating its indexes if needed.
+func NewMongoRefreshTokenStore(ctx context.Context, collection *mongo.Collection) (RefreshTokenStore, error) {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "family_id", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create refresh token indexes: %w", err)
+	}
+
+	return &mongoRefreshTokenStore{collection: collection}, nil
+}
+
+func (s *mongoRefreshTokenStore) Create(ctx context.Context, token *RefreshToken) error {
+	start := time.Now()
+	_, err := s.collection.InsertOne(ctx, token)
+	metrics.ObserveMongoOperation("insert_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+	return nil
+}
+
+func (s *mongoRefreshTokenStore) Find(ctx context.Context, hash string) (*RefreshToken, error) {
+	start := time.Now()
+	var token RefreshToken
+	err := s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: hash}}).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one", start, nil)
+		return nil, ErrRefreshTokenNotFound
+	}
+	metrics.ObserveMongoOperation("find_one", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+func (s *mongoRefreshTokenStore) Use(ctx context.Context, hash string, at time.Time) (*RefreshToken, error) {
+	// Only an unused token matches, so two concurrent rotations of the same
+	// token cannot both succeed.
+	filter := bson.D{
+		{Key: "_id", Value: hash},
+		{Key: "used_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "used_at", Value: at}}}}
+
+	start := time.Now()
+	var token RefreshToken
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After)).Decode(&token)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		metrics.ObserveMongoOperation("find_one_and_update", start, nil)
+		used, err := s.Find(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		return used, ErrRefreshTokenUsed
+	}
+	metrics.ObserveMongoOperation("find_one_and_update", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to use refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+func (s *mongoRefreshTokenStore) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	filter := bson.D{
+		{Key: "family_id", Value: familyID},
+		{Key: "revoked_at", Value: bson.D{{Key: "$exists", Value: false}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "revoked_at", Value: at}}}}
+
+	start := time.Now()
+	_, err := s.collection.UpdateMany(ctx, filter, update)
+	metrics.ObserveMongoOperation("update_many", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryRefreshTokenStore keeps refresh tokens in memory for tests.
+type memoryRefreshTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*RefreshToken
+	now    func() time.Time
+}
+
+func newMemoryRefreshTokenStore() RefreshTokenStore {
+	return &memoryRefreshTokenStore{
+		tokens: make(map[string]*RefreshToken),
+		now:    time.Now,
+	}
+}
+
+func (s *memoryRefreshTokenStore) Create(_ context.Context, token *RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	stored := *token
+	s.tokens[token.Hash] = &stored
+	return nil
+}
+
+func (s *memoryRefreshTokenStore) Find(_ context.Context, hash string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+	found := *t
+	return &found, nil
+}
+
+func (s *memoryRefreshTokenStore) Use(_ context.Context, hash string, at time.Time) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[hash]
+	if !ok {
+		return nil, ErrRefreshTokenNotFound
+	}
+	if t.UsedAt != nil {
+		found := *t
+		return &found, ErrRefreshTokenUsed
+	}
+
+	t.UsedAt = &at
+	found := *t
+	return &found, nil
+}
+
+func (s *memoryRefreshTokenStore) RevokeFamily(_ context.Context, familyID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.FamilyID == familyID && t.RevokedAt == nil {
+			t.RevokedAt = &at
+		}
+	}
+	return nil
+}
+
+// pruneLocked drops expired tokens. s.mu must be held.
+func (s *memoryRefreshTokenStore) pruneLocked() {
+	now := s.now()
+	for hash, t := range s.tokens {
+		if !t.ExpiresAt.After(now) {
+			delete(s.tokens, hash)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+)
+
+// dummyHash is compared against when a user does not exist, so that unknown
+// usernames take as long to reject as wrong passwords.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+// TokenPair is an access token and the refresh token that renews it.
+type TokenPair struct {
+	AccessToken      string
+	ExpiresIn        time.Duration
+	RefreshToken     string
+	RefreshExpiresIn time.Duration
+}
+
+// TokenService issues the tokens accepted by JWTAuthMiddleware.
+type TokenService interface {
+	// PasswordGrant signs a rider in with their username and password.
+	PasswordGrant(ctx context.Context, username, password string) (*TokenPair, error)
+	// DeviceGrant signs a rider in with the secret of one of their devices.
+	DeviceGrant(ctx context.Context, deviceID, secret string) (*TokenPair, error)
+	// Refresh exchanges a refresh token for a new pair. The refresh token can
+	// only be used once; presenting it again revokes every token of its family.
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	// Revoke revokes the refresh token and every token of its family.
+	Revoke(ctx context.Context, refreshToken string) error
+}
+
+type tokenService struct {
+	users      UserStore
+	refresh    RefreshTokenStore
+	signer     signingKey
+	secret     []byte
+	issuer     string
+	audience   string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+// NewTokenService creates a token service. Access tokens are signed with the
+// key of JWTSigningKeyFile when set, and with JWTSecret (HS256) otherwise.
+func NewTokenService(users UserStore, refresh RefreshTokenStore, cfg config.Config, logger *zap.Logger) (TokenService, error) {
+	s := tokenService{
+		users:      users,
+		refresh:    refresh,
+		issuer:     cfg.JWTIssuer,
+		audience:   cfg.JWTAudience,
+		accessTTL:  cfg.AccessTokenTTL,
+		refreshTTL: cfg.RefreshTokenTTL,
+		logger:     logger,
+		now:        time.Now,
+	}
+
+	switch {
+	case cfg.JWTSigningKeyFile != "":
+		key, err := loadSigningKey(cfg.JWTSigningKeyFile, cfg.JWTSigningKeyID)
+		if err != nil {
+			return nil, err
+		}
+		s.signer = key
+	case cfg.JWTSecret != "":
+		s.signer = signingKey{method: jwt.SigningMethodHS256}
+		s.secret = []byte(cfg.JWTSecret)
+	default:
+		return nil, errors.New("no JWT signing key file or secret configured")
+	}
+
+	return s, nil
+}
+
+func (s tokenService) PasswordGrant(ctx context.Context, username, password string) (*TokenPair, error) {
+	user, err := s.users.FindUserByUsername(ctx, username)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	hash := dummyHash
+	if user != nil && user.PasswordHash != "" {
+		hash = []byte(user.PasswordHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil || user == nil || user.PasswordHash == "" || user.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issue(ctx, user, uuid.NewString())
+}
+
+func (s tokenService) DeviceGrant(ctx context.Context, deviceID, secret string) (*TokenPair, error) {
+	user, device, err := s.users.FindUserByDevice(ctx, deviceID)
+	if err != nil && !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	hash := dummyHash
+	if device != nil {
+		hash = []byte(device.SecretHash)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(secret)) != nil || user == nil || user.Disabled {
+		return nil, ErrInvalidCredentials
+	}
+
+	return s.issue(ctx, user, uuid.NewString())
+}
+
+func (s tokenService) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	now := s.now()
+
+	stored, err := s.refresh.Use(ctx, hashToken(refreshToken), now)
+	switch {
+	case errors.Is(err, ErrRefreshTokenNotFound):
+		return nil, ErrInvalidRefreshToken
+	case errors.Is(err, ErrRefreshTokenUsed):
+		// A used token was presented again, so it has leaked: revoke the
+		// whole family, including the token that replaced it.
+		if stored.RevokedAt == nil {
+			logging.FromContext(ctx, s.logger).Warn("Refresh token reused, revoking its family",
+				zap.String("user_id", stored.UserID), zap.String("family_id", stored.FamilyID))
+			if err := s.refresh.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidRefreshToken
+	case err != nil:
+		return nil, err
+	}
+
+	if stored.RevokedAt != nil || !stored.ExpiresAt.After(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.users.FindUserByID(ctx, stored.UserID)
+	if errors.Is(err, ErrUserNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return s.issue(ctx, user, stored.FamilyID)
+}
+
+func (s tokenService) Revoke(ctx context.Context, refreshToken string) error {
+	stored, err := s.refresh.Find(ctx, hashToken(refreshToken))
+	if errors.Is(err, ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.refresh.RevokeFamily(ctx, stored.FamilyID, s.now())
+}
+
+// issue signs an access token for user and stores a new refresh token in familyID.
+func (s tokenService) issue(ctx context.Context, user *User, familyID string) (*TokenPair, error) {
+	now := s.now()
+
+	claims := jwt.MapClaims{
+		"authenticated": true,
+		"user_id":       user.ID,
+		"sub":           user.ID,
+		"jti":           uuid.NewString(),
+		"iat":           now.Unix(),
+		"exp":           now.Add(s.accessTTL).Unix(),
+	}
+	if len(user.Roles) > 0 {
+		claims["roles"] = user.Roles
+	}
+	if s.issuer != "" {
+		claims["iss"] = s.issuer
+	}
+	if s.audience != "" {
+		claims["aud"] = s.audience
+	}
+
+	token := jwt.NewWithClaims(s.signer.method, claims)
+	if s.signer.kid != "" {
+		token.Header["kid"] = s.signer.kid
+	}
+
+	var key any = s.secret
+	if s.signer.key != nil {
+		key = s.signer.key
+	}
+	accessToken, err := token.SignedString(key)
+	if err != nil {
+		return nil, fmt.Errorf("sign access token: %w", err)
+	}
+
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refresh.Create(ctx, &RefreshToken{
+		Hash:      hashToken(refreshToken),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(s.refreshTTL),
+	}); err != nil {
+		return nil, fmt.Errorf("store refresh token: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		ExpiresIn:        s.accessTTL,
+		RefreshToken:     refreshToken,
+		RefreshExpiresIn: s.refreshTTL,
+	}, nil
+}
+
+func newRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+func bcryptHash(t *testing.T, secret string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.MinCost)
+	require.NoError(t, err)
+	return string(hash)
+}
+
+// writeUsers writes a users file with an active rider, who also has a device,
+// a disabled rider and a dispatcher.
+func writeUsers(t *testing.T) string {
+	users := []User{
+		{
+			ID:           "rider-1",
+			Username:     "ayse",
+			PasswordHash: bcryptHash(t, "correct-password"),
+			Devices:      []Device{{ID: "phone-1", SecretHash: bcryptHash(t, "device-secret")}},
+		},
+		{
+			ID:           "rider-2",
+			Username:     "mehmet",
+			PasswordHash: bcryptHash(t, "correct-password"),
+			Disabled:     true,
+		},
+		{
+			ID:           "dispatcher-1",
+			Username:     "zeynep",
+			Roles:        []string{config.RoleDispatcher},
+			PasswordHash: bcryptHash(t, "correct-password"),
+		},
+	}
+	data, err := json.Marshal(users)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func testTokenConfig() config.Config {
+	return config.Config{
+		JWTSecret:       testSecret,
+		JWTIssuer:       "https://issuer.example",
+		JWTAudience:     "matching",
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: time.Hour,
+	}
+}
+
+func setupTokenTest(t *testing.T, cfg config.Config) (TokenService, *Verifier) {
+	users, err := NewFileUserStore(writeUsers(t))
+	require.NoError(t, err)
+
+	tokens, err := NewTokenService(users, newMemoryRefreshTokenStore(), cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	verifier, err := NewVerifier(cfg, zap.NewNop())
+	require.NoError(t, err)
+	return tokens, verifier
+}
+
+func TestTokenService_Grants(t *testing.T) {
+	tokens, verifier := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		grant         func() (*TokenPair, error)
+		expectedError error
+	}{
+		{
+			name:  "success - password",
+			grant: func() (*TokenPair, error) { return tokens.PasswordGrant(ctx, "ayse", "correct-password") },
+		},
+		{
+			name:  "success - device",
+			grant: func() (*TokenPair, error) { return tokens.DeviceGrant(ctx, "phone-1", "device-secret") },
+		},
+		{
+			name:          "failure - wrong password",
+			grant:         func() (*TokenPair, error) { return tokens.PasswordGrant(ctx, "ayse", "wrong-password") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "failure - unknown user",
+			grant:         func() (*TokenPair, error) { return tokens.PasswordGrant(ctx, "nobody", "correct-password") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "failure - disabled user",
+			grant:         func() (*TokenPair, error) { return tokens.PasswordGrant(ctx, "mehmet", "correct-password") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "failure - wrong device secret",
+			grant:         func() (*TokenPair, error) { return tokens.DeviceGrant(ctx, "phone-1", "other-secret") },
+			expectedError: ErrInvalidCredentials,
+		},
+		{
+			name:          "failure - unknown device",
+			grant:         func() (*TokenPair, error) { return tokens.DeviceGrant(ctx, "phone-9", "device-secret") },
+			expectedError: ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			pair, err := tt.grant()
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, pair)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotEmpty(t, pair.RefreshToken)
+			assert.Equal(t, 15*time.Minute, pair.ExpiresIn)
+
+			token, err := verifier.Parse(ctx, pair.AccessToken)
+			require.NoError(t, err, "issued tokens must pass JWTAuthMiddleware verification")
+			claims := token.Claims.(jwt.MapClaims)
+			assert.Equal(t, true, claims["authenticated"])
+			assert.Equal(t, "rider-1", claims["user_id"])
+		})
+	}
+}
+
+func TestTokenService_IssuesRoles(t *testing.T) {
+	// Setup
+	tokens, verifier := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+
+	// Execute
+	pair, err := tokens.PasswordGrant(ctx, "zeynep", "correct-password")
+	require.NoError(t, err)
+	token, err := verifier.Parse(ctx, pair.AccessToken)
+	require.NoError(t, err)
+	principal, err := NewPrincipal(token.Claims.(jwt.MapClaims))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "dispatcher-1", principal.UserID)
+	assert.Equal(t, []string{config.RoleDispatcher}, principal.Roles)
+	assert.True(t, principal.Can(config.PermissionMatchOnBehalf))
+	assert.False(t, principal.Can("keys:admin"))
+}
+
+func TestTokenService_RefreshRotates(t *testing.T) {
+	// Setup
+	tokens, _ := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+	first, err := tokens.PasswordGrant(ctx, "ayse", "correct-password")
+	require.NoError(t, err)
+
+	// Execute
+	second, err := tokens.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	third, err := tokens.Refresh(ctx, second.RefreshToken)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+	assert.NotEqual(t, second.RefreshToken, third.RefreshToken)
+
+	_, err = tokens.Refresh(ctx, "unknown-token")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestTokenService_RefreshReuseRevokesFamily(t *testing.T) {
+	// Setup
+	tokens, _ := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+	first, err := tokens.PasswordGrant(ctx, "ayse", "correct-password")
+	require.NoError(t, err)
+	second, err := tokens.Refresh(ctx, first.RefreshToken)
+	require.NoError(t, err)
+	other, err := tokens.DeviceGrant(ctx, "phone-1", "device-secret")
+	require.NoError(t, err)
+
+	// Execute
+	_, reuseErr := tokens.Refresh(ctx, first.RefreshToken)
+	_, successorErr := tokens.Refresh(ctx, second.RefreshToken)
+	_, otherErr := tokens.Refresh(ctx, other.RefreshToken)
+
+	// Assert
+	assert.ErrorIs(t, reuseErr, ErrInvalidRefreshToken)
+	assert.ErrorIs(t, successorErr, ErrInvalidRefreshToken, "the token that replaced the reused one must be revoked")
+	assert.NoError(t, otherErr, "other sign-ins of the rider are not affected")
+}
+
+func TestTokenService_Revoke(t *testing.T) {
+	// Setup
+	tokens, _ := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+	pair, err := tokens.PasswordGrant(ctx, "ayse", "correct-password")
+	require.NoError(t, err)
+
+	// Execute
+	require.NoError(t, tokens.Revoke(ctx, pair.RefreshToken))
+	_, err = tokens.Refresh(ctx, pair.RefreshToken)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.NoError(t, tokens.Revoke(ctx, "unknown-token"))
+}
+
+func TestTokenService_RefreshExpired(t *testing.T) {
+	// Setup
+	tokens, _ := setupTokenTest(t, testTokenConfig())
+	ctx := context.Background()
+	pair, err := tokens.PasswordGrant(ctx, "ayse", "correct-password")
+	require.NoError(t, err)
+
+	svc := tokens.(tokenService)
+	svc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	// Execute
+	_, err = svc.Refresh(ctx, pair.RefreshToken)
+
+	// Assert
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestTokenService_SigningKey(t *testing.T) {
+	// Setup
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "signing.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	cfg := testTokenConfig()
+	cfg.JWTSecret = ""
+	cfg.JWTSigningKeyFile = path
+	cfg.JWTSigningKeyID = "matching-1"
+	tokens, verifier := setupTokenTest(t, cfg)
+	ctx := context.Background()
+
+	// Execute
+	pair, err := tokens.PasswordGrant(ctx, "ayse", "correct-password")
+	require.NoError(t, err)
+	token, err := verifier.Parse(ctx, pair.AccessToken)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ES256", token.Method.Alg())
+	assert.Equal(t, "matching-1", token.Header["kid"])
+}
+
+func TestNewFileUserStore_Duplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"id":"a","username":"x"},{"id":"b","username":"x"}]`), 0o600))
+
+	_, err := NewFileUserStore(path)
+
+	assert.ErrorContains(t, err, "duplicate username")
+}
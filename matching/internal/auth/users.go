@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// User is a rider that can be issued tokens. Riders sign in with their
+// username and password, or with the secret of one of their devices. Hashes
+// are bcrypt hashes. Users without roles are riders.
+type User struct {
+	ID           string   `json:"id"`
+	Username     string   `json:"username"`
+	Roles        []string `json:"roles,omitempty"`
+	PasswordHash string   `json:"password_hash,omitempty"`
+	Devices      []Device `json:"devices,omitempty"`
+	Disabled     bool     `json:"disabled,omitempty"`
+}
+
+type Device struct {
+	ID         string `json:"id"`
+	SecretHash string `json:"secret_hash"`
+}
+
+// UserStore looks up the riders tokens are issued to.
+type UserStore interface {
+	FindUserByID(ctx context.Context, id string) (*User, error)
+	FindUserByUsername(ctx context.Context, username string) (*User, error)
+	FindUserByDevice(ctx context.Context, deviceID string) (*User, *Device, error)
+}
+
+// fileUserStore serves the users of a JSON file, read once at startup.
+type fileUserStore struct {
+	byID       map[string]*User
+	byUsername map[string]*User
+	byDevice   map[string]*User
+}
+
+// NewFileUserStore loads the users of a JSON file holding an array of users.
+func NewFileUserStore(path string) (UserStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read users file: %w", err)
+	}
+
+	var users []*User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("parse users file: %w", err)
+	}
+
+	s := fileUserStore{
+		byID:       make(map[string]*User, len(users)),
+		byUsername: make(map[string]*User, len(users)),
+		byDevice:   make(map[string]*User),
+	}
+	for _, u := range users {
+		if u.ID == "" {
+			return nil, fmt.Errorf("user %q has no id", u.Username)
+		}
+		if _, exists := s.byID[u.ID]; exists {
+			return nil, fmt.Errorf("duplicate user id %q", u.ID)
+		}
+		s.byID[u.ID] = u
+
+		if u.Username != "" {
+			if _, exists := s.byUsername[u.Username]; exists {
+				return nil, fmt.Errorf("duplicate username %q", u.Username)
+			}
+			s.byUsername[u.Username] = u
+		}
+
+		for _, d := range u.Devices {
+			if _, exists := s.byDevice[d.ID]; exists {
+				return nil, fmt.Errorf("duplicate device id %q", d.ID)
+			}
+			s.byDevice[d.ID] = u
+		}
+	}
+
+	return s, nil
+}
+
+func (s fileUserStore) FindUserByID(_ context.Context, id string) (*User, error) {
+	if u, ok := s.byID[id]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s fileUserStore) FindUserByUsername(_ context.Context, username string) (*User, error) {
+	if u, ok := s.byUsername[username]; ok {
+		return u, nil
+	}
+	return nil, ErrUserNotFound
+}
+
+func (s fileUserStore) FindUserByDevice(_ context.Context, deviceID string) (*User, *Device, error) {
+	u, ok := s.byDevice[deviceID]
+	if !ok {
+		return nil, nil, ErrUserNotFound
+	}
+	for i := range u.Devices {
+		if u.Devices[i].ID == deviceID {
+			return u, &u.Devices[i], nil
+		}
+	}
+	return nil, nil, ErrUserNotFound
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+var ErrNoVerificationKey = errors.New("no verification key for token")
+
+// Verifier parses and verifies bearer tokens. HMAC tokens are verified with
+// the shared secret, RS256 and ES256 tokens with the keys of the PEM file, the
+// JWKS URL and the key the service signs its own tokens with. The issuer and
+// audience are checked when configured.
+type Verifier struct {
+	secret     []byte
+	staticKeys []publicKey
+	jwks       *JWKS
+	parser     *jwt.Parser
+}
+
+// NewVerifier creates a verifier from the JWT settings of cfg. The PEM file
+// is read immediately; the JWKS URL is fetched on first use.
+func NewVerifier(cfg config.Config, logger *zap.Logger) (*Verifier, error) {
+	v := &Verifier{}
+	var methods []string
+
+	if cfg.JWTSecret != "" {
+		v.secret = []byte(cfg.JWTSecret)
+		methods = append(methods,
+			jwt.SigningMethodHS256.Alg(),
+			jwt.SigningMethodHS384.Alg(),
+			jwt.SigningMethodHS512.Alg(),
+		)
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		keys, err := loadPEMKeys(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		v.staticKeys = keys
+	}
+
+	if cfg.JWTSigningKeyFile != "" {
+		key, err := loadSigningKey(cfg.JWTSigningKeyFile, cfg.JWTSigningKeyID)
+		if err != nil {
+			return nil, err
+		}
+		v.staticKeys = append(v.staticKeys, key.publicKey())
+	}
+
+	if cfg.JWTJWKSURL != "" {
+		v.jwks = NewJWKS(cfg.JWTJWKSURL, cfg.JWTJWKSRefreshInterval, logger)
+	}
+
+	if v.staticKeys != nil || v.jwks != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg())
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("no JWT secret, public key file or JWKS URL configured")
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.JWTAudience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Parse verifies tokenString and returns the parsed token.
+func (v *Verifier) Parse(ctx context.Context, tokenString string) (*jwt.Token, error) {
+	return v.parser.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if v.secret == nil {
+				return nil, ErrNoVerificationKey
+			}
+			return v.secret, nil
+		}
+
+		alg := token.Method.Alg()
+		kid, _ := token.Header["kid"].(string)
+
+		var keys []publicKey
+		for _, k := range v.staticKeys {
+			if k.supports(alg) {
+				keys = append(keys, k)
+			}
+		}
+		if v.jwks != nil {
+			published, err := v.jwks.Keys(ctx, kid, alg)
+			if err != nil && keys == nil {
+				return nil, err
+			}
+			keys = append(keys, published...)
+		}
+
+		if len(keys) == 0 {
+			return nil, ErrNoVerificationKey
+		}
+
+		set := jwt.VerificationKeySet{Keys: make([]jwt.VerificationKey, len(keys))}
+		for i, k := range keys {
+			set.Keys[i] = k.key
+		}
+		return set, nil
+	})
+}
+
+// Check reports whether tokens can be verified: the shared secret must be
+// long enough and the JWKS, when configured, must have been fetched.
+func (v *Verifier) Check(ctx context.Context) error {
+	if v.secret != nil && len(v.secret) < config.MinJWTSecretLength {
+		return fmt.Errorf("secret is shorter than %d bytes", config.MinJWTSecretLength)
+	}
+	if v.jwks != nil {
+		return v.jwks.Check(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+)
+
+const testSecret = "test-secret-key-that-is-32-bytes"
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"authenticated": true,
+		"iss":           "https://issuer.example",
+		"aud":           "matching",
+		"exp":           time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return s
+}
+
+func writePEM(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "public.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jwk {
+	point, _ := key.Bytes()
+	return jwk{
+		Kty: "EC",
+		Kid: kid,
+		Alg: "ES256",
+		Crv: "P-256",
+		X:   base64.RawURLEncoding.EncodeToString(point[1:33]),
+		Y:   base64.RawURLEncoding.EncodeToString(point[33:]),
+	}
+}
+
+// jwksServer serves the keys returned by keys and counts the requests.
+func jwksServer(t *testing.T, keys func() []jwk) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys()})
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestVerifier_Parse(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	server, _ := jwksServer(t, func() []jwk {
+		return []jwk{rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey)}
+	})
+
+	tests := []struct {
+		name          string
+		cfg           config.Config
+		token         func() string
+		expectedError error
+	}{
+		{
+			name:  "success - HS256 with secret",
+			cfg:   config.Config{JWTSecret: testSecret},
+			token: func() string { return sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims()) },
+		},
+		{
+			name:  "success - RS256 with PEM key",
+			cfg:   config.Config{JWTPublicKeyFile: writePEM(t, &rsaKey.PublicKey)},
+			token: func() string { return sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims()) },
+		},
+		{
+			name:  "success - ES256 with PEM key",
+			cfg:   config.Config{JWTPublicKeyFile: writePEM(t, &ecKey.PublicKey)},
+			token: func() string { return sign(t, jwt.SigningMethodES256, ecKey, "", validClaims()) },
+		},
+		{
+			name:  "success - RS256 with JWKS kid",
+			cfg:   config.Config{JWTJWKSURL: server.URL, JWTJWKSRefreshInterval: time.Hour},
+			token: func() string { return sign(t, jwt.SigningMethodRS256, rsaKey, "rsa-1", validClaims()) },
+		},
+		{
+			name:  "success - ES256 with JWKS kid",
+			cfg:   config.Config{JWTJWKSURL: server.URL, JWTJWKSRefreshInterval: time.Hour},
+			token: func() string { return sign(t, jwt.SigningMethodES256, ecKey, "ec-1", validClaims()) },
+		},
+		{
+			name:  "success - matching issuer and audience",
+			cfg:   config.Config{JWTSecret: testSecret, JWTIssuer: "https://issuer.example", JWTAudience: "matching"},
+			token: func() string { return sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims()) },
+		},
+		{
+			name:          "failure - unknown kid",
+			cfg:           config.Config{JWTJWKSURL: server.URL, JWTJWKSRefreshInterval: time.Hour},
+			token:         func() string { return sign(t, jwt.SigningMethodES256, ecKey, "ec-2", validClaims()) },
+			expectedError: ErrNoVerificationKey,
+		},
+		{
+			name:          "failure - kid of another key",
+			cfg:           config.Config{JWTJWKSURL: server.URL, JWTJWKSRefreshInterval: time.Hour},
+			token:         func() string { return sign(t, jwt.SigningMethodES256, otherKey, "ec-1", validClaims()) },
+			expectedError: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:          "failure - HS256 without secret",
+			cfg:           config.Config{JWTPublicKeyFile: writePEM(t, &rsaKey.PublicKey)},
+			token:         func() string { return sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims()) },
+			expectedError: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:          "failure - RS256 without public keys",
+			cfg:           config.Config{JWTSecret: testSecret},
+			token:         func() string { return sign(t, jwt.SigningMethodRS256, rsaKey, "", validClaims()) },
+			expectedError: jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name: "failure - wrong issuer",
+			cfg:  config.Config{JWTSecret: testSecret, JWTIssuer: "https://other.example"},
+			token: func() string {
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims())
+			},
+			expectedError: jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name: "failure - wrong audience",
+			cfg:  config.Config{JWTSecret: testSecret, JWTAudience: "billing"},
+			token: func() string {
+				return sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", validClaims())
+			},
+			expectedError: jwt.ErrTokenInvalidAudience,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			verifier, err := NewVerifier(tt.cfg, zap.NewNop())
+			require.NoError(t, err)
+
+			// Execute
+			token, err := verifier.Parse(context.Background(), tt.token())
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, token.Valid)
+			}
+		})
+	}
+}
+
+func TestNewVerifier_Errors(t *testing.T) {
+	_, err := NewVerifier(config.Config{}, zap.NewNop())
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a key"), 0o600))
+	_, err = NewVerifier(config.Config{JWTPublicKeyFile: path}, zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestJWKS_RefreshesOnUnknownKid(t *testing.T) {
+	// Setup
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	newKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	published := []jwk{ecJWK("old", &oldKey.PublicKey)}
+	server, hits := jwksServer(t, func() []jwk { return published })
+
+	jwks := NewJWKS(server.URL, time.Hour, zap.NewNop())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	jwks.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Execute
+	keys, err := jwks.Keys(ctx, "old", "ES256")
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	_, err = jwks.Keys(ctx, "old", "ES256")
+	require.NoError(t, err)
+
+	published = append(published, ecJWK("new", &newKey.PublicKey))
+	keys, err = jwks.Keys(ctx, "new", "ES256")
+	require.NoError(t, err)
+	assert.Empty(t, keys, "refreshes triggered by unknown kids are rate limited")
+
+	now = now.Add(config.JWKSMinRefreshInterval)
+	keys, err = jwks.Keys(ctx, "new", "ES256")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, int32(2), hits.Load())
+}
+
+func TestJWKS_KeepsKeysWhenRefreshFails(t *testing.T) {
+	// Setup
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{ecJWK("k", &key.PublicKey)}})
+	}))
+	defer server.Close()
+
+	jwks := NewJWKS(server.URL, time.Minute, zap.NewNop())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	jwks.now = func() time.Time { return now }
+	ctx := context.Background()
+	require.NoError(t, jwks.Check(ctx))
+
+	// Execute
+	failing.Store(true)
+	now = now.Add(time.Hour)
+	keys, err := jwks.Keys(ctx, "k", "ES256")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+	assert.NoError(t, jwks.Check(ctx))
+}
+
+func TestJWKS_ServesCachedKeysWhileRefreshing(t *testing.T) {
+	// Setup
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > 1 {
+			<-release
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []jwk{ecJWK("k", &key.PublicKey)}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	jwks := NewJWKS(server.URL, time.Minute, zap.NewNop())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	jwks.now = func() time.Time { return now }
+	ctx := context.Background()
+	require.NoError(t, jwks.Check(ctx))
+	now = now.Add(time.Hour)
+
+	// Execute
+	done := make(chan []publicKey)
+	go func() {
+		for range 3 {
+			keys, _ := jwks.Keys(ctx, "k", "ES256")
+			done <- keys
+		}
+	}()
+
+	// Assert
+	for range 3 {
+		select {
+		case keys := <-done:
+			assert.Len(t, keys, 1)
+		case <-time.After(time.Second):
+			t.Fatal("Keys waited for the refresh in flight")
+		}
+	}
+	assert.Eventually(t, func() bool { return hits.Load() == 2 }, time.Second, 10*time.Millisecond, "concurrent refreshes must share one fetch")
+}
+
+func TestVerifier_Check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		cfg         config.Config
+		expectError bool
+	}{
+		{
+			name: "ok - long secret",
+			cfg:  config.Config{JWTSecret: testSecret},
+		},
+		{
+			name:        "unavailable - short secret",
+			cfg:         config.Config{JWTSecret: "short"},
+			expectError: true,
+		},
+		{
+			name:        "unavailable - JWKS not fetched",
+			cfg:         config.Config{JWTJWKSURL: server.URL, JWTJWKSRefreshInterval: time.Hour},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			verifier, err := NewVerifier(tt.cfg, zap.NewNop())
+			require.NoError(t, err)
+
+			// Execute
+			err = verifier.Check(context.Background())
+
+			// Assert
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+var ErrClosed = errors.New("batcher is closed")
+
+// Result is the outcome of a single request within a batch.
+type Result struct {
+	Match *dto.DriverMatch
+	Err   error
+}
+
+// ResolveFunc resolves a batch of requests. It must return one result per
+// request, in the same order.
+type ResolveFunc func(ctx context.Context, requests []*dto.MatchRequest) []Result
+
+type pending struct {
+	// ctx is the context of the submitting request.
+	ctx     context.Context
+	request *dto.MatchRequest
+	result  chan Result
+}
+
+// Batcher buffers match requests for a short window and resolves them together.
+type Batcher struct {
+	window         time.Duration
+	maxSize        int
+	resolveTimeout time.Duration
+	resolve        ResolveFunc
+	tracer         trace.Tracer
+
+	queue     chan *pending
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewBatcher starts a batcher that flushes after window has elapsed since the
+// first buffered request, or as soon as maxSize requests are buffered. Both
+// window and maxSize must be positive.
+func NewBatcher(window time.Duration, maxSize int, resolveTimeout time.Duration, resolve ResolveFunc) *Batcher {
+	b := &Batcher{
+		window:         window,
+		maxSize:        maxSize,
+		resolveTimeout: resolveTimeout,
+		resolve:        resolve,
+		tracer:         otel.Tracer("github.com/BarkinBalci/bitaksi-case-study/matching/internal/batch"),
+		queue:          make(chan *pending, maxSize),
+		done:           make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// Submit enqueues a request and waits until its batch has been resolved.
+func (b *Batcher) Submit(ctx context.Context, req *dto.MatchRequest) (*dto.DriverMatch, error) {
+	p := &pending{
+		ctx:     ctx,
+		request: req,
+		result:  make(chan Result, 1),
+	}
+
+	select {
+	case <-b.done:
+		return nil, ErrClosed
+	default:
+	}
+
+	select {
+	case b.queue <- p:
+	case <-b.done:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	select {
+	case res := <-p.result:
+		return res.Match, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops accepting requests and resolves anything already buffered.
+func (b *Batcher) Close() {
+	b.closeOnce.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+}
+
+func (b *Batcher) run() {
+	defer b.wg.Done()
+
+	for {
+		var first *pending
+		select {
+		case first = <-b.queue:
+		case <-b.done:
+			b.drain()
+			return
+		}
+
+		batch := []*pending{first}
+		timer := time.NewTimer(b.window)
+
+	collect:
+		for len(batch) < b.maxSize {
+			select {
+			case p := <-b.queue:
+				batch = append(batch, p)
+			case <-timer.C:
+				break collect
+			case <-b.done:
+				break collect
+			}
+		}
+		timer.Stop()
+
+		b.flush(batch)
+	}
+}
+
+// drain resolves requests that were queued before the batcher was closed.
+func (b *Batcher) drain() {
+	var batch []*pending
+	for {
+		select {
+		case p := <-b.queue:
+			batch = append(batch, p)
+		default:
+			if len(batch) > 0 {
+				b.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+func (b *Batcher) flush(batch []*pending) {
+	// Requests whose callers gave up are dropped, so that no driver is held
+	// for a rider who is no longer waiting.
+	batch = slices.DeleteFunc(batch, func(p *pending) bool {
+		if err := p.ctx.Err(); err != nil {
+			p.result <- Result{Err: err}
+			return true
+		}
+		return false
+	})
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel, span := batchContext(b.tracer, batch, b.resolveTimeout)
+	defer cancel()
+
+	requests := make([]*dto.MatchRequest, len(batch))
+	for i, p := range batch {
+		requests[i] = p.request
+	}
+
+	results := b.resolve(ctx, requests)
+	span.End()
+	for i, p := range batch {
+		p.result <- results[i]
+	}
+}
+
+// batchContext returns the context a batch is resolved on. Callers may have
+// different deadlines, so it is not cancelled with any of them, but it keeps
+// the request ID and logger of the first request. The batch gets a span of
+// its own, linked to the span of every request in it, and its logger lists
+// the IDs of those requests.
+func batchContext(tracer trace.Tracer, batch []*pending, timeout time.Duration) (context.Context, context.CancelFunc, trace.Span) {
+	first := batch[0].ctx
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(first), timeout)
+
+	links := make([]trace.Link, 0, len(batch))
+	requestIDs := make([]string, 0, len(batch))
+	for _, p := range batch {
+		if sc := trace.SpanContextFromContext(p.ctx); sc.IsValid() {
+			links = append(links, trace.Link{SpanContext: sc})
+		}
+		if id := logging.RequestID(p.ctx); id != "" {
+			requestIDs = append(requestIDs, id)
+		}
+	}
+
+	ctx, span := tracer.Start(ctx, "Batcher.flush",
+		trace.WithNewRoot(),
+		trace.WithLinks(links...),
+		trace.WithAttributes(attribute.Int("batch.size", len(batch))),
+	)
+
+	if logger := logging.FromContext(first, nil); logger != nil {
+		ctx = logging.WithLogger(ctx, logger.With(zap.Strings("batch_request_ids", requestIDs)))
+	}
+
+	return ctx, cancel, span
+}
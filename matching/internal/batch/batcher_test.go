@@ -0,0 +1,187 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+func echoResolver(batchSizes chan<- int) ResolveFunc {
+	return func(ctx context.Context, requests []*dto.MatchRequest) []Result {
+		batchSizes <- len(requests)
+		results := make([]Result, len(requests))
+		for i, req := range requests {
+			if req.Policy == "fail" {
+				results[i].Err = errors.New("resolve failed")
+				continue
+			}
+			results[i].Match = &dto.DriverMatch{ID: req.Policy}
+		}
+		return results
+	}
+}
+
+func TestBatcher_FanOut(t *testing.T) {
+	batchSizes := make(chan int, 10)
+	b := NewBatcher(50*time.Millisecond, 10, time.Second, echoResolver(batchSizes))
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := fmt.Sprintf("rider-%d", i)
+
+			match, err := b.Submit(context.Background(), &dto.MatchRequest{Policy: id})
+
+			assert.NoError(t, err)
+			assert.Equal(t, id, match.ID)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 3, <-batchSizes)
+}
+
+func TestBatcher_FlushesAtMaxSize(t *testing.T) {
+	batchSizes := make(chan int, 10)
+	b := NewBatcher(time.Hour, 2, time.Second, echoResolver(batchSizes))
+	defer b.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Submit(context.Background(), &dto.MatchRequest{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 2, <-batchSizes)
+}
+
+func TestBatcher_PropagatesErrors(t *testing.T) {
+	b := NewBatcher(10*time.Millisecond, 10, time.Second, echoResolver(make(chan int, 10)))
+	defer b.Close()
+
+	match, err := b.Submit(context.Background(), &dto.MatchRequest{Policy: "fail"})
+
+	assert.Error(t, err)
+	assert.Nil(t, match)
+}
+
+func TestBatcher_SubmitAfterClose(t *testing.T) {
+	b := NewBatcher(10*time.Millisecond, 10, time.Second, echoResolver(make(chan int, 10)))
+	b.Close()
+
+	_, err := b.Submit(context.Background(), &dto.MatchRequest{})
+
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestBatcher_Context(t *testing.T) {
+	// Setup
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	var resolved context.Context
+	var resolvedErr error
+	b := NewBatcher(time.Hour, 2, time.Second, func(ctx context.Context, requests []*dto.MatchRequest) []Result {
+		resolved, resolvedErr = ctx, ctx.Err()
+		return make([]Result, len(requests))
+	})
+	defer b.Close()
+
+	requestIDs := []string{"req-1", "req-2"}
+	spans := make([]trace.SpanContext, len(requestIDs))
+	var wg sync.WaitGroup
+	for i, id := range requestIDs {
+		ctx, span := provider.Tracer("test").Start(logging.WithRequestID(context.Background(), id), "request")
+		defer span.End()
+		spans[i] = span.SpanContext()
+		// A caller giving up must not cancel the batch of the others.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// Execute
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Submit(ctx, &dto.MatchRequest{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	require.NotNil(t, resolved)
+	assert.Contains(t, requestIDs, logging.RequestID(resolved))
+	assert.NoError(t, resolvedErr)
+
+	var flush sdktrace.ReadOnlySpan
+	for _, s := range recorder.Ended() {
+		if s.Name() == "Batcher.flush" {
+			flush = s
+		}
+	}
+	require.NotNil(t, flush)
+	assert.False(t, flush.Parent().IsValid())
+	var linked []trace.SpanContext
+	for _, l := range flush.Links() {
+		linked = append(linked, l.SpanContext)
+	}
+	assert.ElementsMatch(t, spans, linked)
+}
+
+func TestBatcher_DropsCancelledRequests(t *testing.T) {
+	// Setup
+	batched := make(chan []*dto.MatchRequest, 1)
+	b := NewBatcher(50*time.Millisecond, 10, time.Second, func(ctx context.Context, requests []*dto.MatchRequest) []Result {
+		batched <- requests
+		return make([]Result, len(requests))
+	})
+	defer b.Close()
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	gone := &dto.MatchRequest{RiderID: "gone"}
+	waiting := &dto.MatchRequest{RiderID: "waiting"}
+
+	// Execute
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := b.Submit(cancelled, gone)
+		assert.ErrorIs(t, err, context.Canceled)
+	}()
+	go func() {
+		defer wg.Done()
+		_, err := b.Submit(context.Background(), waiting)
+		assert.NoError(t, err)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, []*dto.MatchRequest{waiting}, <-batched)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// Setup
+	breaker := NewCircuitBreaker(2, 30*time.Second)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	// A single failure keeps the circuit closed.
+	breaker.Failure()
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, BreakerStateClosed, breaker.Status().State)
+
+	// Reaching the threshold opens it.
+	breaker.Failure()
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+	assert.Equal(t, BreakerStateOpen, breaker.Status().State)
+	assert.Equal(t, now, breaker.Status().OpenedAt)
+
+	// After the open timeout one trial call is let through.
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, breaker.Allow())
+	assert.Equal(t, BreakerStateHalfOpen, breaker.Status().State)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// A failed trial reopens the circuit.
+	breaker.Failure()
+	assert.Equal(t, BreakerStateOpen, breaker.Status().State)
+	assert.ErrorIs(t, breaker.Allow(), ErrCircuitOpen)
+
+	// A successful trial closes it.
+	now = now.Add(30 * time.Second)
+	assert.NoError(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, BreakerStateClosed, breaker.Status().State)
+	assert.Equal(t, 0, breaker.Status().ConsecutiveFailures)
+	assert.NoError(t, breaker.Allow())
+}
+
+func TestCircuitBreaker_CancelledTrial(t *testing.T) {
+	// Setup: an open breaker whose timeout has elapsed.
+	breaker := NewCircuitBreaker(1, 30*time.Second)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	breaker.Failure()
+	now = now.Add(30 * time.Second)
+
+	// Execute: the trial call is let through, then abandoned.
+	assert.NoError(t, breaker.Allow())
+	breaker.Cancel()
+
+	// Assert: the next call becomes the trial, and its success closes the circuit.
+	assert.Equal(t, BreakerStateHalfOpen, breaker.Status().State)
+	assert.NoError(t, breaker.Allow())
+	breaker.Success()
+	assert.Equal(t, BreakerStateClosed, breaker.Status().State)
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	breaker := NewCircuitBreaker(0, time.Second)
+
+	for i := 0; i < 10; i++ {
+		breaker.Failure()
+	}
+
+	assert.NoError(t, breaker.Allow())
+}
@@ -7,52 +7,131 @@ import (
 	"fmt"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/metrics"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/requestid"
 )
 
+var tracer = otel.Tracer("github.com/BarkinBalci/bitaksi-case-study/matching/internal/client")
+
+// metricsTarget labels the metrics of calls made by DriverLocationClient.
+const metricsTarget = "driver_location"
+
 type DriverLocationClient struct {
 	baseURL    string
 	apiKey     string
 	httpClient *http.Client
+	opts       Options
+	breaker    *CircuitBreaker
 }
 
-func NewDriverLocationClient(baseURL, apiKey string) *DriverLocationClient {
+func NewDriverLocationClient(baseURL, apiKey string, opts Options) *DriverLocationClient {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
 	return &DriverLocationClient{
 		baseURL: baseURL,
 		apiKey:  apiKey,
 		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout:   opts.Timeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
 		},
+		opts:    opts,
+		breaker: NewCircuitBreaker(opts.BreakerThreshold, opts.BreakerOpenTimeout),
 	}
 }
 
+// BreakerStatus returns the state of the circuit breaker guarding driver-location.
+func (c *DriverLocationClient) BreakerStatus() BreakerStatus {
+	return c.breaker.Status()
+}
+
 type GeoJSONPoint struct {
 	Type        string    `json:"type"`
 	Coordinates []float64 `json:"coordinates"`
 }
 
+type VehicleRequirements struct {
+	VehicleTypes         []string `json:"vehicle_types,omitempty"`
+	MinCapacity          int      `json:"min_capacity,omitempty"`
+	WheelchairAccessible bool     `json:"wheelchair_accessible,omitempty"`
+	PetFriendly          bool     `json:"pet_friendly,omitempty"`
+}
+
 type SearchRequest struct {
-	Location GeoJSONPoint `json:"location"`
-	Radius   float64      `json:"radius"`
+	Location     GeoJSONPoint         `json:"location"`
+	Radius       float64              `json:"radius"`
+	Requirements *VehicleRequirements `json:"requirements,omitempty"`
+	Position     string               `json:"position,omitempty"`
+}
+
+type Vehicle struct {
+	Type                 string `json:"type"`
+	Capacity             int    `json:"capacity"`
+	WheelchairAccessible bool   `json:"wheelchair_accessible"`
+	PetFriendly          bool   `json:"pet_friendly"`
+}
+
+// SearchResultLocation is a driver returned by a search. Driver attributes are
+// optional and left at their zero values when driver-location does not report them.
+type SearchResultLocation struct {
+	ID             string       `json:"id"`
+	Location       GeoJSONPoint `json:"location"`
+	Distance       float64      `json:"distance"`
+	Rating         float64      `json:"rating,omitempty"`
+	IdleSeconds    float64      `json:"idle_seconds,omitempty"`
+	AcceptanceRate float64      `json:"acceptance_rate,omitempty"`
+	Vehicle        *Vehicle     `json:"vehicle,omitempty"`
+	// Heading is in degrees clockwise from north, Speed in meters per second
+	// and Accuracy in meters, each set only when the driver reported it.
+	Heading  *float64 `json:"heading,omitempty"`
+	Speed    *float64 `json:"speed,omitempty"`
+	Accuracy *float64 `json:"accuracy,omitempty"`
+	// SnappedLocation is the location moved onto the road segment identified
+	// by RoadSegmentID, when driver-location has map matching enabled.
+	SnappedLocation *GeoJSONPoint `json:"snapped_location,omitempty"`
+	RoadSegmentID   string        `json:"road_segment_id,omitempty"`
+	// Flags lists the spoofing flags currently raised against the driver.
+	Flags []string `json:"flags,omitempty"`
 }
 
 type SearchResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
-		Locations []struct {
-			ID       string       `json:"id"`
-			Location GeoJSONPoint `json:"location"`
-			Distance float64      `json:"distance"`
-		} `json:"locations"`
+		Locations []SearchResultLocation `json:"locations"`
 	} `json:"data"`
 }
 
-func (c *DriverLocationClient) SearchDrivers(ctx context.Context, lat, lon, radius float64) (*SearchResponse, error) {
+func (c *DriverLocationClient) SearchDrivers(ctx context.Context, lat, lon, radius float64, requirements *VehicleRequirements) (*SearchResponse, error) {
+	ctx, span := tracer.Start(ctx, "DriverLocationClient.SearchDrivers", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	resp, err := c.searchDrivers(ctx, lat, lon, radius, requirements)
+	metrics.ObserveClientCall(metricsTarget, "search", start, errorReason(err))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, errorReason(err))
+	}
+	return resp, err
+}
+
+func (c *DriverLocationClient) searchDrivers(ctx context.Context, lat, lon, radius float64, requirements *VehicleRequirements) (*SearchResponse, error) {
 	searchReq := SearchRequest{
 		Location: GeoJSONPoint{
 			Type:        "Point",
 			Coordinates: []float64{lon, lat},
 		},
-		Radius: radius,
+		Radius:       radius,
+		Requirements: requirements,
+		Position:     c.opts.Position,
 	}
 
 	body, err := json.Marshal(searchReq)
@@ -60,15 +139,18 @@ func (c *DriverLocationClient) SearchDrivers(ctx context.Context, lat, lon, radi
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/locations/search", bytes.NewBuffer(body))
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-API-Key", c.apiKey)
+	// Search only reads, so it is safe to retry and hedge despite being a POST.
+	resp, err := c.do(ctx, true, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/locations/search", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
 
-	resp, err := c.httpClient.Do(req)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", c.apiKey)
+		setRequestID(req)
+		return req, nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -89,3 +171,183 @@ func (c *DriverLocationClient) SearchDrivers(ctx context.Context, lat, lon, radi
 
 	return &result, nil
 }
+
+type CountResponse struct {
+	Success bool `json:"success"`
+	Data    struct {
+		Count int `json:"count"`
+	} `json:"data"`
+}
+
+// CountDrivers returns the number of available drivers within radius meters
+// of the point. Unlike a search, the count is not limited to the drivers a
+// search returns.
+func (c *DriverLocationClient) CountDrivers(ctx context.Context, lat, lon, radius float64) (int, error) {
+	ctx, span := tracer.Start(ctx, "DriverLocationClient.CountDrivers", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	count, err := c.countDrivers(ctx, lat, lon, radius)
+	metrics.ObserveClientCall(metricsTarget, "count", start, errorReason(err))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, errorReason(err))
+	}
+	return count, err
+}
+
+func (c *DriverLocationClient) countDrivers(ctx context.Context, lat, lon, radius float64) (int, error) {
+	countReq := SearchRequest{
+		Location: GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: []float64{lon, lat},
+		},
+		Radius:   radius,
+		Position: c.opts.Position,
+	}
+
+	body, err := json.Marshal(countReq)
+	if err != nil {
+		return 0, err
+	}
+
+	// Count only reads, so it is safe to retry and hedge despite being a POST.
+	resp, err := c.do(ctx, true, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/locations/count", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", c.apiKey)
+		setRequestID(req)
+		return req, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var result CountResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.Data.Count, nil
+}
+
+// PickupPoint is a curated pickup spot returned by a pickup point search.
+type PickupPoint struct {
+	ID       string       `json:"id"`
+	Name     string       `json:"name"`
+	Zone     string       `json:"zone,omitempty"`
+	Location GeoJSONPoint `json:"location"`
+	Distance float64      `json:"distance"`
+}
+
+type PickupPointSearchRequest struct {
+	Location GeoJSONPoint `json:"location"`
+	Radius   float64      `json:"radius"`
+}
+
+type PickupPointSearchResponse struct {
+	Success bool          `json:"success"`
+	Data    []PickupPoint `json:"data"`
+}
+
+// SearchPickupPoints returns the pickup points within radius meters of the
+// point, nearest first.
+func (c *DriverLocationClient) SearchPickupPoints(ctx context.Context, lat, lon, radius float64) ([]PickupPoint, error) {
+	ctx, span := tracer.Start(ctx, "DriverLocationClient.SearchPickupPoints", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
+	start := time.Now()
+	points, err := c.searchPickupPoints(ctx, lat, lon, radius)
+	metrics.ObserveClientCall(metricsTarget, "pickup_points", start, errorReason(err))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, errorReason(err))
+	}
+	return points, err
+}
+
+func (c *DriverLocationClient) searchPickupPoints(ctx context.Context, lat, lon, radius float64) ([]PickupPoint, error) {
+	body, err := json.Marshal(PickupPointSearchRequest{
+		Location: GeoJSONPoint{
+			Type:        "Point",
+			Coordinates: []float64{lon, lat},
+		},
+		Radius: radius,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, true, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/api/v1/pickup-points/search", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-API-Key", c.apiKey)
+		setRequestID(req)
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	var result PickupPointSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// CheckHealth probes the readiness endpoint of driver-location. It bypasses
+// retries and the circuit breaker so the result reflects the current state.
+func (c *DriverLocationClient) CheckHealth(ctx context.Context) error {
+	start := time.Now()
+	err := c.checkHealth(ctx)
+	metrics.ObserveClientCall(metricsTarget, "health", start, errorReason(err))
+	return err
+}
+
+func (c *DriverLocationClient) checkHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health/ready", nil)
+	if err != nil {
+		return err
+	}
+	setRequestID(req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// setRequestID forwards the request ID of the inbound request, if any, so
+// both services log the same ID for a call.
+func setRequestID(req *http.Request) {
+	if id := logging.RequestID(req.Context()); id != "" {
+		req.Header.Set(requestid.Header, id)
+	}
+}
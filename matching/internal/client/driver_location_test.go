@@ -0,0 +1,445 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/requestid"
+)
+
+const searchBody = `{"success":true,"data":{"locations":[{"id":"d1","location":{"type":"Point","coordinates":[29,41]},"distance":120}]}}`
+
+func TestDriverLocationClient_SearchDrivers(t *testing.T) {
+	tests := []struct {
+		name             string
+		maxRetries       int
+		statuses         []int
+		expectedCalls    int32
+		expectedError    bool
+		expectedSuccess  bool
+		expectedBreaker  string
+		breakerThreshold int
+	}{
+		{
+			name:            "success",
+			maxRetries:      2,
+			statuses:        []int{http.StatusOK},
+			expectedCalls:   1,
+			expectedSuccess: true,
+			expectedBreaker: BreakerStateClosed,
+		},
+		{
+			name:            "retries server errors",
+			maxRetries:      2,
+			statuses:        []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			expectedCalls:   3,
+			expectedSuccess: true,
+			expectedBreaker: BreakerStateClosed,
+		},
+		{
+			name:            "gives up after max retries",
+			maxRetries:      1,
+			statuses:        []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+			expectedCalls:   2,
+			expectedError:   true,
+			expectedBreaker: BreakerStateClosed,
+		},
+		{
+			name:            "does not retry client errors",
+			maxRetries:      2,
+			statuses:        []int{http.StatusBadRequest, http.StatusOK},
+			expectedCalls:   1,
+			expectedError:   true,
+			expectedBreaker: BreakerStateClosed,
+		},
+		{
+			name:             "opens the circuit",
+			maxRetries:       3,
+			breakerThreshold: 2,
+			statuses:         []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusOK},
+			expectedCalls:    2,
+			expectedError:    true,
+			expectedBreaker:  BreakerStateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := calls.Add(1)
+				w.WriteHeader(tt.statuses[n-1])
+				_, _ = w.Write([]byte(searchBody))
+			}))
+			defer server.Close()
+
+			threshold := tt.breakerThreshold
+			if threshold == 0 {
+				threshold = 10
+			}
+			c := NewDriverLocationClient(server.URL, "key", Options{
+				MaxRetries:         tt.maxRetries,
+				RetryBaseDelay:     time.Millisecond,
+				RetryMaxDelay:      5 * time.Millisecond,
+				BreakerThreshold:   threshold,
+				BreakerOpenTimeout: time.Minute,
+			})
+
+			// Execute
+			resp, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedSuccess, resp.Success)
+				assert.Len(t, resp.Data.Locations, 1)
+			}
+			assert.Equal(t, tt.expectedCalls, calls.Load())
+			assert.Equal(t, tt.expectedBreaker, c.BreakerStatus().State)
+		})
+	}
+}
+
+func TestDriverLocationClient_FailsFastWhenOpen(t *testing.T) {
+	// Setup
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{
+		BreakerThreshold:   1,
+		BreakerOpenTimeout: time.Minute,
+	})
+
+	// Execute
+	_, firstErr := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+	_, secondErr := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+	// Assert
+	assert.Error(t, firstErr)
+	assert.ErrorIs(t, secondErr, ErrCircuitOpen)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestDriverLocationClient_CancelledTrial(t *testing.T) {
+	// Setup: the first call opens the circuit, the trial stalls until cancelled.
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		switch calls.Add(1) {
+		case 1:
+			w.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			_, _ = w.Write([]byte(searchBody))
+		}
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{
+		BreakerThreshold:   1,
+		BreakerOpenTimeout: time.Millisecond,
+	})
+	_, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+	require.Error(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	// Execute
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, trialErr := c.SearchDrivers(ctx, 41, 29, 1000, nil)
+	resp, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+	// Assert
+	assert.ErrorIs(t, trialErr, context.DeadlineExceeded)
+	require.NoError(t, err)
+	assert.Len(t, resp.Data.Locations, 1)
+	assert.Equal(t, BreakerStateClosed, c.BreakerStatus().State)
+}
+
+func TestDriverLocationClient_Hedging(t *testing.T) {
+	// Setup
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		if calls.Add(1) == 1 {
+			// The first request stalls until the hedge wins and cancels it.
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(searchBody))
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{
+		HedgeDelay: 20 * time.Millisecond,
+	})
+
+	// Execute
+	start := time.Now()
+	resp, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, resp.Data.Locations, 1)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestDriverLocationClient_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{MaxRetries: 2})
+
+	resp, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+	require.NoError(t, err)
+	assert.False(t, resp.Success)
+	assert.Equal(t, BreakerStateClosed, c.BreakerStatus().State)
+}
+
+func TestDriverLocationClient_CheckHealth(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		expectedError bool
+	}{
+		{name: "ready", status: http.StatusOK},
+		{name: "not ready", status: http.StatusServiceUnavailable, expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var gotPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c := NewDriverLocationClient(server.URL, "key", Options{BreakerThreshold: 1, BreakerOpenTimeout: time.Minute})
+
+			// Execute
+			err := c.CheckHealth(context.Background())
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, "/health/ready", gotPath)
+			// Probes do not count towards the circuit breaker.
+			assert.Equal(t, BreakerStateClosed, c.BreakerStatus().State)
+		})
+	}
+}
+
+func TestDriverLocationClient_Backoff(t *testing.T) {
+	c := NewDriverLocationClient("http://localhost", "key", Options{
+		RetryBaseDelay: 100 * time.Millisecond,
+		RetryMaxDelay:  time.Second,
+	})
+
+	tests := []struct {
+		attempt         int
+		expectedCeiling time.Duration
+	}{
+		{attempt: 1, expectedCeiling: 100 * time.Millisecond},
+		{attempt: 2, expectedCeiling: 200 * time.Millisecond},
+		{attempt: 3, expectedCeiling: 400 * time.Millisecond},
+		{attempt: 4, expectedCeiling: 800 * time.Millisecond},
+		{attempt: 5, expectedCeiling: time.Second},
+		{attempt: 40, expectedCeiling: time.Second},
+		{attempt: 100, expectedCeiling: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("attempt %d", tt.attempt), func(t *testing.T) {
+			// Execute
+			ceiling := c.backoffCeiling(tt.attempt)
+
+			// Assert
+			assert.Equal(t, tt.expectedCeiling, ceiling)
+			for range 100 {
+				delay := c.backoff(tt.attempt)
+				assert.GreaterOrEqual(t, delay, time.Duration(0))
+				assert.Less(t, delay, tt.expectedCeiling)
+			}
+		})
+	}
+}
+
+func TestErrorReason(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{name: "success", err: nil, expected: ""},
+		{name: "circuit open", err: ErrCircuitOpen, expected: "circuit_open"},
+		{name: "canceled", err: context.Canceled, expected: "canceled"},
+		{name: "deadline", err: context.DeadlineExceeded, expected: "timeout"},
+		{name: "bad response", err: errors.New("unexpected status: 500"), expected: "response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorReason(tt.err))
+		})
+	}
+}
+
+func TestDriverLocationClient_PropagatesTraceContext(t *testing.T) {
+	// Setup
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+	var gotTraceParent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotTraceParent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(searchBody))
+	}))
+	defer server.Close()
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	c := NewDriverLocationClient(server.URL, "key", Options{})
+
+	// Execute
+	_, err := c.SearchDrivers(ctx, 41, 29, 1000, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Contains(t, gotTraceParent, traceID.String())
+}
+
+func TestDriverLocationClient_ForwardsRequestID(t *testing.T) {
+	// Setup
+	var gotRequestID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRequestID = r.Header.Get(requestid.Header)
+		_, _ = w.Write([]byte(searchBody))
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{})
+	ctx := logging.WithRequestID(context.Background(), "req-123")
+
+	// Execute
+	_, err := c.SearchDrivers(ctx, 41, 29, 1000, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", gotRequestID)
+}
+
+func TestDriverLocationClient_SendsPosition(t *testing.T) {
+	// Setup
+	var got SearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(searchBody))
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{Position: config.DriverPositionSmoothed})
+
+	// Execute
+	_, err := c.SearchDrivers(context.Background(), 41, 29, 1000, nil)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, config.DriverPositionSmoothed, got.Position)
+}
+
+func TestDriverLocationClient_CountDrivers(t *testing.T) {
+	// Setup
+	var got SearchRequest
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"success":true,"data":{"count":250}}`))
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{Position: config.DriverPositionSnapped})
+
+	// Execute
+	count, err := c.CountDrivers(context.Background(), 41, 29, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/locations/count", gotPath)
+	assert.Equal(t, []float64{29, 41}, got.Location.Coordinates)
+	assert.Equal(t, 1000.0, got.Radius)
+	assert.Equal(t, config.DriverPositionSnapped, got.Position)
+	assert.Equal(t, 250, count, "the count is not capped at the search result limit")
+}
+
+func TestDriverLocationClient_SearchPickupPoints(t *testing.T) {
+	// Setup
+	var got PickupPointSearchRequest
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"success":true,"data":[{"id":"p1","name":"Gate 4","zone":"airport","location":{"type":"Point","coordinates":[29.001,41.001]},"distance":14}]}`))
+	}))
+	defer server.Close()
+
+	c := NewDriverLocationClient(server.URL, "key", Options{})
+
+	// Execute
+	points, err := c.SearchPickupPoints(context.Background(), 41, 29, 150)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v1/pickup-points/search", gotPath)
+	assert.Equal(t, []float64{29, 41}, got.Location.Coordinates)
+	assert.Equal(t, 150.0, got.Radius)
+	require.Len(t, points, 1)
+	assert.Equal(t, "p1", points[0].ID)
+	assert.Equal(t, 14.0, points[0].Distance)
+}
@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Options configures timeouts, retries, circuit breaking, hedging and the
+// driver positions searched.
+type Options struct {
+	// Timeout bounds a single HTTP attempt.
+	Timeout time.Duration
+	// MaxRetries is the number of extra attempts for idempotent calls.
+	MaxRetries int
+	// RetryBaseDelay and RetryMaxDelay bound the jittered exponential backoff.
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// BreakerThreshold is the number of consecutive failures that opens the
+	// circuit; zero disables the breaker.
+	BreakerThreshold int
+	// BreakerOpenTimeout is how long the circuit stays open before a trial call.
+	BreakerOpenTimeout time.Duration
+	// HedgeDelay, when positive, sends a second copy of an idempotent request
+	// if the first has not answered within the delay.
+	HedgeDelay time.Duration
+	// Position selects whether drivers are searched by their raw or smoothed
+	// location. Empty leaves the choice to driver-location.
+	Position string
+}
+
+type requestFunc func(ctx context.Context) (*http.Request, error)
+
+// do sends a request, retrying idempotent calls with jittered backoff and
+// failing fast while the circuit is open. The last response is returned even
+// when its status is an error, so callers can report it.
+func (c *DriverLocationClient) do(ctx context.Context, idempotent bool, newRequest requestFunc) (*http.Response, error) {
+	attempts := 1
+	if idempotent {
+		attempts += c.opts.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(ctx, c.backoff(attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.send(ctx, idempotent, newRequest)
+		if ctx.Err() != nil {
+			// The caller gave up; this says nothing about driver-location's health.
+			c.breaker.Cancel()
+			if resp != nil {
+				_ = resp.Body.Close()
+			}
+			return nil, ctx.Err()
+		}
+
+		if err == nil && !retryableStatus(resp.StatusCode) {
+			c.breaker.Success()
+			return resp, nil
+		}
+		c.breaker.Failure()
+
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if attempt == attempts-1 {
+			return resp, nil
+		}
+		lastErr = fmt.Errorf("unexpected status: %d", resp.StatusCode)
+		drain(resp)
+	}
+
+	return nil, lastErr
+}
+
+type hedgeResult struct {
+	index int
+	resp  *http.Response
+	err   error
+}
+
+// send performs one logical attempt. Hedged attempts race a second request
+// against a slow first one and keep whichever answers successfully first.
+func (c *DriverLocationClient) send(ctx context.Context, hedge bool, newRequest requestFunc) (*http.Response, error) {
+	if !hedge || c.opts.HedgeDelay <= 0 {
+		req, err := newRequest(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return c.httpClient.Do(req)
+	}
+
+	results := make(chan hedgeResult, 2)
+	var cancels []context.CancelFunc
+	launch := func() {
+		reqCtx, cancel := context.WithCancel(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+
+		go func() {
+			req, err := newRequest(reqCtx)
+			if err != nil {
+				results <- hedgeResult{index: index, err: err}
+				return
+			}
+			resp, err := c.httpClient.Do(req)
+			results <- hedgeResult{index: index, resp: resp, err: err}
+		}()
+	}
+
+	// finish cancels every request but the winner and cleans up late responses.
+	finish := func(winner int, received int) {
+		for i, cancel := range cancels {
+			if i != winner {
+				cancel()
+			}
+		}
+		go func(pending int) {
+			for ; pending > 0; pending-- {
+				if r := <-results; r.resp != nil {
+					drain(r.resp)
+				}
+			}
+		}(len(cancels) - received)
+	}
+
+	launch()
+	timer := time.NewTimer(c.opts.HedgeDelay)
+	defer timer.Stop()
+
+	var last hedgeResult
+	received := 0
+	for received < len(cancels) {
+		select {
+		case <-timer.C:
+			launch()
+		case r := <-results:
+			received++
+			if r.err == nil && !retryableStatus(r.resp.StatusCode) {
+				if last.resp != nil {
+					drain(last.resp)
+				}
+				finish(r.index, received)
+				r.resp.Body = cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[r.index]}
+				return r.resp, nil
+			}
+			if last.resp != nil {
+				drain(last.resp)
+			}
+			last = r
+		case <-ctx.Done():
+			if last.resp != nil {
+				drain(last.resp)
+			}
+			finish(-1, received)
+			return nil, ctx.Err()
+		}
+	}
+
+	finish(last.index, received)
+	if last.resp == nil {
+		cancels[last.index]()
+		return nil, last.err
+	}
+	last.resp.Body = cancelOnClose{ReadCloser: last.resp.Body, cancel: cancels[last.index]}
+	return last.resp, nil
+}
+
+// backoff returns a random delay in [0, backoffCeiling(attempt)).
+func (c *DriverLocationClient) backoff(attempt int) time.Duration {
+	ceiling := c.backoffCeiling(attempt)
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// backoffCeiling returns min(max, base*2^(attempt-1)) for a retry attempt
+// counted from 1, so the first retry waits up to the base delay.
+func (c *DriverLocationClient) backoffCeiling(attempt int) time.Duration {
+	base, ceiling := c.opts.RetryBaseDelay, c.opts.RetryMaxDelay
+	shift := attempt - 1
+	if base > 0 && shift < 63 && base <= ceiling>>shift {
+		return base << shift
+	}
+	return ceiling
+}
+
+// errorReason classifies a failed call for metrics. It is empty when err is nil.
+func errorReason(err error) string {
+	var netErr net.Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	default:
+		return "response"
+	}
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+// cancelOnClose releases the request context of a hedged request once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
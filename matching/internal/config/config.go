@@ -2,19 +2,84 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/tracing"
 )
 
 // Config holds all application configuration.
 type Config struct {
-	DriverLocationApiKey  string
-	Environment           string
-	SwaggerEnabled        bool
-	DriverLocationBaseURL string
-	SearchRadius          int
-	JWTSecret             string
+	DriverLocationApiKey             string
+	Environment                      string
+	SwaggerEnabled                   bool
+	DriverLocationBaseURL            string
+	SearchRadius                     int
+	JWTSecret                        string
+	JWTPublicKeyFile                 string
+	JWTJWKSURL                       string
+	JWTJWKSRefreshInterval           time.Duration
+	JWTIssuer                        string
+	JWTAudience                      string
+	JWTSigningKeyFile                string
+	JWTSigningKeyID                  string
+	AccessTokenTTL                   time.Duration
+	RefreshTokenTTL                  time.Duration
+	AuthUsersFile                    string
+	ScoringConfigFile                string
+	BatchMatchingEnabled             bool
+	BatchWindow                      time.Duration
+	BatchMaxSize                     int
+	ETAProvider                      string
+	OSRMBaseURL                      string
+	OSRMProfile                      string
+	ETACandidates                    int
+	ETATimeout                       time.Duration
+	RoadGraphFile                    string
+	RoadSnapDistance                 int
+	SurgeCellSize                    float64
+	SurgeDemandWindow                time.Duration
+	SurgeRefreshInterval             time.Duration
+	SurgeSensitivity                 float64
+	SurgeSmoothing                   float64
+	SurgeMaxMultiplier               float64
+	SurgeHistorySize                 int
+	SurgeHistoryCollection           string
+	SurgeHistoryRetention            time.Duration
+	TariffConfigFile                 string
+	AverageSpeedKmh                  float64
+	DriverLocationTimeout            time.Duration
+	DriverLocationMaxRetries         int
+	DriverLocationRetryBaseDelay     time.Duration
+	DriverLocationRetryMaxDelay      time.Duration
+	DriverLocationBreakerThreshold   int
+	DriverLocationBreakerOpenTimeout time.Duration
+	DriverLocationHedgeDelay         time.Duration
+	DriverLocationPosition           string
+	PickupPointsEnabled              bool
+	PickupPointRadius                int
+	HealthCacheTTL                   time.Duration
+	TracingExporter                  string
+	OTLPEndpoint                     string
+	TracingSampleRatio               float64
+	RateLimitEnabled                 bool
+	RateLimitStore                   string
+	RateLimitDefault                 string
+	RateLimitRoutes                  string
+	RedisURL                         string
+	MongoURI                         string
+	MongoDBName                      string
+	IdempotencyCollection            string
+	RefreshTokenCollection           string
+	IdempotencyTTL                   time.Duration
+	IdempotencyMaxBodyBytes          int
+	// TrustedProxies lists the addresses or CIDRs of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers are believed. When it is empty
+	// the client IP is the address of the connection.
+	TrustedProxies []string
 }
 
 // LoadConfig loads configuration from environment variables.
@@ -36,13 +101,221 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
+	jwksRefreshInterval, err := parseDuration(getEnv("JWT_JWKS_REFRESH_INTERVAL", "10m"), "JWT_JWKS_REFRESH_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+
+	accessTokenTTL, err := parseDuration(getEnv("ACCESS_TOKEN_TTL", "15m"), "ACCESS_TOKEN_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTokenTTL, err := parseDuration(getEnv("REFRESH_TOKEN_TTL", "720h"), "REFRESH_TOKEN_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	batchWindow, err := parsePositiveDuration(getEnv("BATCH_WINDOW", "2s"), "BATCH_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	batchMaxSize, err := parsePositiveInt(getEnv("BATCH_MAX_SIZE", "100"), "BATCH_MAX_SIZE")
+	if err != nil {
+		return nil, err
+	}
+
+	etaCandidates, err := parseInt(getEnv("ETA_CANDIDATES", "5"), "ETA_CANDIDATES")
+	if err != nil {
+		return nil, err
+	}
+
+	etaTimeout, err := parseDuration(getEnv("ETA_TIMEOUT", "2s"), "ETA_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	roadSnapDistance, err := parseInt(getEnv("ROAD_SNAP_DISTANCE", "500"), "ROAD_SNAP_DISTANCE")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeCellSize, err := parseFloat(getEnv("SURGE_CELL_SIZE", "0.01"), "SURGE_CELL_SIZE")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeDemandWindow, err := parseDuration(getEnv("SURGE_DEMAND_WINDOW", "5m"), "SURGE_DEMAND_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeRefreshInterval, err := parseDuration(getEnv("SURGE_REFRESH_INTERVAL", "30s"), "SURGE_REFRESH_INTERVAL")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeSensitivity, err := parseFloat(getEnv("SURGE_SENSITIVITY", "0.5"), "SURGE_SENSITIVITY")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeSmoothing, err := parseFraction(getEnv("SURGE_SMOOTHING", "0.5"), "SURGE_SMOOTHING")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeMaxMultiplier, err := parseFloat(getEnv("SURGE_MAX_MULTIPLIER", "3.0"), "SURGE_MAX_MULTIPLIER")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeHistorySize, err := parsePositiveInt(getEnv("SURGE_HISTORY_SIZE", "1000"), "SURGE_HISTORY_SIZE")
+	if err != nil {
+		return nil, err
+	}
+
+	surgeHistoryRetention, err := parsePositiveDuration(getEnv("SURGE_HISTORY_RETENTION", "720h"), "SURGE_HISTORY_RETENTION")
+	if err != nil {
+		return nil, err
+	}
+
+	averageSpeed, err := parsePositiveFloat(getEnv("AVERAGE_SPEED_KMH", "30"), "AVERAGE_SPEED_KMH")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationTimeout, err := parseDuration(getEnv("DRIVER_LOCATION_TIMEOUT", "10s"), "DRIVER_LOCATION_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationMaxRetries, err := parseInt(getEnv("DRIVER_LOCATION_MAX_RETRIES", "2"), "DRIVER_LOCATION_MAX_RETRIES")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationRetryBaseDelay, err := parseDuration(getEnv("DRIVER_LOCATION_RETRY_BASE_DELAY", "100ms"), "DRIVER_LOCATION_RETRY_BASE_DELAY")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationRetryMaxDelay, err := parseDuration(getEnv("DRIVER_LOCATION_RETRY_MAX_DELAY", "1s"), "DRIVER_LOCATION_RETRY_MAX_DELAY")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationBreakerThreshold, err := parseInt(getEnv("DRIVER_LOCATION_BREAKER_THRESHOLD", "5"), "DRIVER_LOCATION_BREAKER_THRESHOLD")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationBreakerOpenTimeout, err := parseDuration(getEnv("DRIVER_LOCATION_BREAKER_OPEN_TIMEOUT", "30s"), "DRIVER_LOCATION_BREAKER_OPEN_TIMEOUT")
+	if err != nil {
+		return nil, err
+	}
+
+	driverLocationHedgeDelay, err := parseDuration(getEnv("DRIVER_LOCATION_HEDGE_DELAY", "0s"), "DRIVER_LOCATION_HEDGE_DELAY")
+	if err != nil {
+		return nil, err
+	}
+
+	pickupPointRadius, err := parseIntRange(getEnv("PICKUP_POINT_RADIUS", "150"), "PICKUP_POINT_RADIUS", 1, MaxPickupPointRadius)
+	if err != nil {
+		return nil, err
+	}
+
+	healthCacheTTL, err := parseDuration(getEnv("HEALTH_CACHE_TTL", "5s"), "HEALTH_CACHE_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	tracingSampleRatio, err := parseFloat(getEnv("TRACING_SAMPLE_RATIO", "1.0"), "TRACING_SAMPLE_RATIO")
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyTTL, err := parseDuration(getEnv("IDEMPOTENCY_TTL", "24h"), "IDEMPOTENCY_TTL")
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyMaxBodyBytes, err := parsePositiveInt(getEnv("IDEMPOTENCY_MAX_BODY_BYTES", "10485760"), "IDEMPOTENCY_MAX_BODY_BYTES")
+	if err != nil {
+		return nil, err
+	}
+
 	cfg := &Config{
-		DriverLocationApiKey:  getEnv("DRIVER_LOCATION_X_API_KEY", ""),
-		Environment:           getEnv("ENVIRONMENT", "development"),
-		SwaggerEnabled:        parseBool(getEnv("SWAGGER_ENABLED", "true")),
-		DriverLocationBaseURL: getEnv("DRIVER_LOCATION_BASE_URL", "http://localhost:8080"),
-		SearchRadius:          searchRadius,
-		JWTSecret:             getEnv("JWT_SECRET", ""),
+		DriverLocationApiKey:             getEnv("DRIVER_LOCATION_X_API_KEY", ""),
+		Environment:                      getEnv("ENVIRONMENT", "development"),
+		SwaggerEnabled:                   parseBool(getEnv("SWAGGER_ENABLED", "true")),
+		DriverLocationBaseURL:            getEnv("DRIVER_LOCATION_BASE_URL", "http://localhost:8080"),
+		SearchRadius:                     searchRadius,
+		JWTSecret:                        os.Getenv("JWT_SECRET"),
+		JWTPublicKeyFile:                 os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWTJWKSURL:                       os.Getenv("JWT_JWKS_URL"),
+		JWTJWKSRefreshInterval:           jwksRefreshInterval,
+		JWTIssuer:                        os.Getenv("JWT_ISSUER"),
+		JWTAudience:                      os.Getenv("JWT_AUDIENCE"),
+		JWTSigningKeyFile:                os.Getenv("JWT_SIGNING_KEY_FILE"),
+		JWTSigningKeyID:                  os.Getenv("JWT_SIGNING_KEY_ID"),
+		AccessTokenTTL:                   accessTokenTTL,
+		RefreshTokenTTL:                  refreshTokenTTL,
+		AuthUsersFile:                    os.Getenv("AUTH_USERS_FILE"),
+		ScoringConfigFile:                os.Getenv("SCORING_CONFIG_FILE"),
+		BatchMatchingEnabled:             parseBool(getEnv("BATCH_MATCHING_ENABLED", "false")),
+		BatchWindow:                      batchWindow,
+		BatchMaxSize:                     batchMaxSize,
+		ETAProvider:                      getEnv("ETA_PROVIDER", ETAProviderNone),
+		OSRMBaseURL:                      getEnv("OSRM_BASE_URL", "http://localhost:5000"),
+		OSRMProfile:                      getEnv("OSRM_PROFILE", "driving"),
+		ETACandidates:                    etaCandidates,
+		ETATimeout:                       etaTimeout,
+		RoadGraphFile:                    os.Getenv("ROAD_GRAPH_FILE"),
+		RoadSnapDistance:                 roadSnapDistance,
+		SurgeCellSize:                    surgeCellSize,
+		SurgeDemandWindow:                surgeDemandWindow,
+		SurgeRefreshInterval:             surgeRefreshInterval,
+		SurgeSensitivity:                 surgeSensitivity,
+		SurgeSmoothing:                   surgeSmoothing,
+		SurgeMaxMultiplier:               surgeMaxMultiplier,
+		SurgeHistorySize:                 surgeHistorySize,
+		SurgeHistoryCollection:           getEnv("SURGE_HISTORY_COLLECTION_NAME", "surge_history"),
+		SurgeHistoryRetention:            surgeHistoryRetention,
+		TariffConfigFile:                 os.Getenv("TARIFF_CONFIG_FILE"),
+		AverageSpeedKmh:                  averageSpeed,
+		DriverLocationTimeout:            driverLocationTimeout,
+		DriverLocationMaxRetries:         driverLocationMaxRetries,
+		DriverLocationRetryBaseDelay:     driverLocationRetryBaseDelay,
+		DriverLocationRetryMaxDelay:      driverLocationRetryMaxDelay,
+		DriverLocationBreakerThreshold:   driverLocationBreakerThreshold,
+		DriverLocationBreakerOpenTimeout: driverLocationBreakerOpenTimeout,
+		DriverLocationHedgeDelay:         driverLocationHedgeDelay,
+		DriverLocationPosition:           getEnv("DRIVER_LOCATION_POSITION", DriverPositionRaw),
+		PickupPointsEnabled:              parseBool(getEnv("PICKUP_POINTS_ENABLED", "false")),
+		PickupPointRadius:                pickupPointRadius,
+		HealthCacheTTL:                   healthCacheTTL,
+		TracingExporter:                  getEnv("TRACING_EXPORTER", tracing.ExporterNone),
+		OTLPEndpoint:                     getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318"),
+		TracingSampleRatio:               tracingSampleRatio,
+		RateLimitEnabled:                 parseBool(getEnv("RATE_LIMIT_ENABLED", "true")),
+		RateLimitStore:                   getEnv("RATE_LIMIT_STORE", RateLimitStoreMemory),
+		RateLimitDefault:                 getEnv("RATE_LIMIT_DEFAULT", "5/s:10"),
+		RateLimitRoutes:                  os.Getenv("RATE_LIMIT_ROUTES"),
+		RedisURL:                         getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		TrustedProxies:                   parseList(os.Getenv("TRUSTED_PROXIES")),
+		MongoURI:                         getEnv("MONGO_URI", ""),
+		MongoDBName:                      getEnv("MONGO_DB_NAME", ""),
+		IdempotencyCollection:            getEnv("IDEMPOTENCY_COLLECTION_NAME", "idempotency_keys"),
+		RefreshTokenCollection:           getEnv("REFRESH_TOKEN_COLLECTION_NAME", "refresh_tokens"),
+		IdempotencyTTL:                   idempotencyTTL,
+		IdempotencyMaxBodyBytes:          idempotencyMaxBodyBytes,
+	}
+
+	// At least one source of token verification keys is required.
+	if cfg.JWTSecret == "" && cfg.JWTPublicKeyFile == "" && cfg.JWTJWKSURL == "" && cfg.JWTSigningKeyFile == "" {
+		missing = append(missing, "JWT_SECRET")
 	}
 
 	if len(missing) > 0 {
@@ -56,6 +329,17 @@ func parseBool(s string) bool {
 	return strings.EqualFold(s, "true")
 }
 
+// parseList splits a comma-separated list, dropping empty entries.
+func parseList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func parseInt(s, fieldName string) (int, error) {
 	v, err := strconv.Atoi(s)
 	if err != nil {
@@ -63,3 +347,81 @@ func parseInt(s, fieldName string) (int, error) {
 	}
 	return v, nil
 }
+
+// parsePositiveInt parses an integer that must be greater than zero.
+func parsePositiveInt(s, fieldName string) (int, error) {
+	v, err := parseInt(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
+
+// parseIntRange parses an integer that must lie between min and max inclusive.
+func parseIntRange(s, fieldName string, min, max int) (int, error) {
+	v, err := parseInt(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("invalid %s value '%s': must be between %d and %d", fieldName, s, min, max)
+	}
+	return v, nil
+}
+
+func parseFloat(s, fieldName string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", fieldName, s, err)
+	}
+	return v, nil
+}
+
+// parsePositiveFloat parses a float that must be greater than zero.
+func parsePositiveFloat(s, fieldName string) (float64, error) {
+	v, err := parseFloat(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	// Written so that NaN is rejected too.
+	if !(v > 0) || math.IsInf(v, 1) {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
+
+// parseFraction parses a float that must be greater than zero and at most one.
+func parseFraction(s, fieldName string) (float64, error) {
+	v, err := parseFloat(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	// Written so that NaN is rejected too.
+	if !(v > 0 && v <= 1) {
+		return 0, fmt.Errorf("invalid %s value '%s': must be greater than 0 and at most 1", fieldName, s)
+	}
+	return v, nil
+}
+
+func parseDuration(s, fieldName string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s value '%s': %w", fieldName, s, err)
+	}
+	return v, nil
+}
+
+// parsePositiveDuration parses a duration that must be greater than zero.
+func parsePositiveDuration(s, fieldName string) (time.Duration, error) {
+	v, err := parseDuration(s, fieldName)
+	if err != nil {
+		return 0, err
+	}
+	if v <= 0 {
+		return 0, fmt.Errorf("invalid %s value '%s': must be positive", fieldName, s)
+	}
+	return v, nil
+}
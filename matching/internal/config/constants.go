@@ -1,8 +1,100 @@
 package config
 
+import "time"
+
+const (
+	ErrUnauthorized        = "Missing or invalid bearer token."
+	ErrTokenExpired        = "Token has expired. Please login again."
+	ErrNoDriverFound       = "No available driver found nearby."
+	ErrUnknownPolicy       = "Unknown scoring policy."
+	ErrUnavailable         = "Driver location service is temporarily unavailable. Please try again later."
+	ErrForbidden           = "You do not have permission to perform this action."
+	ErrInvalidCredentials  = "Invalid credentials."
+	ErrInvalidRefreshToken = "Refresh token is invalid, expired or revoked. Please login again."
+)
+
+const (
+	RoleRider      = "rider"
+	RoleDriver     = "driver"
+	RoleDispatcher = "dispatcher"
+	RoleAdmin      = "admin"
+)
+
+const (
+	PermissionMatchCreate    = "match:create"
+	PermissionMatchOnBehalf  = "match:on_behalf"
+	PermissionFareEstimate   = "fare:estimate"
+	PermissionPricingRead    = "pricing:read"
+	PermissionPricingHistory = "pricing:history"
+)
+
+const (
+	UserIDKey    = "user_id"
+	PrincipalKey = "principal"
+)
+
+const (
+	GrantTypePassword = "password"
+	GrantTypeDevice   = "device"
+	TokenTypeBearer   = "Bearer"
+)
+
+const (
+	BatchResolveTimeout = 10 * time.Second
+)
+
+const (
+	ReadinessTimeout = 2 * time.Second
+	// MinJWTSecretLength is the shortest HS256 key accepted, matching the size of the hash output.
+	MinJWTSecretLength = 32
+)
+
+const (
+	// JWKSMinRefreshInterval limits how often a token with an unknown key ID
+	// or a failing JWKS endpoint can trigger a refresh.
+	JWKSMinRefreshInterval = 30 * time.Second
+	JWKSFetchTimeout       = 5 * time.Second
+	JWKSMaxResponseBytes   = 1 << 20
+)
+
+const (
+	DependencyDriverLocation = "driver_location"
+	DependencyMongoDB        = "mongodb"
+	DependencyJWT            = "jwt"
+)
+
+// MaxPickupPointRadius bounds PICKUP_POINT_RADIUS, in meters, to the largest
+// radius driver-location accepts in a pickup point search.
+const MaxPickupPointRadius = 1000
+
+// Driver positions searched in driver-location.
+const (
+	DriverPositionRaw      = "raw"
+	DriverPositionSmoothed = "smoothed"
+	DriverPositionSnapped  = "snapped"
+)
+
+const (
+	ETAProviderNone  = "none"
+	ETAProviderOSRM  = "osrm"
+	ETAProviderGraph = "graph"
+)
+
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// IdempotentRoutes lists the routes that honour the Idempotency-Key header.
+var IdempotentRoutes = []string{
+	"POST /api/v1/match",
+}
+
+const (
+	ServiceName = "matching"
+)
+
 const (
-	ErrInternalServer = "An unexpected error occurred. Please try again later."
-	ErrUnauthorized   = "Unauthorized. You shall not pass!"
-	ErrTokenExpired   = "Token has expired. Please login again."
-	ErrNotFound       = "Not found."
+	DurationSourceStraightLine = "straight_line"
+	DurationSourceRouting      = "routing"
 )
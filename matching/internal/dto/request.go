@@ -1,10 +1,54 @@
 package dto
 
+import "time"
+
 type GeoJSONPoint struct {
 	Type        string    `json:"type" binding:"required,eq=Point" example:"Point"`
 	Coordinates []float64 `json:"coordinates" binding:"required,len=2" example:"28.9784,41.0082" swaggertype:"array,number"`
 }
 
+type VehicleRequirements struct {
+	VehicleTypes         []string `json:"vehicle_types,omitempty" binding:"omitempty,dive,oneof=taxi comfort xl" example:"taxi,xl"`
+	MinCapacity          int      `json:"min_capacity,omitempty" binding:"omitempty,min=1,max=8" example:"4"`
+	WheelchairAccessible bool     `json:"wheelchair_accessible,omitempty" example:"false"`
+	PetFriendly          bool     `json:"pet_friendly,omitempty" example:"false"`
+}
+
 type MatchRequest struct {
-	Location GeoJSONPoint `json:"location" binding:"required"`
+	// RiderID defaults to the authenticated user. Only dispatchers and admins
+	// may set it to another rider.
+	RiderID      string               `json:"rider_id,omitempty" example:"rider-1"`
+	Location     GeoJSONPoint         `json:"location" binding:"required"`
+	Policy       string               `json:"policy,omitempty" example:"weighted_sum"`
+	Requirements *VehicleRequirements `json:"requirements,omitempty"`
+}
+
+type SurgeRequest struct {
+	Latitude  *float64 `form:"lat" binding:"required,latitude" example:"41.0082"`
+	Longitude *float64 `form:"lon" binding:"required,longitude" example:"28.9784"`
+}
+
+type SurgeHistoryRequest struct {
+	CellID string    `form:"cell" binding:"required" example:"4100:2897"`
+	From   time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type EstimateRequest struct {
+	Pickup  GeoJSONPoint `json:"pickup" binding:"required"`
+	Dropoff GeoJSONPoint `json:"dropoff" binding:"required"`
+}
+
+// TokenRequest signs a rider in with either their username and password or a
+// device ID and secret, depending on GrantType.
+type TokenRequest struct {
+	GrantType    string `json:"grant_type" binding:"required,oneof=password device" example:"password"`
+	Username     string `json:"username,omitempty" binding:"required_if=GrantType password" example:"rider@example.com"`
+	Password     string `json:"password,omitempty" binding:"required_if=GrantType password" example:"correct-horse-battery-staple"`
+	DeviceID     string `json:"device_id,omitempty" binding:"required_if=GrantType device" example:"ios-3f2b8c1e"`
+	DeviceSecret string `json:"device_secret,omitempty" binding:"required_if=GrantType device" example:"device-secret"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"q8G7xPp0n3Y1v0m2b1c4d5e6f7g8h9i0j1k2l3m4n5o"`
 }
@@ -1,21 +1,122 @@
 package dto
 
-type ErrorResponse struct {
-	Success bool   `json:"success"`
-	Error   string `json:"error"`
-}
+import (
+	"time"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse = apierror.ErrorResponse
+
+// FieldError describes why a single request field was rejected.
+type FieldError = apierror.FieldError
+
+// Problem is an RFC 7807 problem details body, returned instead of
+// ErrorResponse when the client accepts application/problem+json.
+type Problem = apierror.Problem
 
 type HealthCheckResponse struct {
-	Status string `json:"status" example:"ok"`
+	Status         string             `json:"status" example:"ok"`
+	Dependencies   []DependencyStatus `json:"dependencies,omitempty"`
+	CircuitBreaker *CircuitBreaker    `json:"circuit_breaker,omitempty"`
+}
+
+// DependencyStatus is the outcome of a readiness check. Cached is set when
+// the result of an earlier probe was reused.
+type DependencyStatus struct {
+	Name      string  `json:"name" example:"driver_location"`
+	Status    string  `json:"status" example:"ok"`
+	LatencyMs float64 `json:"latency_ms" example:"3.21"`
+	Cached    bool    `json:"cached,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// CircuitBreaker reports the state of the breaker guarding calls to driver-location.
+type CircuitBreaker struct {
+	State               string     `json:"state" example:"closed"`
+	ConsecutiveFailures int        `json:"consecutive_failures" example:"0"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
 }
 
 type DriverMatch struct {
-	ID       string       `json:"id"`
+	ID         string       `json:"id"`
+	RiderID    string       `json:"rider_id" example:"rider-1"`
+	Location   GeoJSONPoint `json:"location"`
+	Distance   float64      `json:"distance"`
+	Policy     string       `json:"policy" example:"distance"`
+	ETASeconds *float64     `json:"eta_seconds,omitempty" example:"312.4"`
+	// PickupPoint is the curated pickup point the rider was moved to, if any.
+	PickupPoint *PickupPoint `json:"pickup_point,omitempty"`
+}
+
+// PickupPoint is a curated pickup spot. Distance is how far, in meters, it
+// lies from the location the rider requested.
+type PickupPoint struct {
+	ID       string       `json:"id" example:"5b0e8f3c-2a41-4c55-9d0e-0b8f1e2a7c11"`
+	Name     string       `json:"name" example:"Terminal 1 Gate 4"`
+	Zone     string       `json:"zone,omitempty" example:"airport"`
 	Location GeoJSONPoint `json:"location"`
-	Distance float64      `json:"distance"`
+	Distance float64      `json:"distance" example:"42.5"`
 }
 
 type MatchResponse struct {
 	Success bool         `json:"success"`
 	Data    *DriverMatch `json:"data"`
 }
+
+type Surge struct {
+	CellID     string    `json:"cell_id" example:"4100:2897"`
+	Multiplier float64   `json:"multiplier" example:"1.4"`
+	Demand     int       `json:"demand" example:"12"`
+	Supply     int       `json:"supply" example:"8"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+type SurgeResponse struct {
+	Success bool   `json:"success"`
+	Data    *Surge `json:"data"`
+}
+
+type SurgeHistoryResponse struct {
+	Success bool    `json:"success"`
+	Data    []Surge `json:"data"`
+}
+
+type FareBreakdown struct {
+	BaseFare        float64 `json:"base_fare" example:"50"`
+	DistanceFare    float64 `json:"distance_fare" example:"84.2"`
+	TimeFare        float64 `json:"time_fare" example:"27.6"`
+	Subtotal        float64 `json:"subtotal" example:"161.8"`
+	SurgeMultiplier float64 `json:"surge_multiplier" example:"1.2"`
+	MinimumFare     float64 `json:"minimum_fare" example:"100"`
+	MinimumApplied  bool    `json:"minimum_applied" example:"false"`
+}
+
+type FareEstimate struct {
+	Currency        string        `json:"currency" example:"TRY"`
+	Zone            string        `json:"zone,omitempty" example:"airport"`
+	DistanceMeters  float64       `json:"distance_meters" example:"4210"`
+	DurationSeconds float64       `json:"duration_seconds" example:"552"`
+	DurationSource  string        `json:"duration_source" example:"straight_line" enums:"routing,straight_line"`
+	Breakdown       FareBreakdown `json:"breakdown"`
+	Total           float64       `json:"total" example:"194.16"`
+}
+
+type EstimateResponse struct {
+	Success bool          `json:"success"`
+	Data    *FareEstimate `json:"data"`
+}
+
+type TokenResponse struct {
+	Success bool       `json:"success"`
+	Data    *TokenData `json:"data"`
+}
+
+type TokenData struct {
+	AccessToken      string `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType        string `json:"token_type" example:"Bearer"`
+	ExpiresIn        int    `json:"expires_in" example:"900"`
+	RefreshToken     string `json:"refresh_token" example:"q8G7xPp0n3Y1v0m2b1c4d5e6f7g8h9i0j1k2l3m4n5o"`
+	RefreshExpiresIn int    `json:"refresh_expires_in" example:"2592000"`
+}
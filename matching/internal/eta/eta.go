@@ -0,0 +1,41 @@
+package eta
+
+import (
+	"context"
+	"math"
+)
+
+// Point is a geographic coordinate.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+	// SegmentID is the road segment, as from_id:to_id of the road graph, that
+	// driver-location snapped the point to. Providers that know the segment
+	// start the route on it instead of at the nearest road.
+	SegmentID string
+}
+
+// Route is the fastest drive from an origin to the destination.
+type Route struct {
+	// Duration is in seconds.
+	Duration float64
+	// Distance is the length of the drive in meters.
+	Distance float64
+}
+
+// Unreachable is the route reported for origins with no drive to the
+// destination.
+var Unreachable = Route{Duration: math.Inf(1), Distance: math.Inf(1)}
+
+// Reachable reports whether there is a drive along the route.
+func (r Route) Reachable() bool {
+	return !math.IsInf(r.Duration, 1)
+}
+
+// Provider estimates drives over the road network.
+type Provider interface {
+	// Routes returns the fastest drive from each origin to the destination,
+	// in the same order as origins. Unreachable origins are reported as
+	// Unreachable.
+	Routes(ctx context.Context, origins []Point, destination Point) ([]Route, error)
+}
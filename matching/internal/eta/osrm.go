@@ -0,0 +1,94 @@
+package eta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OSRMProvider computes routes with the table service of an OSRM-compatible
+// HTTP router (OSRM, or Valhalla behind an OSRM-compatible facade).
+type OSRMProvider struct {
+	baseURL    string
+	profile    string
+	httpClient *http.Client
+}
+
+func NewOSRMProvider(baseURL, profile string, timeout time.Duration) *OSRMProvider {
+	return &OSRMProvider{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		profile: profile,
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+type osrmTableResponse struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+}
+
+func (p *OSRMProvider) Routes(ctx context.Context, origins []Point, destination Point) ([]Route, error) {
+	if len(origins) == 0 {
+		return nil, nil
+	}
+
+	coords := make([]string, 0, len(origins)+1)
+	sources := make([]string, len(origins))
+	for i, o := range origins {
+		coords = append(coords, formatCoordinate(o))
+		sources[i] = strconv.Itoa(i)
+	}
+	coords = append(coords, formatCoordinate(destination))
+
+	url := fmt.Sprintf("%s/table/v1/%s/%s?sources=%s&destinations=%d&annotations=duration,distance",
+		p.baseURL, p.profile, strings.Join(coords, ";"), strings.Join(sources, ";"), len(origins))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result osrmTableResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode table response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK || result.Code != "Ok" {
+		return nil, fmt.Errorf("unexpected table response: status %d, code %q: %s", resp.StatusCode, result.Code, result.Message)
+	}
+
+	if len(result.Durations) != len(origins) || len(result.Distances) != len(origins) {
+		return nil, fmt.Errorf("unexpected table size: got %d duration and %d distance rows, want %d",
+			len(result.Durations), len(result.Distances), len(origins))
+	}
+
+	routes := make([]Route, len(origins))
+	for i := range origins {
+		duration, distance := result.Durations[i], result.Distances[i]
+		if len(duration) != 1 || duration[0] == nil || len(distance) != 1 || distance[0] == nil {
+			routes[i] = Unreachable
+			continue
+		}
+		routes[i] = Route{Duration: *duration[0], Distance: *distance[0]}
+	}
+
+	return routes, nil
+}
+
+func formatCoordinate(p Point) string {
+	return strconv.FormatFloat(p.Longitude, 'f', 6, 64) + "," + strconv.FormatFloat(p.Latitude, 'f', 6, 64)
+}
@@ -0,0 +1,86 @@
+package eta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOSRMProvider_Routes(t *testing.T) {
+	origins := []Point{
+		{Latitude: 41.0, Longitude: 29.0},
+		{Latitude: 41.1, Longitude: 29.1},
+	}
+	destination := Point{Latitude: 41.05, Longitude: 29.05}
+
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		expected      []Route
+		expectedError bool
+	}{
+		{
+			name:     "success",
+			status:   http.StatusOK,
+			body:     `{"code":"Ok","durations":[[120.5],[300]],"distances":[[1500],[4200.5]]}`,
+			expected: []Route{{Duration: 120.5, Distance: 1500}, {Duration: 300, Distance: 4200.5}},
+		},
+		{
+			name:     "unreachable origin",
+			status:   http.StatusOK,
+			body:     `{"code":"Ok","durations":[[120.5],[null]],"distances":[[1500],[null]]}`,
+			expected: []Route{{Duration: 120.5, Distance: 1500}, Unreachable},
+		},
+		{
+			name:          "router error",
+			status:        http.StatusBadRequest,
+			body:          `{"code":"InvalidQuery","message":"Query string malformed"}`,
+			expectedError: true,
+		},
+		{
+			name:          "wrong table size",
+			status:        http.StatusOK,
+			body:          `{"code":"Ok","durations":[[120.5]],"distances":[[1500]]}`,
+			expectedError: true,
+		},
+		{
+			name:          "distances missing",
+			status:        http.StatusOK,
+			body:          `{"code":"Ok","durations":[[120.5],[300]]}`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var gotPath, gotQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				gotQuery = r.URL.RawQuery
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			provider := NewOSRMProvider(server.URL+"/", "driving", time.Second)
+
+			// Execute
+			routes, err := provider.Routes(context.Background(), origins, destination)
+
+			// Assert
+			assert.Equal(t, "/table/v1/driving/29.000000,41.000000;29.100000,41.100000;29.050000,41.050000", gotPath)
+			assert.Equal(t, "sources=0;1&destinations=2&annotations=duration,distance", gotQuery)
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, routes)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+)
+
+// EarthRadiusMeters is the mean Earth radius used for great-circle calculations.
+const EarthRadiusMeters = 6371000.0
+
+// Distance returns the great-circle distance between two points in meters.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadiusMeters * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Bearing returns the initial bearing from the first point to the second, in
+// degrees clockwise from north in [0, 360).
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// Zone is a named rectangular area.
+type Zone struct {
+	Name   string  `json:"name"`
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+// Contains reports whether the point lies inside the zone.
+func (z Zone) Contains(lat, lon float64) bool {
+	return lat >= z.MinLat && lat <= z.MaxLat && lon >= z.MinLon && lon <= z.MaxLon
+}
+
+// Zones is an ordered list of zones; earlier zones take precedence on overlap.
+type Zones []Zone
+
+// Locate returns the first zone containing the point.
+func (zs Zones) Locate(lat, lon float64) (Zone, bool) {
+	for _, z := range zs {
+		if z.Contains(lat, lon) {
+			return z, true
+		}
+	}
+	return Zone{}, false
+}
+
+// Cell is a square of a regular latitude/longitude grid.
+type Cell struct {
+	Size float64
+	X    int
+	Y    int
+}
+
+// CellOf returns the grid cell of the given size, in degrees, containing the point.
+func CellOf(lat, lon, size float64) Cell {
+	return Cell{
+		Size: size,
+		X:    int(math.Floor(lon / size)),
+		Y:    int(math.Floor(lat / size)),
+	}
+}
+
+// ID returns a stable identifier for the cell within its grid.
+func (c Cell) ID() string {
+	return fmt.Sprintf("%d:%d", c.Y, c.X)
+}
+
+// Center returns the latitude and longitude of the cell center.
+func (c Cell) Center() (float64, float64) {
+	return (float64(c.Y) + 0.5) * c.Size, (float64(c.X) + 0.5) * c.Size
+}
+
+// Radius returns the distance in meters from the cell center to its corners.
+func (c Cell) Radius() float64 {
+	lat, lon := c.Center()
+	return Distance(lat, lon, lat+c.Size/2, lon+c.Size/2)
+}
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	// One degree of latitude is 1/360 of the Earth's circumference.
+	assert.InDelta(t, 111195, Distance(41.0, 29.0, 42.0, 29.0), 1)
+	assert.Zero(t, Distance(41.0, 29.0, 41.0, 29.0))
+}
+
+func TestBearing(t *testing.T) {
+	assert.InDelta(t, 0, Bearing(41.0, 29.0, 42.0, 29.0), 1e-9)
+	assert.InDelta(t, 180, Bearing(41.0, 29.0, 40.0, 29.0), 1e-9)
+	assert.InDelta(t, 90, Bearing(0, 29.0, 0, 30.0), 1e-9)
+	assert.InDelta(t, 270, Bearing(0, 29.0, 0, 28.0), 1e-9)
+}
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		name       string
+		lat, lon   float64
+		expectedID string
+	}{
+		{name: "positive coordinates", lat: 41.0082, lon: 28.9784, expectedID: "4100:2897"},
+		{name: "negative coordinates", lat: -33.8688, lon: -151.2093, expectedID: "-3387:-15121"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cell := CellOf(tt.lat, tt.lon, 0.01)
+
+			assert.Equal(t, tt.expectedID, cell.ID())
+			lat, lon := cell.Center()
+			assert.Equal(t, cell, CellOf(lat, lon, 0.01))
+		})
+	}
+}
+
+func TestZones_Locate(t *testing.T) {
+	zones := Zones{
+		{Name: "inner", MinLat: 41.0, MinLon: 29.0, MaxLat: 41.1, MaxLon: 29.1},
+		{Name: "outer", MinLat: 40.0, MinLon: 28.0, MaxLat: 42.0, MaxLon: 30.0},
+	}
+
+	zone, ok := zones.Locate(41.05, 29.05)
+	assert.True(t, ok)
+	assert.Equal(t, "inner", zone.Name)
+
+	zone, ok = zones.Locate(40.5, 28.5)
+	assert.True(t, ok)
+	assert.Equal(t, "outer", zone.Name)
+
+	_, ok = zones.Locate(10, 10)
+	assert.False(t, ok)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type AuthHandler struct {
+	tokens auth.TokenService
+	logger *zap.Logger
+}
+
+func NewAuthHandler(tokens auth.TokenService, logger *zap.Logger) *AuthHandler {
+	return &AuthHandler{tokens: tokens, logger: logger}
+}
+
+func (h *AuthHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.POST("/auth/token", h.issueToken)
+	r.POST("/auth/refresh", h.refreshToken)
+	r.POST("/auth/revoke", h.revokeToken)
+}
+
+// @Summary Issue a token
+// @Description Signs a rider in with their username and password, or with a device ID and secret, and returns an access token and a refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.TokenRequest true "Token request"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/auth/token [post]
+func (h *AuthHandler) issueToken(c *gin.Context) {
+	var req dto.TokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	var pair *auth.TokenPair
+	var err error
+	switch req.GrantType {
+	case config.GrantTypeDevice:
+		pair, err = h.tokens.DeviceGrant(c.Request.Context(), req.DeviceID, req.DeviceSecret)
+	default:
+		pair, err = h.tokens.PasswordGrant(c.Request.Context(), req.Username, req.Password)
+	}
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			apierror.Respond(c, apierror.ErrInvalidCredentials)
+			return
+		}
+
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to issue token", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTokenResponse(pair))
+}
+
+// @Summary Refresh a token
+// @Description Exchanges a refresh token for a new access token and refresh token. Each refresh token can be used once; reusing one revokes every token issued from the same sign-in
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body dto.RefreshTokenRequest true "Refresh token request"
+// @Success 200 {object} dto.TokenResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/auth/refresh [post]
+func (h *AuthHandler) refreshToken(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	pair, err := h.tokens.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) {
+			apierror.Respond(c, apierror.ErrInvalidRefreshToken)
+			return
+		}
+
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to refresh token", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTokenResponse(pair))
+}
+
+// @Summary Revoke a refresh token
+// @Description Revokes the refresh token and every token issued from the same sign-in. Unknown tokens are ignored. Access tokens stay valid until they expire
+// @Tags auth
+// @Accept json
+// @Param request body dto.RefreshTokenRequest true "Revoke request"
+// @Success 204
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/auth/revoke [post]
+func (h *AuthHandler) revokeToken(c *gin.Context) {
+	var req dto.RefreshTokenRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	if err := h.tokens.Revoke(c.Request.Context(), req.RefreshToken); err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to revoke token", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func toTokenResponse(pair *auth.TokenPair) dto.TokenResponse {
+	return dto.TokenResponse{
+		Success: true,
+		Data: &dto.TokenData{
+			AccessToken:      pair.AccessToken,
+			TokenType:        config.TokenTypeBearer,
+			ExpiresIn:        int(pair.ExpiresIn.Seconds()),
+			RefreshToken:     pair.RefreshToken,
+			RefreshExpiresIn: int(pair.RefreshExpiresIn.Seconds()),
+		},
+	}
+}
@@ -1,10 +1,12 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/service"
 )
@@ -18,23 +20,47 @@ func NewHealthHandler(service service.Service) *HealthHandler {
 }
 
 func (h *HealthHandler) RegisterRoutes(r *gin.RouterGroup) {
-	r.GET("/health", h.healthCheck)
+	r.GET("/health/live", h.liveness)
+	r.GET("/health/ready", h.readiness)
 }
 
-// @Summary Check if the service is healthy
-// @Description Check if the service is healthy
+// @Summary Check if the service is alive
+// @Description Reports that the process is up, without checking its dependencies
 // @Tags health
 // @Produce json
 // @Success 200 {object} dto.HealthCheckResponse
-// @Router /health [get]
-func (h *HealthHandler) healthCheck(c *gin.Context) {
-	if err := h.service.HealthCheck(c.Request.Context()); err != nil {
-		c.JSON(http.StatusServiceUnavailable, dto.HealthCheckResponse{
-			Status: "unavailable",
-		})
-		return
-	}
+// @Router /health/live [get]
+func (h *HealthHandler) liveness(c *gin.Context) {
 	c.JSON(http.StatusOK, dto.HealthCheckResponse{
 		Status: "ok",
 	})
 }
+
+// @Summary Check if the service is ready
+// @Description Checks driver-location, MongoDB and the JWT configuration, and reports the status and latency of each along with the circuit breaker state
+// @Tags health
+// @Produce json
+// @Success 200 {object} dto.HealthCheckResponse
+// @Failure 503 {object} dto.HealthCheckResponse
+// @Router /health/ready [get]
+func (h *HealthHandler) readiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), config.ReadinessTimeout)
+	defer cancel()
+
+	response := dto.HealthCheckResponse{
+		Status:         "ok",
+		Dependencies:   h.service.CheckReadiness(ctx),
+		CircuitBreaker: h.service.CircuitBreaker(),
+	}
+	for _, dependency := range response.Dependencies {
+		if dependency.Status != "ok" {
+			response.Status = "unavailable"
+		}
+	}
+
+	if response.Status != "ok" {
+		c.JSON(http.StatusServiceUnavailable, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -7,9 +7,14 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/client"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/scoring"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
 type MatchHandler struct {
@@ -22,48 +27,64 @@ func NewMatchHandler(service service.Service, logger *zap.Logger) *MatchHandler
 }
 
 func (h *MatchHandler) RegisterRoutes(r *gin.RouterGroup) {
-	r.POST("/match", h.findNearestDriver)
+	r.POST("/match", middleware.RequirePermission(config.PermissionMatchCreate), h.findNearestDriver)
 }
 
 // @Summary Find nearest driver
-// @Description Finds the nearest available driver for the given rider location
+// @Description Finds the nearest available driver for the given rider location. The rider is the authenticated user; dispatchers and admins may match on behalf of another rider with rider_id. When pickup points are enabled, the rider is moved to the nearest pickup point, which is returned as pickup_point
 // @Tags match
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param request body dto.MatchRequest true "Match request"
+// @Param Idempotency-Key header string false "Replays the first response to retries with the same key"
 // @Success 200 {object} dto.MatchResponse
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 422 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
 // @Router /api/v1/match [post]
 func (h *MatchHandler) findNearestDriver(c *gin.Context) {
 	var req dto.MatchRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	principal := middleware.PrincipalFromContext(c)
+	if req.RiderID == "" {
+		req.RiderID = principal.UserID
+	} else if req.RiderID != principal.UserID && !principal.Can(config.PermissionMatchOnBehalf) {
+		apierror.Respond(c, apierror.ErrForbidden)
 		return
 	}
 
 	match, err := h.service.FindNearestDriver(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, scoring.ErrUnknownPolicy) {
+			apierror.Respond(c, apierror.ErrUnknownPolicy)
+			return
+		}
+
 		if errors.Is(err, service.ErrNoDriverFound) {
-			c.JSON(http.StatusNotFound, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrNotFound,
-			})
+			apierror.Respond(c, apierror.ErrNoDriverFound)
+			return
+		}
+
+		if errors.Is(err, client.ErrCircuitOpen) {
+			apierror.Respond(c, apierror.ErrUnavailable)
 			return
 		}
 
-		h.logger.Error("failed to find nearest driver", zap.Error(err))
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{
-			Success: false,
-			Error:   config.ErrInternalServer,
-		})
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to find nearest driver", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
 		return
 	}
 
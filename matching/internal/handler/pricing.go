@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/client"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/middleware"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/service"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type PricingHandler struct {
+	service service.Service
+	logger  *zap.Logger
+}
+
+func NewPricingHandler(service service.Service, logger *zap.Logger) *PricingHandler {
+	return &PricingHandler{service: service, logger: logger}
+}
+
+func (h *PricingHandler) RegisterRoutes(r *gin.RouterGroup) {
+	r.GET("/pricing/surge", middleware.RequirePermission(config.PermissionPricingRead), h.getSurge)
+	r.GET("/pricing/surge/history", middleware.RequirePermission(config.PermissionPricingHistory), h.getSurgeHistory)
+	r.POST("/estimate", middleware.RequirePermission(config.PermissionFareEstimate), h.estimateFare)
+}
+
+// @Summary Get surge multiplier
+// @Description Returns the current surge multiplier for the pricing cell containing the given point
+// @Tags pricing
+// @Produce json
+// @Security BearerAuth
+// @Param lat query number true "Latitude"
+// @Param lon query number true "Longitude"
+// @Success 200 {object} dto.SurgeResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Failure 503 {object} dto.ErrorResponse
+// @Router /api/v1/pricing/surge [get]
+func (h *PricingHandler) getSurge(c *gin.Context) {
+	var req dto.SurgeRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	surge, err := h.service.GetSurge(c.Request.Context(), *req.Latitude, *req.Longitude)
+	if err != nil {
+		if errors.Is(err, client.ErrCircuitOpen) {
+			apierror.Respond(c, apierror.ErrUnavailable)
+			return
+		}
+
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to get surge", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SurgeResponse{
+		Success: true,
+		Data:    surge,
+	})
+}
+
+// @Summary Get surge history
+// @Description Returns the surge multipliers computed for a pricing cell, optionally within a time range
+// @Tags pricing
+// @Produce json
+// @Security BearerAuth
+// @Param cell query string true "Pricing cell ID"
+// @Param from query string false "Start of the range (RFC 3339)"
+// @Param to query string false "End of the range (RFC 3339)"
+// @Success 200 {object} dto.SurgeHistoryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/pricing/surge/history [get]
+func (h *PricingHandler) getSurgeHistory(c *gin.Context) {
+	var req dto.SurgeHistoryRequest
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	history, err := h.service.GetSurgeHistory(c.Request.Context(), req.CellID, req.From, req.To)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to get surge history", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SurgeHistoryResponse{
+		Success: true,
+		Data:    history,
+	})
+}
+
+// @Summary Estimate a fare
+// @Description Estimates the fare of a trip between a pickup and a drop-off point, including the current surge
+// @Tags pricing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.EstimateRequest true "Estimate request"
+// @Success 200 {object} dto.EstimateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 429 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /api/v1/estimate [post]
+func (h *PricingHandler) estimateFare(c *gin.Context) {
+	var req dto.EstimateRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.RespondBinding(c, err)
+		return
+	}
+
+	estimate, err := h.service.EstimateFare(c.Request.Context(), &req)
+	if err != nil {
+		logging.FromContext(c.Request.Context(), h.logger).Error("failed to estimate fare", zap.Error(err))
+		apierror.Respond(c, apierror.ErrInternal)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.EstimateResponse{
+		Success: true,
+		Data:    estimate,
+	})
+}
@@ -0,0 +1,91 @@
+// Package metrics defines the Prometheus collectors of the service. HTTP
+// metrics share their names and labels with the driver-location service.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	OutcomeOK    = "ok"
+	OutcomeError = "error"
+)
+
+const (
+	MatchOutcomeMatched  = "matched"
+	MatchOutcomeNoDriver = "no_driver"
+	MatchOutcomeRejected = "rejected"
+	MatchOutcomeError    = "error"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Number of HTTP requests handled, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	clientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "client_request_duration_seconds",
+		Help:    "Latency of calls to downstream services, by target, operation and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"target", "operation", "outcome"})
+
+	clientRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "client_request_errors_total",
+		Help: "Number of failed calls to downstream services, by target, operation and reason.",
+	}, []string{"target", "operation", "reason"})
+
+	mongoOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mongo_operation_duration_seconds",
+		Help:    "Latency of MongoDB operations, by operation and outcome.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "outcome"})
+
+	matchRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "match_requests_total",
+		Help: "Number of match requests, by outcome.",
+	}, []string{"outcome"})
+)
+
+// ObserveHTTPRequest records a handled request. route is the route template,
+// not the raw path, to keep the label set bounded.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveClientCall records a call to a downstream service started at start.
+// reason is empty for a successful call and classifies the failure otherwise.
+func ObserveClientCall(target, operation string, start time.Time, reason string) {
+	outcome := OutcomeOK
+	if reason != "" {
+		outcome = OutcomeError
+		clientRequestErrors.WithLabelValues(target, operation, reason).Inc()
+	}
+	clientRequestDuration.WithLabelValues(target, operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+// ObserveMongoOperation records the latency of a MongoDB operation started at start.
+func ObserveMongoOperation(operation string, start time.Time, err error) {
+	outcome := OutcomeOK
+	if err != nil {
+		outcome = OutcomeError
+	}
+	mongoOperationDuration.WithLabelValues(operation, outcome).Observe(time.Since(start).Seconds())
+}
+
+// AddMatch counts a match request with one of the MatchOutcome values.
+func AddMatch(outcome string) {
+	matchRequests.WithLabelValues(outcome).Inc()
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("POST", "/api/v1/match", "404"))
+
+	ObserveHTTPRequest("POST", "/api/v1/match", 404, 5*time.Millisecond)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(httpRequests.WithLabelValues("POST", "/api/v1/match", "404")))
+}
+
+func TestObserveClientCall(t *testing.T) {
+	before := testutil.ToFloat64(clientRequestErrors.WithLabelValues("driver_location", "search", "timeout"))
+
+	ObserveClientCall("driver_location", "search", time.Now(), "")
+	ObserveClientCall("driver_location", "search", time.Now(), "timeout")
+
+	assert.Equal(t, before+1, testutil.ToFloat64(clientRequestErrors.WithLabelValues("driver_location", "search", "timeout")))
+	assert.Equal(t, 2, testutil.CollectAndCount(clientRequestDuration, "client_request_duration_seconds"))
+}
+
+func TestObserveMongoOperation(t *testing.T) {
+	ObserveMongoOperation("test_op", time.Now(), errors.New("failed"))
+
+	assert.Equal(t, 1, testutil.CollectAndCount(mongoOperationDuration, "mongo_operation_duration_seconds"))
+}
+
+func TestAddMatch(t *testing.T) {
+	before := testutil.ToFloat64(matchRequests.WithLabelValues(MatchOutcomeNoDriver))
+
+	AddMatch(MatchOutcomeNoDriver)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(matchRequests.WithLabelValues(MatchOutcomeNoDriver)))
+}
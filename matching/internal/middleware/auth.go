@@ -2,93 +2,108 @@ package middleware
 
 import (
 	"errors"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
-	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
-// JWTAuthMiddleware creates Gin middleware for JWT authentication.
-func JWTAuthMiddleware(cfg config.Config) gin.HandlerFunc {
+var tracer = otel.Tracer("github.com/BarkinBalci/bitaksi-case-study/matching/internal/middleware")
+
+// JWTAuthMiddleware creates Gin middleware for JWT authentication. The
+// principal of the token is stored in the context under PrincipalKey.
+func JWTAuthMiddleware(verifier *auth.Verifier, logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(cfg.JWTSecret), nil
-		}, jwt.WithExpirationRequired())
+		spanCtx, span := tracer.Start(c.Request.Context(), "JWTAuthMiddleware.verify")
+		token, err := verifier.Parse(spanCtx, tokenString)
+		if err != nil {
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
 
 		if err != nil {
 			if errors.Is(err, jwt.ErrTokenExpired) {
-				c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-					Success: false,
-					Error:   config.ErrTokenExpired,
-				})
+				apierror.Respond(c, apierror.ErrTokenExpired)
 				return
 			}
 
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
 		authenticatedVal, exists := claims["authenticated"]
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
 		authenticated, ok := authenticatedVal.(bool)
 		if !ok || !authenticated {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, dto.ErrorResponse{
-				Success: false,
-				Error:   config.ErrUnauthorized,
-			})
+			apierror.Respond(c, apierror.ErrUnauthorized)
 			return
 		}
 
-		if userID, exists := claims["user_id"]; exists {
-			c.Set("user_id", userID)
+		principal, err := auth.NewPrincipal(claims)
+		if err != nil {
+			apierror.Respond(c, apierror.ErrUnauthorized)
+			return
 		}
+
+		c.Set(config.PrincipalKey, principal)
+		c.Set(config.UserIDKey, principal.UserID)
 		c.Set("authenticated", authenticated)
+		ctx := c.Request.Context()
+		userLogger := logging.FromContext(ctx, logger).With(zap.String(config.UserIDKey, principal.UserID))
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, userLogger))
 
 		c.Next()
 	}
 }
+
+// RequirePermission rejects requests whose principal lacks permission. It
+// must run after JWTAuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := PrincipalFromContext(c)
+		if principal == nil || !principal.Can(permission) {
+			apierror.Respond(c, apierror.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PrincipalFromContext returns the principal set by JWTAuthMiddleware, or nil.
+func PrincipalFromContext(c *gin.Context) *auth.Principal {
+	principal, _ := c.Get(config.PrincipalKey)
+	p, _ := principal.(*auth.Principal)
+	return p
+}
@@ -11,7 +11,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
 )
@@ -25,10 +28,14 @@ func createTestToken(secret string, claims jwt.MapClaims) string {
 	return tokenString
 }
 
+func newTestVerifier(t *testing.T) *auth.Verifier {
+	verifier, err := auth.NewVerifier(config.Config{JWTSecret: testJWTSecret}, zap.NewNop())
+	require.NoError(t, err)
+	return verifier
+}
+
 func TestJWTAuthMiddleware(t *testing.T) {
-	cfg := config.Config{
-		JWTSecret: testJWTSecret,
-	}
+	verifier := newTestVerifier(t)
 
 	tests := []struct {
 		name               string
@@ -58,6 +65,9 @@ func TestJWTAuthMiddleware(t *testing.T) {
 				userID, exists := ctx.Get("user_id")
 				assert.True(t, exists)
 				assert.Equal(t, "user123", userID)
+
+				principal := PrincipalFromContext(ctx)
+				assert.Equal(t, []string{config.RoleRider}, principal.Roles, "tokens without roles are riders")
 			},
 		},
 		{
@@ -123,6 +133,19 @@ func TestJWTAuthMiddleware(t *testing.T) {
 			expectedError:      config.ErrUnauthorized,
 			shouldCallNext:     false,
 		},
+		{
+			name: "failure - missing user_id",
+			setupAuth: func() string {
+				claims := jwt.MapClaims{
+					"authenticated": true,
+					"exp":           time.Now().Add(time.Hour).Unix(),
+				}
+				return "Bearer " + createTestToken(testJWTSecret, claims)
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+			expectedError:      config.ErrUnauthorized,
+			shouldCallNext:     false,
+		},
 		{
 			name: "failure - missing expiration",
 			setupAuth: func() string {
@@ -144,7 +167,7 @@ func TestJWTAuthMiddleware(t *testing.T) {
 			authHeader := tt.setupAuth()
 			router := gin.New()
 			nextCalled := false
-			router.Use(JWTAuthMiddleware(cfg))
+			router.Use(JWTAuthMiddleware(verifier, zap.NewNop()))
 			router.GET("/test", func(c *gin.Context) {
 				nextCalled = true
 				if tt.assertContext != nil {
@@ -179,9 +202,7 @@ func TestJWTAuthMiddleware(t *testing.T) {
 func TestJWTAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	cfg := config.Config{
-		JWTSecret: testJWTSecret,
-	}
+	verifier := newTestVerifier(t)
 
 	tests := []struct {
 		name               string
@@ -208,7 +229,7 @@ func TestJWTAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 				return ""
 			},
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedBody:       fmt.Sprintf(`{"success":false,"error":"%s"}`, config.ErrUnauthorized),
+			expectedBody:       fmt.Sprintf(`{"success":false,"code":"unauthorized","error":"%s"}`, config.ErrUnauthorized),
 		},
 	}
 
@@ -216,7 +237,7 @@ func TestJWTAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Setup router
 			router := gin.New()
-			router.Use(JWTAuthMiddleware(cfg))
+			router.Use(JWTAuthMiddleware(verifier, zap.NewNop()))
 			router.GET("/protected", func(c *gin.Context) {
 				userID, _ := c.Get("user_id")
 				c.JSON(http.StatusOK, gin.H{
@@ -240,3 +261,80 @@ func TestJWTAuthMiddleware_IntegrationWithGinRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	verifier := newTestVerifier(t)
+
+	tests := []struct {
+		name               string
+		claims             jwt.MapClaims
+		permission         string
+		expectedStatusCode int
+	}{
+		{
+			name:               "allowed - rider matches",
+			claims:             jwt.MapClaims{},
+			permission:         config.PermissionMatchCreate,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "forbidden - rider reads surge history",
+			claims:             jwt.MapClaims{"roles": []string{config.RoleRider}},
+			permission:         config.PermissionPricingHistory,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "allowed - dispatcher reads surge history",
+			claims:             jwt.MapClaims{"roles": []string{config.RoleDispatcher}},
+			permission:         config.PermissionPricingHistory,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "forbidden - driver matches",
+			claims:             jwt.MapClaims{"roles": "driver"},
+			permission:         config.PermissionMatchCreate,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "allowed - explicit permission claim",
+			claims:             jwt.MapClaims{"roles": []string{config.RoleDriver}, "permissions": []string{config.PermissionFareEstimate}},
+			permission:         config.PermissionFareEstimate,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "forbidden - unknown role",
+			claims:             jwt.MapClaims{"roles": []string{"superuser"}},
+			permission:         config.PermissionPricingRead,
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			claims := jwt.MapClaims{
+				"authenticated": true,
+				"user_id":       "user123",
+				"exp":           time.Now().Add(time.Hour).Unix(),
+			}
+			for k, v := range tt.claims {
+				claims[k] = v
+			}
+			router := gin.New()
+			router.Use(JWTAuthMiddleware(verifier, zap.NewNop()))
+			router.GET("/test", RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+createTestToken(testJWTSecret, claims))
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatusCode, recorder.Code)
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+)
+
+// RateLimitMiddleware limits the requests of each user to each route. Behind
+// JWTAuthMiddleware requests are counted per user_id, on public routes per
+// client IP. Requests are let through when the store fails, so that an
+// unavailable Redis does not take the API down.
+func RateLimitMiddleware(store ratelimit.Store, limits ratelimit.Limits, logger *zap.Logger) gin.HandlerFunc {
+	return ratelimit.Middleware(store, limits, clientID, logger)
+}
+
+// clientID identifies the caller by its user, or by its IP address on public
+// routes.
+func clientID(c *gin.Context) string {
+	if principal := PrincipalFromContext(c); principal != nil {
+		return "user:" + principal.UserID
+	}
+	return "ip:" + c.ClientIP()
+}
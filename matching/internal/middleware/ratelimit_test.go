@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/ratelimit"
+)
+
+// failingStore is a rate limit store whose backend is down.
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+// setupRateLimitRouter authenticates requests carrying X-Test-User as that
+// user and leaves the others anonymous.
+func setupRateLimitRouter(store ratelimit.Store) *gin.Engine {
+	limits := ratelimit.Limits{
+		Default: ratelimit.Limit{Rate: 1, Burst: 2},
+		Routes: map[string]ratelimit.Limit{
+			"POST /match": {Rate: 1, Burst: 1},
+		},
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set(config.PrincipalKey, &auth.Principal{UserID: userID})
+		}
+	})
+	router.Use(RateLimitMiddleware(store, limits, zap.NewNop()))
+	router.POST("/match", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/auth/token", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type request struct {
+		user string
+		path string
+	}
+
+	tests := []struct {
+		name           string
+		requests       []request
+		expectedStatus []int
+	}{
+		{
+			name:           "route limit",
+			requests:       []request{{"u1", "/match"}, {"u1", "/match"}},
+			expectedStatus: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:           "separate buckets per user",
+			requests:       []request{{"u1", "/match"}, {"u2", "/match"}},
+			expectedStatus: []int{http.StatusOK, http.StatusOK},
+		},
+		{
+			name:           "anonymous requests limited per IP",
+			requests:       []request{{"", "/auth/token"}, {"", "/auth/token"}, {"", "/auth/token"}},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := setupRateLimitRouter(ratelimit.NewMemoryStore())
+
+			for i, r := range tt.requests {
+				// Execute
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, r.path, nil)
+				if r.user != "" {
+					req.Header.Set("X-Test-User", r.user)
+				}
+				router.ServeHTTP(recorder, req)
+
+				// Assert
+				assert.Equal(t, tt.expectedStatus[i], recorder.Code, "request %d", i)
+				if recorder.Code == http.StatusTooManyRequests {
+					assert.Equal(t, "1", recorder.Header().Get(ratelimit.RetryAfterHeader))
+					assert.Contains(t, recorder.Body.String(), `"code":"rate_limited"`)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_ForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		expectedStatus []int
+	}{
+		{
+			name:           "forged header ignored without trusted proxies",
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:           "header of a trusted proxy honoured",
+			trustedProxies: []string{"192.0.2.1"},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := setupRateLimitRouter(ratelimit.NewMemoryStore())
+			assert.NoError(t, router.SetTrustedProxies(tt.trustedProxies))
+
+			for i, forwardedFor := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+				// Execute: every request claims a new client IP.
+				recorder := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodPost, "/auth/token", nil)
+				req.RemoteAddr = "192.0.2.1:4000"
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+				router.ServeHTTP(recorder, req)
+
+				// Assert
+				assert.Equal(t, tt.expectedStatus[i], recorder.Code, "request %d", i)
+			}
+		})
+	}
+}
+
+func TestRateLimitMiddleware_StoreUnavailable(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := setupRateLimitRouter(failingStore{})
+
+	// Execute
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/match", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
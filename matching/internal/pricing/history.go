@@ -0,0 +1,138 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/metrics"
+)
+
+// SurgeHistory records the multipliers computed for each cell.
+type SurgeHistory interface {
+	Record(ctx context.Context, surge Surge) error
+	// Find returns the most recent multipliers, up to limit, computed for a
+	// cell within [from, to], oldest first. A zero from or to leaves that side
+	// of the range open.
+	Find(ctx context.Context, cellID string, from, to time.Time, limit int) ([]Surge, error)
+}
+
+// memorySurgeHistory keeps the latest multipliers of each cell in memory.
+type memorySurgeHistory struct {
+	size int
+
+	mu    sync.Mutex
+	cells map[string][]Surge
+}
+
+// NewMemorySurgeHistory creates a history keeping the last size multipliers
+// of each cell. It is lost on restart and not shared between instances.
+func NewMemorySurgeHistory(size int) SurgeHistory {
+	return &memorySurgeHistory{size: size, cells: make(map[string][]Surge)}
+}
+
+func (h *memorySurgeHistory) Record(ctx context.Context, surge Surge) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	history := append(h.cells[surge.CellID], surge)
+	if len(history) > h.size {
+		history = history[len(history)-h.size:]
+	}
+	h.cells[surge.CellID] = history
+	return nil
+}
+
+func (h *memorySurgeHistory) Find(ctx context.Context, cellID string, from, to time.Time, limit int) ([]Surge, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	result := []Surge{}
+	for _, s := range h.cells[cellID] {
+		if !from.IsZero() && s.ComputedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && s.ComputedAt.After(to) {
+			continue
+		}
+		result = append(result, s)
+	}
+	if len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result, nil
+}
+
+// mongoSurgeHistory keeps multipliers in a collection, so that the price
+// decisions survive restarts and are shared by every instance. A TTL index
+// removes them once they are older than the retention.
+type mongoSurgeHistory struct {
+	collection *mongo.Collection
+}
+
+// NewMongoSurgeHistory creates a history keeping multipliers in collection
+// for retention, creating its indexes if needed.
+func NewMongoSurgeHistory(ctx context.Context, collection *mongo.Collection, retention time.Duration) (SurgeHistory, error) {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "cell_id", Value: 1}, {Key: "computed_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "computed_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(retention.Seconds())),
+		},
+	}
+
+	if _, err := collection.Indexes().CreateMany(ctx, indexes); err != nil {
+		return nil, fmt.Errorf("failed to create surge history indexes: %w", err)
+	}
+
+	return &mongoSurgeHistory{collection: collection}, nil
+}
+
+func (h *mongoSurgeHistory) Record(ctx context.Context, surge Surge) error {
+	start := time.Now()
+	_, err := h.collection.InsertOne(ctx, surge)
+	metrics.ObserveMongoOperation("insert_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to insert surge: %w", err)
+	}
+	return nil
+}
+
+func (h *mongoSurgeHistory) Find(ctx context.Context, cellID string, from, to time.Time, limit int) ([]Surge, error) {
+	filter := bson.D{{Key: "cell_id", Value: cellID}}
+	computedAt := bson.D{}
+	if !from.IsZero() {
+		computedAt = append(computedAt, bson.E{Key: "$gte", Value: from})
+	}
+	if !to.IsZero() {
+		computedAt = append(computedAt, bson.E{Key: "$lte", Value: to})
+	}
+	if len(computedAt) > 0 {
+		filter = append(filter, bson.E{Key: "computed_at", Value: computedAt})
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "computed_at", Value: -1}}).SetLimit(int64(limit))
+
+	start := time.Now()
+	cursor, err := h.collection.Find(ctx, filter, opts)
+	if err != nil {
+		metrics.ObserveMongoOperation("find", start, err)
+		return nil, fmt.Errorf("failed to find surge history: %w", err)
+	}
+
+	result := []Surge{}
+	err = cursor.All(ctx, &result)
+	metrics.ObserveMongoOperation("find", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode surge history: %w", err)
+	}
+
+	// The most recent ones were fetched first.
+	slices.Reverse(result)
+	return result, nil
+}
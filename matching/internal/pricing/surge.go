@@ -0,0 +1,173 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+// SupplyCounter counts the drivers currently available around a point.
+type SupplyCounter interface {
+	CountDrivers(ctx context.Context, lat, lon, radius float64) (int, error)
+}
+
+// SurgeConfig tunes how demand and supply are turned into a multiplier.
+type SurgeConfig struct {
+	// CellSize is the edge length of a pricing cell in degrees.
+	CellSize float64
+	// DemandWindow is how far back match requests count as demand.
+	DemandWindow time.Duration
+	// RefreshInterval is how long a computed multiplier is reused.
+	RefreshInterval time.Duration
+	// Sensitivity is the multiplier increase per unit of demand above supply.
+	Sensitivity float64
+	// Smoothing is the weight of a new value against the previous multiplier, in (0, 1].
+	Smoothing float64
+	// MaxMultiplier caps the multiplier.
+	MaxMultiplier float64
+	// HistorySize is the number of past multipliers returned per cell.
+	HistorySize int
+}
+
+// Surge is the multiplier of a pricing cell at a point in time.
+type Surge struct {
+	CellID     string    `bson:"cell_id"`
+	Multiplier float64   `bson:"multiplier"`
+	Demand     int       `bson:"demand"`
+	Supply     int       `bson:"supply"`
+	ComputedAt time.Time `bson:"computed_at"`
+}
+
+// SurgeEngine tracks demand per cell and computes smoothed surge multipliers.
+type SurgeEngine struct {
+	cfg     SurgeConfig
+	supply  SupplyCounter
+	history SurgeHistory
+	logger  *zap.Logger
+	now     func() time.Time
+
+	mu      sync.Mutex
+	demand  map[string][]time.Time
+	current map[string]Surge
+}
+
+// NewSurgeEngine creates a surge engine recording the multipliers it computes
+// in history. A nil history keeps the last HistorySize multipliers of each
+// cell in memory.
+func NewSurgeEngine(cfg SurgeConfig, supply SupplyCounter, history SurgeHistory, logger *zap.Logger) *SurgeEngine {
+	if history == nil {
+		history = NewMemorySurgeHistory(cfg.HistorySize)
+	}
+	return &SurgeEngine{
+		cfg:     cfg,
+		supply:  supply,
+		history: history,
+		logger:  logger,
+		now:     time.Now,
+		demand:  make(map[string][]time.Time),
+		current: make(map[string]Surge),
+	}
+}
+
+// RecordDemand counts a match request at the given point.
+func (e *SurgeEngine) RecordDemand(lat, lon float64) {
+	id := geo.CellOf(lat, lon, e.cfg.CellSize).ID()
+	now := e.now()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.demand[id] = append(e.prune(e.demand[id], now), now)
+}
+
+// Surge returns the multiplier for the cell containing the point, recomputing
+// it when the last value is older than the refresh interval.
+func (e *SurgeEngine) Surge(ctx context.Context, lat, lon float64) (Surge, error) {
+	cell := geo.CellOf(lat, lon, e.cfg.CellSize)
+	id := cell.ID()
+	now := e.now()
+
+	e.mu.Lock()
+	last, ok := e.current[id]
+	if ok && now.Sub(last.ComputedAt) < e.cfg.RefreshInterval {
+		e.mu.Unlock()
+		return last, nil
+	}
+	e.mu.Unlock()
+
+	centerLat, centerLon := cell.Center()
+	supply, err := e.supply.CountDrivers(ctx, centerLat, centerLon, cell.Radius())
+	if err != nil {
+		return Surge{}, fmt.Errorf("failed to count drivers: %w", err)
+	}
+
+	e.mu.Lock()
+	e.demand[id] = e.prune(e.demand[id], now)
+	demand := len(e.demand[id])
+
+	previous := 1.0
+	if last, ok := e.current[id]; ok {
+		previous = last.Multiplier
+	}
+
+	surge := Surge{
+		CellID:     id,
+		Multiplier: e.multiplier(demand, supply, previous),
+		Demand:     demand,
+		Supply:     supply,
+		ComputedAt: now,
+	}
+
+	e.current[id] = surge
+	e.mu.Unlock()
+
+	// A multiplier that cannot be recorded is still applied, so that prices
+	// do not fall back to no surge while the history is unavailable.
+	if err := e.history.Record(ctx, surge); err != nil {
+		logging.FromContext(ctx, e.logger).Error("failed to record surge",
+			zap.Error(err),
+			zap.String("cell_id", id),
+			zap.Float64("multiplier", surge.Multiplier),
+		)
+	}
+
+	return surge, nil
+}
+
+// History returns the most recent HistorySize multipliers computed for a cell
+// within [from, to], oldest first. A zero from or to leaves that side of the
+// range open.
+func (e *SurgeEngine) History(ctx context.Context, cellID string, from, to time.Time) ([]Surge, error) {
+	history, err := e.history.Find(ctx, cellID, from, to, e.cfg.HistorySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find surge history: %w", err)
+	}
+	return history, nil
+}
+
+// multiplier raises the price linearly with excess demand, caps it, and
+// smooths it against the previous value to avoid sudden jumps.
+func (e *SurgeEngine) multiplier(demand, supply int, previous float64) float64 {
+	ratio := float64(demand) / math.Max(float64(supply), 1)
+	target := 1 + e.cfg.Sensitivity*math.Max(0, ratio-1)
+	target = math.Min(target, e.cfg.MaxMultiplier)
+
+	smoothed := previous + e.cfg.Smoothing*(target-previous)
+	return math.Round(math.Max(1, smoothed)*100) / 100
+}
+
+// prune drops demand timestamps that fell out of the demand window.
+func (e *SurgeEngine) prune(timestamps []time.Time, now time.Time) []time.Time {
+	cutoff := now.Add(-e.cfg.DemandWindow)
+	i := 0
+	for i < len(timestamps) && timestamps[i].Before(cutoff) {
+		i++
+	}
+	return timestamps[i:]
+}
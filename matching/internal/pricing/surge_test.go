@@ -0,0 +1,195 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockSupplyCounter struct {
+	mock.Mock
+}
+
+func (m *MockSupplyCounter) CountDrivers(ctx context.Context, lat, lon, radius float64) (int, error) {
+	args := m.Called(ctx, lat, lon, radius)
+	return args.Int(0), args.Error(1)
+}
+
+type MockSurgeHistory struct {
+	mock.Mock
+}
+
+func (m *MockSurgeHistory) Record(ctx context.Context, surge Surge) error {
+	return m.Called(ctx, surge).Error(0)
+}
+
+func (m *MockSurgeHistory) Find(ctx context.Context, cellID string, from, to time.Time, limit int) ([]Surge, error) {
+	args := m.Called(ctx, cellID, from, to, limit)
+	return args.Get(0).([]Surge), args.Error(1)
+}
+
+var testSurgeConfig = SurgeConfig{
+	CellSize:        0.01,
+	DemandWindow:    5 * time.Minute,
+	RefreshInterval: 30 * time.Second,
+	Sensitivity:     0.5,
+	Smoothing:       1,
+	MaxMultiplier:   2.5,
+	HistorySize:     3,
+}
+
+func setupEngine(cfg SurgeConfig) (*SurgeEngine, *MockSupplyCounter, *time.Time) {
+	supply := &MockSupplyCounter{}
+	engine := NewSurgeEngine(cfg, supply, nil, zap.NewNop())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return engine, supply, &now
+}
+
+func TestSurgeEngine_Surge(t *testing.T) {
+	tests := []struct {
+		name               string
+		demand             int
+		supply             int
+		expectedMultiplier float64
+	}{
+		{name: "no demand", demand: 0, supply: 5, expectedMultiplier: 1},
+		{name: "demand equals supply", demand: 5, supply: 5, expectedMultiplier: 1},
+		{name: "demand exceeds supply", demand: 10, supply: 5, expectedMultiplier: 1.5},
+		{name: "no supply", demand: 3, supply: 0, expectedMultiplier: 2},
+		{name: "capped", demand: 50, supply: 1, expectedMultiplier: 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			engine, supply, _ := setupEngine(testSurgeConfig)
+			supply.On("CountDrivers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.supply, nil).Once()
+			for i := 0; i < tt.demand; i++ {
+				engine.RecordDemand(41.0055, 28.9755)
+			}
+
+			// Execute
+			surge, err := engine.Surge(context.Background(), 41.0051, 28.9751)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Equal(t, "4100:2897", surge.CellID)
+			assert.Equal(t, tt.demand, surge.Demand)
+			assert.Equal(t, tt.supply, surge.Supply)
+			assert.Equal(t, tt.expectedMultiplier, surge.Multiplier)
+			supply.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSurgeEngine_SmoothingAndRefresh(t *testing.T) {
+	cfg := testSurgeConfig
+	cfg.Smoothing = 0.5
+	engine, supply, now := setupEngine(cfg)
+	supply.On("CountDrivers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	for i := 0; i < 5; i++ {
+		engine.RecordDemand(41.0, 29.0)
+	}
+
+	// Target is 2.5, so the first value moves halfway from 1.
+	first, err := engine.Surge(context.Background(), 41.0, 29.0)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.75, first.Multiplier)
+
+	// Within the refresh interval the cached value is returned.
+	*now = now.Add(10 * time.Second)
+	cached, err := engine.Surge(context.Background(), 41.0, 29.0)
+	assert.NoError(t, err)
+	assert.Equal(t, first, cached)
+	supply.AssertNumberOfCalls(t, "CountDrivers", 1)
+
+	// After it, the value moves halfway again.
+	*now = now.Add(30 * time.Second)
+	second, err := engine.Surge(context.Background(), 41.0, 29.0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.13, second.Multiplier)
+
+	// Once demand leaves the window the multiplier decays back towards 1.
+	*now = now.Add(10 * time.Minute)
+	third, err := engine.Surge(context.Background(), 41.0, 29.0)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, third.Demand)
+	assert.Equal(t, 1.57, third.Multiplier)
+}
+
+func TestSurgeEngine_SupplyError(t *testing.T) {
+	engine, supply, _ := setupEngine(testSurgeConfig)
+	supply.On("CountDrivers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(0, errors.New("unavailable"))
+
+	_, err := engine.Surge(context.Background(), 41.0, 29.0)
+
+	assert.Error(t, err)
+	history, err := engine.History(context.Background(), "4100:2900", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestSurgeEngine_RecordError(t *testing.T) {
+	// Setup
+	supply := &MockSupplyCounter{}
+	supply.On("CountDrivers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(5, nil).Once()
+	history := &MockSurgeHistory{}
+	history.On("Record", mock.Anything, mock.Anything).Return(errors.New("unavailable")).Once()
+	engine := NewSurgeEngine(testSurgeConfig, supply, history, zap.NewNop())
+
+	// Execute
+	surge, err := engine.Surge(context.Background(), 41.0, 29.0)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, surge.Multiplier)
+	history.AssertExpectations(t)
+}
+
+func TestSurgeEngine_History(t *testing.T) {
+	engine, supply, now := setupEngine(testSurgeConfig)
+	supply.On("CountDrivers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+	start := *now
+
+	for i := 0; i < 4; i++ {
+		_, err := engine.Surge(context.Background(), 41.0, 29.0)
+		assert.NoError(t, err)
+		*now = now.Add(time.Minute)
+	}
+
+	// Only the most recent HistorySize entries are kept.
+	all, err := engine.History(context.Background(), "4100:2900", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	assert.Equal(t, start.Add(time.Minute), all[0].ComputedAt)
+
+	ranged, err := engine.History(context.Background(), "4100:2900", start.Add(2*time.Minute), start.Add(2*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, ranged, 1)
+
+	unknown, err := engine.History(context.Background(), "unknown", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+}
+
+func TestSurgeEngine_HistoryError(t *testing.T) {
+	// Setup
+	history := &MockSurgeHistory{}
+	history.On("Find", mock.Anything, "4100:2900", time.Time{}, time.Time{}, testSurgeConfig.HistorySize).
+		Return([]Surge(nil), errors.New("unavailable")).Once()
+	engine := NewSurgeEngine(testSurgeConfig, &MockSupplyCounter{}, history, zap.NewNop())
+
+	// Execute
+	result, err := engine.History(context.Background(), "4100:2900", time.Time{}, time.Time{})
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	history.AssertExpectations(t)
+}
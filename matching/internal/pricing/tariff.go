@@ -0,0 +1,133 @@
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+// DefaultTariff is used when no tariff file is configured.
+var DefaultTariff = Tariff{
+	BaseFare:    50,
+	PerKm:       20,
+	PerMinute:   3,
+	MinimumFare: 100,
+}
+
+// DefaultCurrency is used when the tariff file does not set one.
+const DefaultCurrency = "TRY"
+
+// Tariff holds the fare components of a zone.
+type Tariff struct {
+	BaseFare    float64 `json:"base_fare"`
+	PerKm       float64 `json:"per_km"`
+	PerMinute   float64 `json:"per_minute"`
+	MinimumFare float64 `json:"minimum_fare"`
+}
+
+// Fare is a priced trip with its components.
+type Fare struct {
+	BaseFare        float64
+	DistanceFare    float64
+	TimeFare        float64
+	Subtotal        float64
+	SurgeMultiplier float64
+	MinimumFare     float64
+	MinimumApplied  bool
+	Total           float64
+}
+
+// Quote prices a trip of the given distance in meters and duration in seconds.
+// The surge multiplier applies to the subtotal, and the minimum fare to the result.
+func (t Tariff) Quote(distanceMeters, durationSeconds, surge float64) Fare {
+	fare := Fare{
+		BaseFare:        round(t.BaseFare),
+		DistanceFare:    round(t.PerKm * distanceMeters / 1000),
+		TimeFare:        round(t.PerMinute * durationSeconds / 60),
+		SurgeMultiplier: surge,
+		MinimumFare:     t.MinimumFare,
+	}
+	fare.Subtotal = round(fare.BaseFare + fare.DistanceFare + fare.TimeFare)
+	fare.Total = round(fare.Subtotal * surge)
+
+	if fare.Total < t.MinimumFare {
+		fare.Total = t.MinimumFare
+		fare.MinimumApplied = true
+	}
+
+	return fare
+}
+
+// TariffZone binds a tariff to a geographic zone.
+type TariffZone struct {
+	geo.Zone
+	Tariff Tariff `json:"tariff"`
+}
+
+// TariffFile is the layout of the tariff configuration file.
+type TariffFile struct {
+	Currency string       `json:"currency"`
+	Default  Tariff       `json:"default"`
+	Zones    []TariffZone `json:"zones"`
+}
+
+// Tariffs resolves the tariff that applies at a pickup point.
+type Tariffs struct {
+	currency string
+	fallback Tariff
+	zones    geo.Zones
+	byZone   map[string]Tariff
+}
+
+// LoadTariffs reads the tariff file at path, or returns the default tariff when path is empty.
+func LoadTariffs(path string) (*Tariffs, error) {
+	if path == "" {
+		return &Tariffs{currency: DefaultCurrency, fallback: DefaultTariff}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tariff file: %w", err)
+	}
+
+	var file TariffFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tariff file: %w", err)
+	}
+
+	t := &Tariffs{
+		currency: file.Currency,
+		fallback: file.Default,
+		byZone:   make(map[string]Tariff, len(file.Zones)),
+	}
+	if t.currency == "" {
+		t.currency = DefaultCurrency
+	}
+	for _, z := range file.Zones {
+		t.zones = append(t.zones, z.Zone)
+		t.byZone[z.Name] = z.Tariff
+	}
+
+	return t, nil
+}
+
+// Currency returns the currency all tariffs are expressed in.
+func (t *Tariffs) Currency() string {
+	return t.currency
+}
+
+// At returns the tariff for a pickup point and the name of its zone, which is
+// empty when the default tariff applies.
+func (t *Tariffs) At(lat, lon float64) (Tariff, string) {
+	if zone, ok := t.zones.Locate(lat, lon); ok {
+		return t.byZone[zone.Name], zone.Name
+	}
+	return t.fallback, ""
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
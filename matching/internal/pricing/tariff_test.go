@@ -0,0 +1,97 @@
+package pricing
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTariff_Quote(t *testing.T) {
+	tariff := Tariff{BaseFare: 50, PerKm: 20, PerMinute: 3, MinimumFare: 100}
+
+	tests := []struct {
+		name             string
+		distanceMeters   float64
+		durationSeconds  float64
+		surge            float64
+		expectedSubtotal float64
+		expectedTotal    float64
+		expectedMinimum  bool
+	}{
+		{
+			name:             "regular trip",
+			distanceMeters:   10000,
+			durationSeconds:  1200,
+			surge:            1,
+			expectedSubtotal: 310,
+			expectedTotal:    310,
+		},
+		{
+			name:             "surge applies to subtotal",
+			distanceMeters:   10000,
+			durationSeconds:  1200,
+			surge:            1.5,
+			expectedSubtotal: 310,
+			expectedTotal:    465,
+		},
+		{
+			name:             "minimum fare",
+			distanceMeters:   500,
+			durationSeconds:  60,
+			surge:            1,
+			expectedSubtotal: 63,
+			expectedTotal:    100,
+			expectedMinimum:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			fare := tariff.Quote(tt.distanceMeters, tt.durationSeconds, tt.surge)
+
+			// Assert
+			assert.Equal(t, tt.expectedSubtotal, fare.Subtotal)
+			assert.Equal(t, tt.expectedTotal, fare.Total)
+			assert.Equal(t, tt.expectedMinimum, fare.MinimumApplied)
+		})
+	}
+}
+
+func TestLoadTariffs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tariffs.json")
+	err := os.WriteFile(path, []byte(`{
+		"currency": "EUR",
+		"default": {"base_fare": 3, "per_km": 1, "per_minute": 0.3, "minimum_fare": 6},
+		"zones": [
+			{"name": "airport", "min_lat": 41.2, "min_lon": 28.7, "max_lat": 41.3, "max_lon": 28.8,
+			 "tariff": {"base_fare": 10, "per_km": 1.2, "per_minute": 0.4, "minimum_fare": 20}}
+		]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	tariffs, err := LoadTariffs(path)
+	require.NoError(t, err)
+	assert.Equal(t, "EUR", tariffs.Currency())
+
+	tariff, zone := tariffs.At(41.25, 28.75)
+	assert.Equal(t, "airport", zone)
+	assert.Equal(t, 10.0, tariff.BaseFare)
+
+	tariff, zone = tariffs.At(41.0, 29.0)
+	assert.Empty(t, zone)
+	assert.Equal(t, 3.0, tariff.BaseFare)
+}
+
+func TestLoadTariffs_Default(t *testing.T) {
+	tariffs, err := LoadTariffs("")
+	require.NoError(t, err)
+
+	tariff, zone := tariffs.At(41.0, 29.0)
+	assert.Empty(t, zone)
+	assert.Equal(t, DefaultTariff, tariff)
+	assert.Equal(t, DefaultCurrency, tariffs.Currency())
+}
@@ -0,0 +1,213 @@
+package routing
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+// cellSize is the edge length, in degrees, of the grid used to find the nearest node.
+const cellSize = 0.01
+
+var ErrEmptyGraph = errors.New("road graph has no edges")
+
+type node struct {
+	lat float64
+	lon float64
+}
+
+type edge struct {
+	to      int
+	seconds float64
+	meters  float64
+}
+
+// segment is a road segment, kept so that routes can start on the segment a
+// driver was snapped to.
+type segment struct {
+	from int
+	to   int
+	// speed is in meters per second.
+	speed  float64
+	oneway bool
+}
+
+type cell struct {
+	x int
+	y int
+}
+
+// Graph is an in-memory road network weighted by travel time.
+type Graph struct {
+	nodes []node
+	// reverse holds incoming edges for each node, so that a single search from
+	// the destination yields travel times from every origin.
+	reverse  [][]edge
+	grid     map[cell][]int
+	segments map[string]segment
+}
+
+// LoadGraph reads a road graph from an edge list file.
+func LoadGraph(path string) (*Graph, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open road graph: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ParseGraph(f)
+}
+
+// ParseGraph reads a road graph from CSV with the header
+//
+//	from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+//
+// where each row is a road segment, typically exported from OpenStreetMap ways.
+// Segments are traversable in both directions unless oneway is true.
+func ParseGraph(r io.Reader) (*Graph, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 8
+
+	if _, err := reader.Read(); err != nil {
+		return nil, fmt.Errorf("failed to read road graph header: %w", err)
+	}
+
+	g := &Graph{grid: make(map[cell][]int), segments: make(map[string]segment)}
+	ids := make(map[string]int)
+
+	nodeIndex := func(id string, lat, lon float64) int {
+		if i, ok := ids[id]; ok {
+			return i
+		}
+		i := len(g.nodes)
+		ids[id] = i
+		g.nodes = append(g.nodes, node{lat: lat, lon: lon})
+		g.reverse = append(g.reverse, nil)
+		c := cellOf(lat, lon)
+		g.grid[c] = append(g.grid[c], i)
+		return i
+	}
+
+	edges := 0
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read road graph: %w", err)
+		}
+
+		values, err := parseFloats(record[1], record[2], record[4], record[5], record[6])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		fromLat, fromLon, toLat, toLon, speed := values[0], values[1], values[2], values[3], values[4]
+		if speed <= 0 {
+			return nil, fmt.Errorf("line %d: speed must be positive", line)
+		}
+
+		from := nodeIndex(record[0], fromLat, fromLon)
+		to := nodeIndex(record[3], toLat, toLon)
+		meters := geo.Distance(fromLat, fromLon, toLat, toLon)
+		seconds := meters / (speed / 3.6)
+		oneway := strings.EqualFold(strings.TrimSpace(record[7]), "true")
+
+		g.reverse[to] = append(g.reverse[to], edge{to: from, seconds: seconds, meters: meters})
+		if !oneway {
+			g.reverse[from] = append(g.reverse[from], edge{to: to, seconds: seconds, meters: meters})
+		}
+		g.segments[SegmentID(record[0], record[3])] = segment{from: from, to: to, speed: speed / 3.6, oneway: oneway}
+		edges++
+	}
+
+	if edges == 0 {
+		return nil, ErrEmptyGraph
+	}
+
+	return g, nil
+}
+
+// SegmentID returns the ID of the segment from one road node to another, as
+// reported by driver-location for drivers snapped to it.
+func SegmentID(fromID, toID string) string {
+	return strings.TrimSpace(fromID) + ":" + strings.TrimSpace(toID)
+}
+
+// Nodes returns the number of nodes in the graph.
+func (g *Graph) Nodes() int {
+	return len(g.nodes)
+}
+
+// nearest returns the node closest to the point and its distance in meters,
+// or -1 when no node lies within maxDistance. It scans grid rings outward and
+// stops once no closer node can exist.
+func (g *Graph) nearest(lat, lon, maxDistance float64) (int, float64) {
+	origin := cellOf(lat, lon)
+	best, bestDist := -1, math.Inf(1)
+
+	for ring := 0; ; ring++ {
+		for _, c := range ringCells(origin, ring) {
+			for _, i := range g.grid[c] {
+				if d := geo.Distance(lat, lon, g.nodes[i].lat, g.nodes[i].lon); d < bestDist {
+					best, bestDist = i, d
+				}
+			}
+		}
+
+		// Every unscanned cell is more than ring cells away along one axis.
+		// Longitude degrees shrink away from the equator, so take the smaller reach.
+		reach := float64(ring) * cellSize
+		minReach := math.Min(
+			geo.Distance(lat, lon, lat+reach, lon),
+			geo.Distance(lat, lon, lat, lon+reach),
+		)
+		if bestDist <= minReach || minReach > maxDistance {
+			break
+		}
+	}
+
+	if bestDist > maxDistance {
+		return -1, bestDist
+	}
+	return best, bestDist
+}
+
+// ringCells returns the cells on the square ring at the given distance from origin.
+func ringCells(origin cell, ring int) []cell {
+	if ring == 0 {
+		return []cell{origin}
+	}
+
+	cells := make([]cell, 0, 8*ring)
+	for x := origin.x - ring; x <= origin.x+ring; x++ {
+		cells = append(cells, cell{x: x, y: origin.y - ring}, cell{x: x, y: origin.y + ring})
+	}
+	for y := origin.y - ring + 1; y <= origin.y+ring-1; y++ {
+		cells = append(cells, cell{x: origin.x - ring, y: y}, cell{x: origin.x + ring, y: y})
+	}
+	return cells
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{x: int(math.Floor(lon / cellSize)), y: int(math.Floor(lat / cellSize))}
+}
+
+func parseFloats(values ...string) ([]float64, error) {
+	result := make([]float64, len(values))
+	for i, v := range values {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %w", v, err)
+		}
+		result[i] = f
+	}
+	return result, nil
+}
@@ -0,0 +1,179 @@
+package routing
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/eta"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+func TestParseGraph(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedNodes int
+		expectedError bool
+	}{
+		{
+			name: "success",
+			input: `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,41.0,29.0,b,41.0,29.01,50,false
+b,41.0,29.01,c,41.01,29.01,50,true`,
+			expectedNodes: 3,
+		},
+		{
+			name:          "failure - no edges",
+			input:         `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway`,
+			expectedError: true,
+		},
+		{
+			name: "failure - invalid coordinate",
+			input: `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,north,29.0,b,41.0,29.01,50,false`,
+			expectedError: true,
+		},
+		{
+			name: "failure - zero speed",
+			input: `from_id,from_lat,from_lon,to_id,to_lat,to_lon,speed_kmh,oneway
+a,41.0,29.0,b,41.0,29.01,0,false`,
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			g, err := ParseGraph(strings.NewReader(tt.input))
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedNodes, g.Nodes())
+		})
+	}
+}
+
+func TestRouter_Routes(t *testing.T) {
+	g, err := LoadGraph("testdata/graph.csv")
+	require.NoError(t, err)
+	router := NewRouter(g, 500)
+
+	a := eta.Point{Latitude: 41.000, Longitude: 29.000}
+	b := eta.Point{Latitude: 41.000, Longitude: 29.010}
+	c := eta.Point{Latitude: 41.010, Longitude: 29.010}
+	d := eta.Point{Latitude: 41.010, Longitude: 29.000}
+	far := eta.Point{Latitude: 40.0, Longitude: 28.0}
+	// Origins halfway along a segment they were snapped to.
+	onDC := eta.Point{Latitude: 41.010, Longitude: 29.005, SegmentID: "d:c"}
+	onAB := eta.Point{Latitude: 41.000, Longitude: 29.005, SegmentID: "a:b"}
+
+	// meters returns the length of a segment.
+	meters := func(from, to eta.Point) float64 {
+		return geo.Distance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	}
+	// seconds returns the travel time of a segment at the given speed.
+	seconds := func(from, to eta.Point, kmh float64) float64 {
+		return meters(from, to) / (kmh / 3.6)
+	}
+	inf := math.Inf(1)
+
+	tests := []struct {
+		name              string
+		origins           []eta.Point
+		destination       eta.Point
+		expected          []float64
+		expectedDistances []float64
+	}{
+		{
+			name:              "fast one-way road is used forwards",
+			origins:           []eta.Point{a},
+			destination:       c,
+			expected:          []float64{seconds(a, d, 36) + seconds(d, c, 72)},
+			expectedDistances: []float64{meters(a, d) + meters(d, c)},
+		},
+		{
+			name:              "one-way road is not used backwards",
+			origins:           []eta.Point{c},
+			destination:       a,
+			expected:          []float64{seconds(c, b, 36) + seconds(b, a, 36)},
+			expectedDistances: []float64{meters(c, b) + meters(b, a)},
+		},
+		{
+			name:              "multiple origins in one search",
+			origins:           []eta.Point{b, d, a},
+			destination:       a,
+			expected:          []float64{seconds(b, a, 36), seconds(d, a, 36), 0},
+			expectedDistances: []float64{meters(b, a), meters(d, a), 0},
+		},
+		{
+			name:              "origin too far from the road network",
+			origins:           []eta.Point{far, b},
+			destination:       a,
+			expected:          []float64{math.Inf(1), seconds(b, a, 36)},
+			expectedDistances: []float64{inf, meters(b, a)},
+		},
+		{
+			name:              "origin drives along its one-way segment",
+			origins:           []eta.Point{onDC},
+			destination:       c,
+			expected:          []float64{seconds(onDC, c, 72)},
+			expectedDistances: []float64{meters(onDC, c)},
+		},
+		{
+			name:              "origin cannot turn back on its one-way segment",
+			origins:           []eta.Point{onDC},
+			destination:       d,
+			expected:          []float64{seconds(onDC, c, 72) + seconds(c, b, 36) + seconds(b, a, 36) + seconds(a, d, 36)},
+			expectedDistances: []float64{meters(onDC, c) + meters(c, b) + meters(b, a) + meters(a, d)},
+		},
+		{
+			name:              "origin drives either way along its two-way segment",
+			origins:           []eta.Point{onAB, onAB},
+			destination:       a,
+			expected:          []float64{seconds(onAB, a, 36), seconds(onAB, a, 36)},
+			expectedDistances: []float64{meters(onAB, a), meters(onAB, a)},
+		},
+		{
+			name:              "unknown segment falls back to the nearest node",
+			origins:           []eta.Point{{Latitude: 41.000, Longitude: 29.000, SegmentID: "x:y"}},
+			destination:       a,
+			expected:          []float64{0},
+			expectedDistances: []float64{0},
+		},
+		{
+			name:              "destination too far from the road network",
+			origins:           []eta.Point{a},
+			destination:       far,
+			expected:          []float64{math.Inf(1)},
+			expectedDistances: []float64{inf},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			routes, err := router.Routes(context.Background(), tt.origins, tt.destination)
+
+			// Assert
+			assert.NoError(t, err)
+			require.Len(t, routes, len(tt.expected))
+			for i := range tt.expected {
+				if math.IsInf(tt.expected[i], 1) {
+					assert.Equal(t, eta.Unreachable, routes[i])
+					continue
+				}
+				assert.InDelta(t, tt.expected[i], routes[i].Duration, 0.001)
+				assert.InDelta(t, tt.expectedDistances[i], routes[i].Distance, 0.001)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package routing
+
+import (
+	"container/heap"
+	"context"
+	"math"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/eta"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+// accessSpeed is the assumed speed, in meters per second, for the leg between
+// a point and the road node it was snapped to.
+const accessSpeed = 5.0
+
+// cancelCheckInterval is how many settled nodes pass between context checks.
+const cancelCheckInterval = 1024
+
+// Router computes the fastest drives over an in-memory Graph. It implements eta.Provider.
+type Router struct {
+	graph           *Graph
+	maxSnapDistance float64
+}
+
+// NewRouter creates a router. Points further than maxSnapDistance meters from
+// any road node are treated as unreachable.
+func NewRouter(graph *Graph, maxSnapDistance float64) *Router {
+	return &Router{
+		graph:           graph,
+		maxSnapDistance: maxSnapDistance,
+	}
+}
+
+// Routes runs a single Dijkstra search backwards from the destination and
+// stops as soon as every origin's road node has been settled. Origins on a
+// known road segment start along it, towards either end it can be driven to.
+// The distance of a route is that of its fastest drive.
+func (r *Router) Routes(ctx context.Context, origins []eta.Point, destination eta.Point) ([]eta.Route, error) {
+	routes := make([]eta.Route, len(origins))
+	for i := range routes {
+		routes[i] = eta.Unreachable
+	}
+
+	target, targetDist := r.graph.nearest(destination.Latitude, destination.Longitude, r.maxSnapDistance)
+	if target < 0 {
+		return routes, nil
+	}
+	arrival := eta.Route{Duration: targetDist / accessSpeed, Distance: targetDist}
+
+	// Group origins by the road nodes they start from.
+	pending := make(map[int][]start)
+	for i, o := range origins {
+		for _, s := range r.starts(o) {
+			s.origin = i
+			pending[s.node] = append(pending[s.node], s)
+		}
+	}
+
+	dist := make([]float64, len(r.graph.nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+	}
+	dist[target] = 0
+
+	queue := &priorityQueue{{node: target}}
+	settled := 0
+	for queue.Len() > 0 && len(pending) > 0 {
+		item := heap.Pop(queue).(queueItem)
+		if item.seconds > dist[item.node] {
+			continue
+		}
+
+		settled++
+		if settled%cancelCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, s := range pending[item.node] {
+			route := eta.Route{
+				Duration: s.access.Duration + item.seconds + arrival.Duration,
+				Distance: s.access.Distance + item.meters + arrival.Distance,
+			}
+			if route.Duration < routes[s.origin].Duration {
+				routes[s.origin] = route
+			}
+		}
+		delete(pending, item.node)
+
+		for _, e := range r.graph.reverse[item.node] {
+			if next := item.seconds + e.seconds; next < dist[e.to] {
+				dist[e.to] = next
+				heap.Push(queue, queueItem{node: e.to, seconds: next, meters: item.meters + e.meters})
+			}
+		}
+	}
+
+	return routes, nil
+}
+
+// start is a road node an origin can reach and the drive to reach it.
+type start struct {
+	origin int
+	node   int
+	access eta.Route
+}
+
+// starts returns the road nodes origin o can start from. Origins on a known
+// segment drive along it; others go to the nearest node at accessSpeed.
+func (r *Router) starts(o eta.Point) []start {
+	if s, ok := r.graph.segments[o.SegmentID]; ok {
+		starts := []start{r.along(o, s.to, s.speed)}
+		if !s.oneway {
+			starts = append(starts, r.along(o, s.from, s.speed))
+		}
+		return starts
+	}
+
+	n, d := r.graph.nearest(o.Latitude, o.Longitude, r.maxSnapDistance)
+	if n < 0 {
+		return nil
+	}
+	return []start{{node: n, access: eta.Route{Duration: d / accessSpeed, Distance: d}}}
+}
+
+// along returns the start at node n reached from o at speed meters per second.
+func (r *Router) along(o eta.Point, n int, speed float64) start {
+	d := geo.Distance(o.Latitude, o.Longitude, r.graph.nodes[n].lat, r.graph.nodes[n].lon)
+	return start{node: n, access: eta.Route{Duration: d / speed, Distance: d}}
+}
+
+type queueItem struct {
+	node    int
+	seconds float64
+	meters  float64
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int           { return len(q) }
+func (q priorityQueue) Less(i, j int) bool { return q[i].seconds < q[j].seconds }
+func (q priorityQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) {
+	*q = append(*q, x.(queueItem))
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
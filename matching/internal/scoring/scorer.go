@@ -0,0 +1,178 @@
+package scoring
+
+import (
+	"math"
+	"sort"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+const (
+	PolicyDistance    = "distance"
+	PolicyWeightedSum = "weighted_sum"
+)
+
+// MaxRating is the upper bound of the driver rating scale.
+const MaxRating = 5.0
+
+// DefaultMaxIdleSeconds caps the idle time that still improves a driver's score.
+const DefaultMaxIdleSeconds = 1800.0
+
+// Heading is only trusted from drivers moving at least MinHeadingSpeed meters
+// per second and at least MinHeadingDistance meters from the rider; below
+// those the direction of travel or to the rider is mostly GPS noise.
+const (
+	MinHeadingSpeed    = 1.0
+	MinHeadingDistance = 50.0
+)
+
+// HeadingDistance is how much closer, in meters, the distance policy counts a
+// driver heading straight at the rider, and how much further one heading
+// straight away, which would have to turn around first.
+const HeadingDistance = 100.0
+
+// FlaggedPenalty is added to the assignment cost of flagged candidates, so that
+// they are only assigned when no other candidate is available.
+const FlaggedPenalty = 1e6
+
+// Candidate is a driver considered for a match.
+type Candidate struct {
+	ID             string
+	Latitude       float64
+	Longitude      float64
+	Distance       float64
+	Rating         float64
+	IdleSeconds    float64
+	AcceptanceRate float64
+	VehicleType    string
+	// ETASeconds is the driving time to the rider, when an ETA provider ranked the candidate.
+	ETASeconds *float64
+	// Flagged is set when the driver sent location updates that look spoofed.
+	Flagged bool
+	// HeadingAlignment is the cosine of the angle between the heading of the
+	// driver and the direction to the rider: 1 when driving straight at the
+	// rider, -1 when driving away and 0 when the heading is unknown.
+	HeadingAlignment float64
+	// RoadSegmentID is the road segment the driver was snapped to, if any,
+	// and RoadLatitude and RoadLongitude the position of the driver on it.
+	RoadSegmentID string
+	RoadLatitude  float64
+	RoadLongitude float64
+}
+
+// HeadingAlignment returns the alignment of a driver at driverLat, driverLon
+// heading in the given direction with the direction to the rider.
+func HeadingAlignment(heading, driverLat, driverLon, riderLat, riderLon float64) float64 {
+	bearing := geo.Bearing(driverLat, driverLon, riderLat, riderLon)
+	return math.Cos((heading - bearing) * math.Pi / 180)
+}
+
+// Scorer assigns a cost to a candidate. Candidates with lower costs rank first.
+type Scorer interface {
+	Name() string
+	Score(c Candidate) float64
+}
+
+// Rank returns the candidates ordered by ascending score, breaking ties by
+// distance. Flagged candidates rank after all others.
+func Rank(scorer Scorer, candidates []Candidate) []Candidate {
+	scores := make(map[string]float64, len(candidates))
+	for _, c := range candidates {
+		scores[c.ID] = scorer.Score(c)
+	}
+
+	ranked := make([]Candidate, len(candidates))
+	copy(ranked, candidates)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Flagged != ranked[j].Flagged {
+			return !ranked[i].Flagged
+		}
+		si, sj := scores[ranked[i].ID], scores[ranked[j].ID]
+		if si != sj {
+			return si < sj
+		}
+		return ranked[i].Distance < ranked[j].Distance
+	})
+	return ranked
+}
+
+// DistanceScorer ranks candidates by straight-line distance, shifted by up to
+// HeadingDistance by their heading alignment.
+type DistanceScorer struct{}
+
+func (DistanceScorer) Name() string {
+	return PolicyDistance
+}
+
+func (DistanceScorer) Score(c Candidate) float64 {
+	return c.Distance - HeadingDistance*c.HeadingAlignment
+}
+
+// Weights configures how much each attribute contributes to a weighted sum score.
+type Weights struct {
+	Distance       float64 `json:"distance"`
+	Rating         float64 `json:"rating"`
+	IdleTime       float64 `json:"idle_time"`
+	AcceptanceRate float64 `json:"acceptance_rate"`
+	// Heading rewards drivers heading toward the rider and penalises those
+	// heading away.
+	Heading float64 `json:"heading"`
+}
+
+// DefaultWeights favours proximity while still rewarding good and long-waiting drivers.
+var DefaultWeights = Weights{
+	Distance:       1.0,
+	Rating:         0.3,
+	IdleTime:       0.2,
+	AcceptanceRate: 0.2,
+	Heading:        0.2,
+}
+
+// WeightedSumScorer combines normalised distance and driver attributes into a single cost.
+type WeightedSumScorer struct {
+	name           string
+	weights        Weights
+	vehicleBonus   map[string]float64
+	maxDistance    float64
+	maxIdleSeconds float64
+}
+
+// NewWeightedSumScorer creates a weighted sum scorer. Distances are normalised by
+// maxDistance and idle times by maxIdleSeconds; vehicleBonus lowers the cost of
+// the listed vehicle types.
+func NewWeightedSumScorer(name string, weights Weights, vehicleBonus map[string]float64, maxDistance, maxIdleSeconds float64) *WeightedSumScorer {
+	if maxIdleSeconds <= 0 {
+		maxIdleSeconds = DefaultMaxIdleSeconds
+	}
+	return &WeightedSumScorer{
+		name:           name,
+		weights:        weights,
+		vehicleBonus:   vehicleBonus,
+		maxDistance:    maxDistance,
+		maxIdleSeconds: maxIdleSeconds,
+	}
+}
+
+func (s *WeightedSumScorer) Name() string {
+	return s.name
+}
+
+func (s *WeightedSumScorer) Score(c Candidate) float64 {
+	distance := c.Distance
+	if s.maxDistance > 0 {
+		distance = clamp(c.Distance / s.maxDistance)
+	}
+
+	score := s.weights.Distance * distance
+	score -= s.weights.Rating * clamp(c.Rating/MaxRating)
+	score -= s.weights.IdleTime * clamp(c.IdleSeconds/s.maxIdleSeconds)
+	score -= s.weights.AcceptanceRate * clamp(c.AcceptanceRate)
+	score -= s.weights.Heading * c.HeadingAlignment
+	score -= s.vehicleBonus[c.VehicleType]
+
+	return score
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,225 @@
+package scoring
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRank(t *testing.T) {
+	candidates := []Candidate{
+		{ID: "far-good", Distance: 4000, Rating: 5, AcceptanceRate: 1, IdleSeconds: 1800},
+		{ID: "near-poor", Distance: 500, Rating: 2, AcceptanceRate: 0.2},
+		{ID: "mid", Distance: 1500, Rating: 4.5, AcceptanceRate: 0.9, IdleSeconds: 600},
+	}
+
+	tests := []struct {
+		name          string
+		scorer        Scorer
+		expectedOrder []string
+	}{
+		{
+			name:          "distance only",
+			scorer:        DistanceScorer{},
+			expectedOrder: []string{"near-poor", "mid", "far-good"},
+		},
+		{
+			name:          "weighted sum with default weights",
+			scorer:        NewWeightedSumScorer(PolicyWeightedSum, DefaultWeights, nil, 8000, 0),
+			expectedOrder: []string{"mid", "far-good", "near-poor"},
+		},
+		{
+			name:          "weighted sum dominated by distance",
+			scorer:        NewWeightedSumScorer("proximity", Weights{Distance: 10, Rating: 0.1}, nil, 8000, 0),
+			expectedOrder: []string{"near-poor", "mid", "far-good"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			ranked := Rank(tt.scorer, candidates)
+
+			// Assert
+			ids := make([]string, len(ranked))
+			for i, c := range ranked {
+				ids[i] = c.ID
+			}
+			assert.Equal(t, tt.expectedOrder, ids)
+		})
+	}
+}
+
+func TestRank_FlaggedLast(t *testing.T) {
+	// Setup
+	candidates := []Candidate{
+		{ID: "near-flagged", Distance: 100, Flagged: true},
+		{ID: "far", Distance: 3000},
+		{ID: "mid", Distance: 1500},
+	}
+
+	// Execute
+	ranked := Rank(DistanceScorer{}, candidates)
+
+	// Assert
+	ids := make([]string, len(ranked))
+	for i, c := range ranked {
+		ids[i] = c.ID
+	}
+	assert.Equal(t, []string{"mid", "far", "near-flagged"}, ids)
+}
+
+func TestRank_HeadingTowardRider(t *testing.T) {
+	tests := []struct {
+		name          string
+		scorer        Scorer
+		candidates    []Candidate
+		expectedOrder []string
+	}{
+		{
+			name:   "weighted sum at the same distance",
+			scorer: NewWeightedSumScorer(PolicyWeightedSum, DefaultWeights, nil, 8000, 0),
+			candidates: []Candidate{
+				{ID: "away", Distance: 1000, HeadingAlignment: -1},
+				{ID: "unknown", Distance: 1000},
+				{ID: "toward", Distance: 1000, HeadingAlignment: 1},
+			},
+			expectedOrder: []string{"toward", "unknown", "away"},
+		},
+		{
+			name:   "distance at the same distance",
+			scorer: DistanceScorer{},
+			candidates: []Candidate{
+				{ID: "away", Distance: 1000, HeadingAlignment: -1},
+				{ID: "unknown", Distance: 1000},
+				{ID: "toward", Distance: 1000, HeadingAlignment: 1},
+			},
+			expectedOrder: []string{"toward", "unknown", "away"},
+		},
+		{
+			name:   "distance prefers a slightly further driver heading toward the rider",
+			scorer: DistanceScorer{},
+			candidates: []Candidate{
+				{ID: "near-away", Distance: 300, HeadingAlignment: -1},
+				{ID: "further-toward", Distance: 450, HeadingAlignment: 1},
+			},
+			expectedOrder: []string{"further-toward", "near-away"},
+		},
+		{
+			name:   "distance still prefers a much closer driver heading away",
+			scorer: DistanceScorer{},
+			candidates: []Candidate{
+				{ID: "near-away", Distance: 300, HeadingAlignment: -1},
+				{ID: "far-toward", Distance: 800, HeadingAlignment: 1},
+			},
+			expectedOrder: []string{"near-away", "far-toward"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			ranked := Rank(tt.scorer, tt.candidates)
+
+			// Assert
+			ids := make([]string, len(ranked))
+			for i, c := range ranked {
+				ids[i] = c.ID
+			}
+			assert.Equal(t, tt.expectedOrder, ids)
+		})
+	}
+}
+
+func TestHeadingAlignment(t *testing.T) {
+	// The rider is due north of the driver.
+	assert.InDelta(t, 1, HeadingAlignment(0, 41.0, 29.0, 41.01, 29.0), 1e-9)
+	assert.InDelta(t, -1, HeadingAlignment(180, 41.0, 29.0, 41.01, 29.0), 1e-9)
+	assert.InDelta(t, 0, HeadingAlignment(90, 41.0, 29.0, 41.01, 29.0), 1e-9)
+}
+
+func TestWeightedSumScorer_VehicleBonus(t *testing.T) {
+	scorer := NewWeightedSumScorer("xl", Weights{Distance: 1}, map[string]float64{"xl": 0.5}, 1000, 0)
+
+	taxi := Candidate{ID: "taxi", Distance: 100, VehicleType: "taxi"}
+	xl := Candidate{ID: "xl", Distance: 400, VehicleType: "xl"}
+
+	assert.Less(t, scorer.Score(xl), scorer.Score(taxi))
+}
+
+func TestSelector_Select(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scoring.json")
+	err := os.WriteFile(path, []byte(`{
+		"default_policy": "weighted_sum",
+		"policies": {
+			"airport": {"type": "weighted_sum", "weights": {"distance": 1, "rating": 1}}
+		},
+		"zones": [
+			{"name": "ist", "min_lat": 41.2, "min_lon": 28.7, "max_lat": 41.3, "max_lon": 28.8, "policy": "airport"}
+		]
+	}`), 0o600)
+	require.NoError(t, err)
+
+	selector, err := LoadSelector(path, 8000)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		policy         string
+		lat, lon       float64
+		expectedPolicy string
+		expectedError  error
+	}{
+		{
+			name:           "default policy outside zones",
+			lat:            41.0,
+			lon:            29.0,
+			expectedPolicy: PolicyWeightedSum,
+		},
+		{
+			name:           "zone policy",
+			lat:            41.25,
+			lon:            28.75,
+			expectedPolicy: "airport",
+		},
+		{
+			name:           "request policy overrides zone",
+			policy:         PolicyDistance,
+			lat:            41.25,
+			lon:            28.75,
+			expectedPolicy: PolicyDistance,
+		},
+		{
+			name:          "unknown policy",
+			policy:        "cheapest",
+			expectedError: ErrUnknownPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			scorer, err := selector.Select(tt.policy, tt.lat, tt.lon)
+
+			// Assert
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedPolicy, scorer.Name())
+		})
+	}
+}
+
+func TestLoadSelector_UnknownZonePolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scoring.json")
+	err := os.WriteFile(path, []byte(`{"zones": [{"name": "x", "policy": "missing"}]}`), 0o600)
+	require.NoError(t, err)
+
+	_, err = LoadSelector(path, 8000)
+	assert.ErrorIs(t, err, ErrUnknownPolicy)
+}
@@ -0,0 +1,127 @@
+package scoring
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+)
+
+var ErrUnknownPolicy = errors.New("unknown scoring policy")
+
+// PolicyConfig describes a named policy in the scoring configuration file.
+type PolicyConfig struct {
+	Type           string             `json:"type"`
+	Weights        Weights            `json:"weights"`
+	VehicleTypes   map[string]float64 `json:"vehicle_types"`
+	MaxIdleSeconds float64            `json:"max_idle_seconds"`
+}
+
+// ZonePolicy binds a scoring policy to a geographic zone.
+type ZonePolicy struct {
+	geo.Zone
+	Policy string `json:"policy"`
+}
+
+// FileConfig is the layout of the scoring configuration file.
+type FileConfig struct {
+	DefaultPolicy string                  `json:"default_policy"`
+	Policies      map[string]PolicyConfig `json:"policies"`
+	Zones         []ZonePolicy            `json:"zones"`
+}
+
+// Selector resolves the scorer to use for a match request.
+type Selector struct {
+	scorers       map[string]Scorer
+	defaultPolicy string
+	zones         geo.Zones
+	zonePolicies  map[string]string
+}
+
+// NewSelector creates a selector with the built-in distance and weighted sum policies.
+func NewSelector(maxDistance float64) *Selector {
+	s := &Selector{
+		scorers:       make(map[string]Scorer),
+		defaultPolicy: PolicyDistance,
+		zonePolicies:  make(map[string]string),
+	}
+	s.Register(DistanceScorer{})
+	s.Register(NewWeightedSumScorer(PolicyWeightedSum, DefaultWeights, nil, maxDistance, DefaultMaxIdleSeconds))
+	return s
+}
+
+// LoadSelector creates a selector and applies the configuration file at path, if any.
+func LoadSelector(path string, maxDistance float64) (*Selector, error) {
+	s := NewSelector(maxDistance)
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scoring config: %w", err)
+	}
+
+	var fileCfg FileConfig
+	if err := json.Unmarshal(data, &fileCfg); err != nil {
+		return nil, fmt.Errorf("failed to parse scoring config: %w", err)
+	}
+
+	for name, p := range fileCfg.Policies {
+		switch p.Type {
+		case PolicyWeightedSum:
+			s.Register(NewWeightedSumScorer(name, p.Weights, p.VehicleTypes, maxDistance, p.MaxIdleSeconds))
+		default:
+			return nil, fmt.Errorf("policy %q has unsupported type %q", name, p.Type)
+		}
+	}
+
+	if fileCfg.DefaultPolicy != "" {
+		if err := s.SetDefault(fileCfg.DefaultPolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, z := range fileCfg.Zones {
+		if _, ok := s.scorers[z.Policy]; !ok {
+			return nil, fmt.Errorf("zone %q: %w: %s", z.Name, ErrUnknownPolicy, z.Policy)
+		}
+		s.zones = append(s.zones, z.Zone)
+		s.zonePolicies[z.Name] = z.Policy
+	}
+
+	return s, nil
+}
+
+// Register adds a scorer under its name, replacing any existing one.
+func (s *Selector) Register(scorer Scorer) {
+	s.scorers[scorer.Name()] = scorer
+}
+
+// SetDefault changes the policy used when neither the request nor a zone selects one.
+func (s *Selector) SetDefault(policy string) error {
+	if _, ok := s.scorers[policy]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPolicy, policy)
+	}
+	s.defaultPolicy = policy
+	return nil
+}
+
+// Select returns the scorer for a request. An explicit policy wins over the
+// zone containing the rider, which wins over the default policy.
+func (s *Selector) Select(policy string, lat, lon float64) (Scorer, error) {
+	if policy == "" {
+		policy = s.defaultPolicy
+		if zone, ok := s.zones.Locate(lat, lon); ok {
+			policy = s.zonePolicies[zone.Name]
+		}
+	}
+
+	scorer, ok := s.scorers[policy]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownPolicy, policy)
+	}
+	return scorer, nil
+}
@@ -4,60 +4,546 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
 
+	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.uber.org/zap"
 
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/assignment"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/auth"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/batch"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/client"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
 	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/eta"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/geo"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/metrics"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/pricing"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/scoring"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
 )
 
 var ErrNoDriverFound = errors.New("no driver found")
 
 type Service interface {
 	FindNearestDriver(ctx context.Context, req *dto.MatchRequest) (*dto.DriverMatch, error)
-	HealthCheck(ctx context.Context) error
+	GetSurge(ctx context.Context, lat, lon float64) (*dto.Surge, error)
+	GetSurgeHistory(ctx context.Context, cellID string, from, to time.Time) ([]dto.Surge, error)
+	EstimateFare(ctx context.Context, req *dto.EstimateRequest) (*dto.FareEstimate, error)
+	CheckReadiness(ctx context.Context) []dto.DependencyStatus
+	CircuitBreaker() *dto.CircuitBreaker
+	Close()
 }
 
 type service struct {
 	driverLocationClient *client.DriverLocationClient
+	scorers              *scoring.Selector
+	etaProvider          eta.Provider
+	surge                *pricing.SurgeEngine
+	tariffs              *pricing.Tariffs
+	verifier             *auth.Verifier
+	database             *mongo.Database
+	batcher              *batch.Batcher
+	driverLocationHealth *healthCache
 	config               *config.Config
 	logger               *zap.Logger
 }
 
-func NewService(driverLocationClient *client.DriverLocationClient, cfg *config.Config, logger *zap.Logger) Service {
-	return &service{
+// NewService creates the matching service. etaProvider may be nil, in which
+// case drivers are ranked by their scores alone.
+func NewService(driverLocationClient *client.DriverLocationClient, scorers *scoring.Selector, etaProvider eta.Provider, surge *pricing.SurgeEngine, tariffs *pricing.Tariffs, verifier *auth.Verifier, database *mongo.Database, cfg *config.Config, logger *zap.Logger) Service {
+	s := &service{
 		driverLocationClient: driverLocationClient,
+		scorers:              scorers,
+		etaProvider:          etaProvider,
+		surge:                surge,
+		tariffs:              tariffs,
+		verifier:             verifier,
+		database:             database,
+		driverLocationHealth: &healthCache{ttl: cfg.HealthCacheTTL},
 		config:               cfg,
 		logger:               logger,
 	}
+
+	if cfg.BatchMatchingEnabled {
+		s.batcher = batch.NewBatcher(cfg.BatchWindow, cfg.BatchMaxSize, config.BatchResolveTimeout, s.matchBatch)
+	}
+
+	return s
+}
+
+// log returns the request-scoped logger of ctx, falling back to the service logger.
+func (s service) log(ctx context.Context) *zap.Logger {
+	return logging.FromContext(ctx, s.logger)
 }
 
 func (s service) FindNearestDriver(ctx context.Context, req *dto.MatchRequest) (*dto.DriverMatch, error) {
+	match, err := s.findNearestDriver(ctx, req)
+
+	switch {
+	case err == nil:
+		metrics.AddMatch(metrics.MatchOutcomeMatched)
+	case errors.Is(err, ErrNoDriverFound):
+		metrics.AddMatch(metrics.MatchOutcomeNoDriver)
+	case errors.Is(err, scoring.ErrUnknownPolicy):
+		metrics.AddMatch(metrics.MatchOutcomeRejected)
+	default:
+		metrics.AddMatch(metrics.MatchOutcomeError)
+	}
+
+	return match, err
+}
+
+func (s service) findNearestDriver(ctx context.Context, req *dto.MatchRequest) (*dto.DriverMatch, error) {
+	req, pickup := s.snapToPickupPoint(ctx, req)
+	lon := req.Location.Coordinates[0]
+	lat := req.Location.Coordinates[1]
+
+	scorer, err := s.scorers.Select(req.Policy, lat, lon)
+	if err != nil {
+		return nil, err
+	}
+
+	s.surge.RecordDemand(lat, lon)
+
+	var match *dto.DriverMatch
+	if s.batcher != nil {
+		match, err = s.batcher.Submit(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		candidates, err := s.searchCandidates(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(candidates) == 0 {
+			return nil, ErrNoDriverFound
+		}
+
+		ranked := s.rankByETA(ctx, lat, lon, scoring.Rank(scorer, candidates))
+		match = toDriverMatch(req, ranked[0], scorer.Name())
+	}
+
+	match.PickupPoint = pickup
+	return match, nil
+}
+
+// snapToPickupPoint moves the rider to the nearest curated pickup point
+// within PickupPointRadius, returning a copy of req located there and the
+// point. The request is returned unchanged, with a nil point, when pickup
+// points are disabled, none is nearby or they cannot be searched.
+func (s service) snapToPickupPoint(ctx context.Context, req *dto.MatchRequest) (*dto.MatchRequest, *dto.PickupPoint) {
+	if !s.config.PickupPointsEnabled {
+		return req, nil
+	}
+
+	lon := req.Location.Coordinates[0]
+	lat := req.Location.Coordinates[1]
+
+	points, err := s.driverLocationClient.SearchPickupPoints(ctx, lat, lon, float64(s.config.PickupPointRadius))
+	if err != nil {
+		s.log(ctx).Warn("failed to search pickup points, matching at the requested location", zap.Error(err))
+		return req, nil
+	}
+	if len(points) == 0 {
+		return req, nil
+	}
+
+	// Points are returned nearest first.
+	p := points[0]
+	snapped := *req
+	snapped.Location = dto.GeoJSONPoint{Type: "Point", Coordinates: p.Location.Coordinates}
+
+	return &snapped, &dto.PickupPoint{
+		ID:       p.ID,
+		Name:     p.Name,
+		Zone:     p.Zone,
+		Location: snapped.Location,
+		Distance: p.Distance,
+	}
+}
+
+func (s service) GetSurge(ctx context.Context, lat, lon float64) (*dto.Surge, error) {
+	surge, err := s.surge.Surge(ctx, lat, lon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute surge: %w", err)
+	}
+
+	result := toSurgeDTO(surge)
+	return &result, nil
+}
+
+func (s service) GetSurgeHistory(ctx context.Context, cellID string, from, to time.Time) ([]dto.Surge, error) {
+	history, err := s.surge.History(ctx, cellID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.Surge, len(history))
+	for i, surge := range history {
+		result[i] = toSurgeDTO(surge)
+	}
+	return result, nil
+}
+
+// EstimateFare prices a trip with the tariff of the pickup zone. The distance
+// and duration are those of the route from the ETA provider when available,
+// and otherwise the straight-line distance driven at the configured average
+// speed.
+func (s service) EstimateFare(ctx context.Context, req *dto.EstimateRequest) (*dto.FareEstimate, error) {
+	pickup := eta.Point{Latitude: req.Pickup.Coordinates[1], Longitude: req.Pickup.Coordinates[0]}
+	dropoff := eta.Point{Latitude: req.Dropoff.Coordinates[1], Longitude: req.Dropoff.Coordinates[0]}
+
+	distance := geo.Distance(pickup.Latitude, pickup.Longitude, dropoff.Latitude, dropoff.Longitude)
+	duration := distance / (s.config.AverageSpeedKmh / 3.6)
+	durationSource := config.DurationSourceStraightLine
+
+	if s.etaProvider != nil {
+		routes, err := s.etaProvider.Routes(ctx, []eta.Point{pickup}, dropoff)
+		switch {
+		case err != nil:
+			s.log(ctx).Warn("failed to compute trip route, using straight-line estimate", zap.Error(err))
+		case routes[0].Reachable():
+			distance = routes[0].Distance
+			duration = routes[0].Duration
+			durationSource = config.DurationSourceRouting
+		}
+	}
+
+	multiplier := 1.0
+	surge, err := s.surge.Surge(ctx, pickup.Latitude, pickup.Longitude)
+	if err != nil {
+		s.log(ctx).Warn("failed to compute surge, estimating without it", zap.Error(err))
+	} else {
+		multiplier = surge.Multiplier
+	}
+
+	tariff, zone := s.tariffs.At(pickup.Latitude, pickup.Longitude)
+	fare := tariff.Quote(distance, duration, multiplier)
+
+	return &dto.FareEstimate{
+		Currency:        s.tariffs.Currency(),
+		Zone:            zone,
+		DistanceMeters:  math.Round(distance),
+		DurationSeconds: math.Round(duration),
+		DurationSource:  durationSource,
+		Breakdown: dto.FareBreakdown{
+			BaseFare:        fare.BaseFare,
+			DistanceFare:    fare.DistanceFare,
+			TimeFare:        fare.TimeFare,
+			Subtotal:        fare.Subtotal,
+			SurgeMultiplier: fare.SurgeMultiplier,
+			MinimumFare:     fare.MinimumFare,
+			MinimumApplied:  fare.MinimumApplied,
+		},
+		Total: fare.Total,
+	}, nil
+}
+
+// CheckReadiness reports whether driver-location and MongoDB are reachable
+// and tokens can be verified. The driver-location probe is cached for HealthCacheTTL so
+// that frequent probes do not load it.
+func (s service) CheckReadiness(ctx context.Context) []dto.DependencyStatus {
+	return []dto.DependencyStatus{
+		s.driverLocationHealth.get(func() dto.DependencyStatus {
+			return probe(config.DependencyDriverLocation, func() error {
+				return s.driverLocationClient.CheckHealth(ctx)
+			})
+		}),
+		probe(config.DependencyMongoDB, func() error {
+			return s.database.Client().Ping(ctx, nil)
+		}),
+		probe(config.DependencyJWT, func() error {
+			return s.verifier.Check(ctx)
+		}),
+	}
+}
+
+// healthCache holds the last result of a dependency probe.
+type healthCache struct {
+	ttl       time.Duration
+	mu        sync.Mutex
+	checkedAt time.Time
+	status    dto.DependencyStatus
+}
+
+// get returns the cached status, or runs check when it is older than ttl.
+// Concurrent callers wait for a single check instead of probing in parallel.
+func (c *healthCache) get(check func() dto.DependencyStatus) dto.DependencyStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checkedAt.IsZero() && time.Since(c.checkedAt) < c.ttl {
+		status := c.status
+		status.Cached = true
+		return status
+	}
+
+	c.status = check()
+	c.checkedAt = time.Now()
+	return c.status
+}
+
+func probe(name string, check func() error) dto.DependencyStatus {
+	start := time.Now()
+	err := check()
+
+	status := dto.DependencyStatus{
+		Name:      name,
+		Status:    "ok",
+		LatencyMs: math.Round(float64(time.Since(start).Microseconds())/10) / 100,
+	}
+	if err != nil {
+		status.Status = "unavailable"
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// CircuitBreaker returns the state of the breaker guarding driver-location calls.
+func (s service) CircuitBreaker() *dto.CircuitBreaker {
+	status := s.driverLocationClient.BreakerStatus()
+
+	breaker := &dto.CircuitBreaker{
+		State:               status.State,
+		ConsecutiveFailures: status.ConsecutiveFailures,
+	}
+	if !status.OpenedAt.IsZero() {
+		breaker.OpenedAt = &status.OpenedAt
+	}
+
+	return breaker
+}
+
+// Close flushes pending batched requests and stops the batcher, if enabled.
+func (s service) Close() {
+	if s.batcher != nil {
+		s.batcher.Close()
+	}
+}
+
+// matchBatch assigns drivers to a batch of riders so that the total time
+// drivers take to reach them is minimal, rather than giving each rider its own
+// best driver. The policy of each rider decides which of its candidates are
+// routed when there is an ETA provider.
+func (s service) matchBatch(ctx context.Context, requests []*dto.MatchRequest) []batch.Result {
+	results := make([]batch.Result, len(requests))
+	scorers := make([]scoring.Scorer, len(requests))
+	candidates := make([][]scoring.Candidate, len(requests))
+
+	var wg sync.WaitGroup
+	for i, req := range requests {
+		scorer, err := s.scorers.Select(req.Policy, req.Location.Coordinates[1], req.Location.Coordinates[0])
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		scorers[i] = scorer
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := s.searchCandidates(ctx, req)
+			if err != nil {
+				results[i].Err = err
+				return
+			}
+			candidates[i] = s.rankByETA(ctx, req.Location.Coordinates[1], req.Location.Coordinates[0], scoring.Rank(scorer, found))
+		}()
+	}
+	wg.Wait()
+
+	// Index every distinct driver seen by any rider as a column of the cost matrix.
+	driverIndex := make(map[string]int)
+	for i := range requests {
+		for _, c := range candidates[i] {
+			if _, ok := driverIndex[c.ID]; !ok {
+				driverIndex[c.ID] = len(driverIndex)
+			}
+		}
+	}
+
+	cost := make([][]float64, len(requests))
+	for i := range requests {
+		cost[i] = make([]float64, len(driverIndex))
+		for j := range cost[i] {
+			cost[i][j] = math.Inf(1)
+		}
+		// Every rider is costed in seconds, so that riders of different
+		// policies compare. Riders whose leading candidates have ETAs are
+		// assigned by driving time among those candidates only; the rest by
+		// the straight-line distance driven at the average speed.
+		useETA := len(candidates[i]) > 0 && candidates[i][0].ETASeconds != nil
+		for _, c := range candidates[i] {
+			j := driverIndex[c.ID]
+			switch {
+			case !useETA:
+				cost[i][j] = c.Distance / (s.config.AverageSpeedKmh / 3.6)
+			case c.ETASeconds != nil:
+				cost[i][j] = *c.ETASeconds
+			}
+			if c.Flagged {
+				cost[i][j] += scoring.FlaggedPenalty
+			}
+		}
+	}
+
+	assigned := assignment.Solve(cost)
+	for i := range requests {
+		if results[i].Err != nil {
+			continue
+		}
+
+		if assigned[i] < 0 {
+			results[i].Err = ErrNoDriverFound
+			continue
+		}
+
+		for _, c := range candidates[i] {
+			if driverIndex[c.ID] == assigned[i] {
+				results[i].Match = toDriverMatch(requests[i], c, scorers[i].Name())
+				break
+			}
+		}
+	}
+
+	s.logger.Debug("resolved match batch",
+		zap.Int("requests", len(requests)),
+		zap.Int("drivers", len(driverIndex)),
+	)
+
+	return results
+}
+
+// rankByETA re-ranks the leading candidates by driving time to the rider.
+// Candidates without a route keep their relative order after those with one,
+// flagged candidates stay last, and the geographic ranking is kept when no
+// ETA can be computed.
+func (s service) rankByETA(ctx context.Context, lat, lon float64, ranked []scoring.Candidate) []scoring.Candidate {
+	if s.etaProvider == nil || len(ranked) == 0 {
+		return ranked
+	}
+
+	top := ranked[:min(s.config.ETACandidates, len(ranked))]
+	origins := make([]eta.Point, len(top))
+	for i, c := range top {
+		// Drivers on a known segment start from their position on it, which
+		// lies on the road the segment is routed along.
+		if c.RoadSegmentID != "" {
+			origins[i] = eta.Point{Latitude: c.RoadLatitude, Longitude: c.RoadLongitude, SegmentID: c.RoadSegmentID}
+			continue
+		}
+		origins[i] = eta.Point{Latitude: c.Latitude, Longitude: c.Longitude}
+	}
+
+	routes, err := s.etaProvider.Routes(ctx, origins, eta.Point{Latitude: lat, Longitude: lon})
+	if err != nil {
+		s.log(ctx).Warn("failed to compute ETAs, using geographic ranking", zap.Error(err))
+		return ranked
+	}
+
+	for i := range top {
+		if routes[i].Reachable() {
+			top[i].ETASeconds = &routes[i].Duration
+		}
+	}
+
+	sort.SliceStable(top, func(i, j int) bool {
+		if top[i].Flagged != top[j].Flagged {
+			return !top[i].Flagged
+		}
+		if top[i].ETASeconds == nil || top[j].ETASeconds == nil {
+			return top[i].ETASeconds != nil && top[j].ETASeconds == nil
+		}
+		return *top[i].ETASeconds < *top[j].ETASeconds
+	})
+
+	return ranked
+}
+
+func (s service) searchCandidates(ctx context.Context, req *dto.MatchRequest) ([]scoring.Candidate, error) {
 	lon := req.Location.Coordinates[0]
 	lat := req.Location.Coordinates[1]
 
-	searchResp, err := s.driverLocationClient.SearchDrivers(ctx, lat, lon, float64(s.config.SearchRadius))
+	searchResp, err := s.driverLocationClient.SearchDrivers(ctx, lat, lon, float64(s.config.SearchRadius), toClientRequirements(req.Requirements))
 	if err != nil {
 		return nil, fmt.Errorf("failed to search drivers: %w", err)
 	}
 
-	if !searchResp.Success || len(searchResp.Data.Locations) == 0 {
-		return nil, ErrNoDriverFound
+	if !searchResp.Success {
+		return nil, nil
 	}
 
-	nearest := searchResp.Data.Locations[0]
+	return toCandidates(searchResp.Data.Locations, lat, lon), nil
+}
+
+// toCandidates converts the drivers found around the rider at lat, lon.
+func toCandidates(locations []client.SearchResultLocation, lat, lon float64) []scoring.Candidate {
+	candidates := make([]scoring.Candidate, len(locations))
+	for i, l := range locations {
+		candidates[i] = scoring.Candidate{
+			ID:             l.ID,
+			Longitude:      l.Location.Coordinates[0],
+			Latitude:       l.Location.Coordinates[1],
+			Distance:       l.Distance,
+			Rating:         l.Rating,
+			IdleSeconds:    l.IdleSeconds,
+			AcceptanceRate: l.AcceptanceRate,
+			Flagged:        len(l.Flags) > 0,
+		}
+		if l.RoadSegmentID != "" && l.SnappedLocation != nil && len(l.SnappedLocation.Coordinates) == 2 {
+			candidates[i].RoadSegmentID = l.RoadSegmentID
+			candidates[i].RoadLongitude = l.SnappedLocation.Coordinates[0]
+			candidates[i].RoadLatitude = l.SnappedLocation.Coordinates[1]
+		}
+		if l.Vehicle != nil {
+			candidates[i].VehicleType = l.Vehicle.Type
+		}
+		moving := l.Speed == nil || *l.Speed >= scoring.MinHeadingSpeed
+		if l.Heading != nil && moving && l.Distance >= scoring.MinHeadingDistance {
+			candidates[i].HeadingAlignment = scoring.HeadingAlignment(*l.Heading, candidates[i].Latitude, candidates[i].Longitude, lat, lon)
+		}
+	}
+	return candidates
+}
 
+func toClientRequirements(r *dto.VehicleRequirements) *client.VehicleRequirements {
+	if r == nil {
+		return nil
+	}
+	return &client.VehicleRequirements{
+		VehicleTypes:         r.VehicleTypes,
+		MinCapacity:          r.MinCapacity,
+		WheelchairAccessible: r.WheelchairAccessible,
+		PetFriendly:          r.PetFriendly,
+	}
+}
+
+func toDriverMatch(req *dto.MatchRequest, c scoring.Candidate, policy string) *dto.DriverMatch {
 	return &dto.DriverMatch{
-		ID: nearest.ID,
+		ID:      c.ID,
+		RiderID: req.RiderID,
 		Location: dto.GeoJSONPoint{
-			Type:        nearest.Location.Type,
-			Coordinates: nearest.Location.Coordinates,
+			Type:        "Point",
+			Coordinates: []float64{c.Longitude, c.Latitude},
 		},
-		Distance: nearest.Distance,
-	}, nil
+		Distance:   c.Distance,
+		Policy:     policy,
+		ETASeconds: c.ETASeconds,
+	}
 }
 
-func (s service) HealthCheck(ctx context.Context) error {
-	return nil
+func toSurgeDTO(s pricing.Surge) dto.Surge {
+	return dto.Surge{
+		CellID:     s.CellID,
+		Multiplier: s.Multiplier,
+		Demand:     s.Demand,
+		Supply:     s.Supply,
+		ComputedAt: s.ComputedAt,
+	}
 }
@@ -0,0 +1,464 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/client"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/config"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/dto"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/eta"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/pricing"
+	"github.com/BarkinBalci/bitaksi-case-study/matching/internal/scoring"
+)
+
+// fakeDriverLocation serves driver and pickup point searches and records the
+// locations drivers were searched around.
+type fakeDriverLocation struct {
+	drivers []client.SearchResultLocation
+	// driversAt, when set, holds the drivers found around each longitude,
+	// latitude instead.
+	driversAt          map[[2]float64][]client.SearchResultLocation
+	pickupPoints       []client.PickupPoint
+	pickupPointsStatus int
+
+	mu               sync.Mutex
+	searchedAt       [][]float64
+	pickupPointCalls int
+}
+
+func (f *fakeDriverLocation) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/v1/locations/search":
+		var req client.SearchRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		f.searchedAt = append(f.searchedAt, req.Location.Coordinates)
+
+		resp := client.SearchResponse{Success: true}
+		resp.Data.Locations = f.drivers
+		if f.driversAt != nil {
+			resp.Data.Locations = f.driversAt[[2]float64{req.Location.Coordinates[0], req.Location.Coordinates[1]}]
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	case "/api/v1/pickup-points/search":
+		f.pickupPointCalls++
+		if f.pickupPointsStatus != 0 {
+			w.WriteHeader(f.pickupPointsStatus)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(client.PickupPointSearchResponse{Success: true, Data: f.pickupPoints})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newTestService creates a service backed by driverLocation, with the
+// distance policy as default, an average speed of 30 km/h and no ETA provider
+// unless one is given.
+func newTestService(t *testing.T, driverLocation http.Handler, cfg *config.Config, etaProvider eta.Provider) Service {
+	t.Helper()
+	server := httptest.NewServer(driverLocation)
+	t.Cleanup(server.Close)
+
+	if cfg.SearchRadius == 0 {
+		cfg.SearchRadius = 1000
+	}
+	if cfg.AverageSpeedKmh == 0 {
+		cfg.AverageSpeedKmh = 30
+	}
+	driverLocationClient := client.NewDriverLocationClient(server.URL, "key", client.Options{
+		Timeout:            time.Second,
+		BreakerThreshold:   100,
+		BreakerOpenTimeout: time.Minute,
+	})
+	surge := pricing.NewSurgeEngine(pricing.SurgeConfig{
+		CellSize:        0.01,
+		DemandWindow:    time.Minute,
+		RefreshInterval: time.Minute,
+		Smoothing:       1,
+		MaxMultiplier:   1,
+		HistorySize:     1,
+	}, driverLocationClient, nil, zap.NewNop())
+
+	s := NewService(driverLocationClient, scoring.NewSelector(float64(cfg.SearchRadius)), etaProvider, surge, nil, nil, nil, cfg, zap.NewNop())
+	t.Cleanup(s.Close)
+	return s
+}
+
+// testMatchRequest is a rider at 29.0, 41.0.
+func testMatchRequest() *dto.MatchRequest {
+	return &dto.MatchRequest{
+		RiderID:  "rider-1",
+		Location: dto.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0, 41.0}},
+	}
+}
+
+func testDriver(id string, lon, lat, distance float64) client.SearchResultLocation {
+	return client.SearchResultLocation{
+		ID:       id,
+		Location: client.GeoJSONPoint{Type: "Point", Coordinates: []float64{lon, lat}},
+		Distance: distance,
+	}
+}
+
+// fakeETAProvider returns fixed routes and records the origins it was asked about.
+type fakeETAProvider struct {
+	routes  []eta.Route
+	err     error
+	origins []eta.Point
+}
+
+func (p *fakeETAProvider) Routes(ctx context.Context, origins []eta.Point, destination eta.Point) ([]eta.Route, error) {
+	p.origins = origins
+	if p.err != nil {
+		return nil, p.err
+	}
+	return p.routes[:len(origins)], nil
+}
+
+func TestToCandidates_SnappedLocation(t *testing.T) {
+	// Setup
+	locations := []client.SearchResultLocation{
+		{
+			ID:              "snapped",
+			Location:        client.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0001, 41.0002}},
+			SnappedLocation: &client.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0001, 41.0}},
+			RoadSegmentID:   "a:b",
+		},
+		{
+			ID:            "segment without position",
+			Location:      client.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0, 41.0}},
+			RoadSegmentID: "a:b",
+		},
+	}
+
+	// Execute
+	candidates := toCandidates(locations, 41.01, 29.01)
+
+	// Assert
+	require.Len(t, candidates, 2)
+	assert.Equal(t, "a:b", candidates[0].RoadSegmentID)
+	assert.Equal(t, 41.0, candidates[0].RoadLatitude)
+	assert.Equal(t, 29.0001, candidates[0].RoadLongitude)
+	assert.Equal(t, 41.0002, candidates[0].Latitude)
+	assert.Empty(t, candidates[1].RoadSegmentID)
+}
+
+func TestRankByETA_Origins(t *testing.T) {
+	// Setup
+	provider := &fakeETAProvider{routes: []eta.Route{{Duration: 60, Distance: 500}, {Duration: 30, Distance: 250}}}
+	s := service{etaProvider: provider, config: &config.Config{ETACandidates: 5}, logger: zap.NewNop()}
+	ranked := []scoring.Candidate{
+		{ID: "on road", Latitude: 41.0002, Longitude: 29.0001, RoadSegmentID: "a:b", RoadLatitude: 41.0, RoadLongitude: 29.0001},
+		{ID: "off road", Latitude: 41.005, Longitude: 29.005},
+	}
+
+	// Execute
+	s.rankByETA(context.Background(), 41.01, 29.01, ranked)
+
+	// Assert
+	assert.Equal(t, []eta.Point{
+		{Latitude: 41.0, Longitude: 29.0001, SegmentID: "a:b"},
+		{Latitude: 41.005, Longitude: 29.005},
+	}, provider.origins)
+}
+
+func TestRankByETA(t *testing.T) {
+	// candidates are ranked a, b, c, d by distance.
+	candidates := func() []scoring.Candidate {
+		return []scoring.Candidate{
+			{ID: "a", Latitude: 41.001, Longitude: 29.0},
+			{ID: "b", Latitude: 41.002, Longitude: 29.0},
+			{ID: "c", Latitude: 41.003, Longitude: 29.0},
+			{ID: "d", Latitude: 41.004, Longitude: 29.0},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		etaCandidates   int
+		provider        *fakeETAProvider
+		expectedOrder   []string
+		expectedETAs    map[string]float64
+		expectedOrigins int
+	}{
+		{
+			name:            "fastest drivers first",
+			etaCandidates:   4,
+			provider:        &fakeETAProvider{routes: []eta.Route{{Duration: 300}, {Duration: 100}, {Duration: 200}, {Duration: 50}}},
+			expectedOrder:   []string{"d", "b", "c", "a"},
+			expectedETAs:    map[string]float64{"a": 300, "b": 100, "c": 200, "d": 50},
+			expectedOrigins: 4,
+		},
+		{
+			name:            "provider error keeps the geographic ranking",
+			etaCandidates:   4,
+			provider:        &fakeETAProvider{err: errors.New("router unavailable")},
+			expectedOrder:   []string{"a", "b", "c", "d"},
+			expectedETAs:    map[string]float64{},
+			expectedOrigins: 4,
+		},
+		{
+			name:            "unreachable drivers go after reachable ones",
+			etaCandidates:   4,
+			provider:        &fakeETAProvider{routes: []eta.Route{eta.Unreachable, {Duration: 200}, eta.Unreachable, {Duration: 100}}},
+			expectedOrder:   []string{"d", "b", "a", "c"},
+			expectedETAs:    map[string]float64{"b": 200, "d": 100},
+			expectedOrigins: 4,
+		},
+		{
+			name:            "only the top candidates are routed and reordered",
+			etaCandidates:   2,
+			provider:        &fakeETAProvider{routes: []eta.Route{{Duration: 300}, {Duration: 100}}},
+			expectedOrder:   []string{"b", "a", "c", "d"},
+			expectedETAs:    map[string]float64{"a": 300, "b": 100},
+			expectedOrigins: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := service{etaProvider: tt.provider, config: &config.Config{ETACandidates: tt.etaCandidates}, logger: zap.NewNop()}
+
+			// Execute
+			ranked := s.rankByETA(context.Background(), 41.0, 29.0, candidates())
+
+			// Assert
+			order := make([]string, len(ranked))
+			etas := map[string]float64{}
+			for i, c := range ranked {
+				order[i] = c.ID
+				if c.ETASeconds != nil {
+					etas[c.ID] = *c.ETASeconds
+				}
+			}
+			assert.Equal(t, tt.expectedOrder, order)
+			assert.Equal(t, tt.expectedETAs, etas)
+			assert.Len(t, tt.provider.origins, tt.expectedOrigins)
+		})
+	}
+}
+
+func TestFindNearestDriver_PickupPoint(t *testing.T) {
+	gate := client.PickupPoint{
+		ID:       "p1",
+		Name:     "Terminal 1 Gate 4",
+		Zone:     "airport",
+		Location: client.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0005, 41.0003}},
+		Distance: 50,
+	}
+	exit := client.PickupPoint{
+		ID:       "p2",
+		Name:     "Terminal 1 Exit",
+		Location: client.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.001, 41.001}},
+		Distance: 140,
+	}
+	requested := []float64{29.0, 41.0}
+
+	tests := []struct {
+		name                string
+		enabled             bool
+		batched             bool
+		pickupPoints        []client.PickupPoint
+		pickupPointsStatus  int
+		expectedLookups     int
+		expectedSearchedAt  []float64
+		expectedPickupPoint *dto.PickupPoint
+	}{
+		{
+			name:               "disabled",
+			pickupPoints:       []client.PickupPoint{gate},
+			expectedSearchedAt: requested,
+		},
+		{
+			name:               "no pickup point nearby",
+			enabled:            true,
+			pickupPoints:       []client.PickupPoint{},
+			expectedLookups:    1,
+			expectedSearchedAt: requested,
+		},
+		{
+			name:               "search error falls back to the pin",
+			enabled:            true,
+			pickupPointsStatus: http.StatusBadRequest,
+			expectedLookups:    1,
+			expectedSearchedAt: requested,
+		},
+		{
+			name:               "nearest pickup point",
+			enabled:            true,
+			pickupPoints:       []client.PickupPoint{gate, exit},
+			expectedLookups:    1,
+			expectedSearchedAt: gate.Location.Coordinates,
+			expectedPickupPoint: &dto.PickupPoint{
+				ID:       "p1",
+				Name:     "Terminal 1 Gate 4",
+				Zone:     "airport",
+				Location: dto.GeoJSONPoint{Type: "Point", Coordinates: gate.Location.Coordinates},
+				Distance: 50,
+			},
+		},
+		{
+			name:               "nearest pickup point when batched",
+			enabled:            true,
+			batched:            true,
+			pickupPoints:       []client.PickupPoint{gate, exit},
+			expectedLookups:    1,
+			expectedSearchedAt: gate.Location.Coordinates,
+			expectedPickupPoint: &dto.PickupPoint{
+				ID:       "p1",
+				Name:     "Terminal 1 Gate 4",
+				Zone:     "airport",
+				Location: dto.GeoJSONPoint{Type: "Point", Coordinates: gate.Location.Coordinates},
+				Distance: 50,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			driverLocation := &fakeDriverLocation{
+				drivers:            []client.SearchResultLocation{testDriver("d1", 29.002, 41.002, 250)},
+				pickupPoints:       tt.pickupPoints,
+				pickupPointsStatus: tt.pickupPointsStatus,
+			}
+			s := newTestService(t, driverLocation, &config.Config{
+				PickupPointsEnabled:  tt.enabled,
+				PickupPointRadius:    150,
+				BatchMatchingEnabled: tt.batched,
+				BatchWindow:          10 * time.Millisecond,
+				BatchMaxSize:         10,
+			}, nil)
+
+			// Execute
+			match, err := s.FindNearestDriver(context.Background(), testMatchRequest())
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, "d1", match.ID)
+			assert.Equal(t, tt.expectedPickupPoint, match.PickupPoint)
+			driverLocation.mu.Lock()
+			defer driverLocation.mu.Unlock()
+			assert.Equal(t, tt.expectedLookups, driverLocation.pickupPointCalls)
+			require.Len(t, driverLocation.searchedAt, 1)
+			assert.Equal(t, tt.expectedSearchedAt, driverLocation.searchedAt[0])
+		})
+	}
+}
+
+func TestFindNearestDriver_Heading(t *testing.T) {
+	north, speed, slow := 0.0, 10.0, 0.5
+
+	// withHeading sets the heading and speed of a driver.
+	withHeading := func(d client.SearchResultLocation, heading, speed *float64) client.SearchResultLocation {
+		d.Heading = heading
+		d.Speed = speed
+		return d
+	}
+
+	tests := []struct {
+		name       string
+		drivers    []client.SearchResultLocation
+		expectedID string
+	}{
+		{
+			name: "driver heading toward the rider beats a closer one heading away",
+			drivers: []client.SearchResultLocation{
+				// North of the rider, driving further north.
+				withHeading(testDriver("near-away", 29.0, 41.0027, 300), &north, &speed),
+				// South of the rider, driving north toward it.
+				withHeading(testDriver("further-toward", 29.0, 40.99685, 350), &north, &speed),
+			},
+			expectedID: "further-toward",
+		},
+		{
+			name: "headings of slow drivers are ignored",
+			drivers: []client.SearchResultLocation{
+				withHeading(testDriver("near-away", 29.0, 41.0027, 300), &north, &slow),
+				withHeading(testDriver("further-toward", 29.0, 40.99685, 350), &north, &slow),
+			},
+			expectedID: "near-away",
+		},
+		{
+			name: "without headings the nearest driver wins",
+			drivers: []client.SearchResultLocation{
+				testDriver("near", 29.0, 41.0027, 300),
+				testDriver("further", 29.0, 40.99685, 350),
+			},
+			expectedID: "near",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := newTestService(t, &fakeDriverLocation{drivers: tt.drivers}, &config.Config{}, nil)
+
+			// Execute
+			match, err := s.FindNearestDriver(context.Background(), testMatchRequest())
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, scoring.PolicyDistance, match.Policy)
+			assert.Equal(t, tt.expectedID, match.ID)
+		})
+	}
+}
+
+func TestFindNearestDriver_BatchMixedPolicies(t *testing.T) {
+	// Setup
+	// The first rider is nearly as close to d2 as to d1, while d2 is far from
+	// the second rider, so d1 has to go to the second rider. Scores of the
+	// distance policy are in meters and those of weighted_sum below one, so
+	// summing them would hand d1 to the first rider.
+	riders := []*dto.MatchRequest{
+		{RiderID: "r1", Policy: scoring.PolicyDistance, Location: dto.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.0, 41.0}}},
+		{RiderID: "r2", Policy: scoring.PolicyWeightedSum, Location: dto.GeoJSONPoint{Type: "Point", Coordinates: []float64{29.1, 41.1}}},
+	}
+	driverLocation := &fakeDriverLocation{driversAt: map[[2]float64][]client.SearchResultLocation{
+		{29.0, 41.0}: {testDriver("d1", 29.0, 41.0009, 100), testDriver("d2", 29.0, 40.99865, 150)},
+		{29.1, 41.1}: {testDriver("d1", 29.1, 41.1009, 100), testDriver("d2", 29.1, 41.127, 3000)},
+	}}
+	s := newTestService(t, driverLocation, &config.Config{
+		SearchRadius:         5000,
+		BatchMatchingEnabled: true,
+		BatchWindow:          time.Second,
+		BatchMaxSize:         len(riders),
+	}, nil)
+
+	// Execute
+	matches := make([]*dto.DriverMatch, len(riders))
+	errs := make([]error, len(riders))
+	var wg sync.WaitGroup
+	for i, req := range riders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			matches[i], errs[i] = s.FindNearestDriver(context.Background(), req)
+		}()
+	}
+	wg.Wait()
+
+	// Assert
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	assert.Equal(t, "d2", matches[0].ID)
+	assert.Equal(t, scoring.PolicyDistance, matches[0].Policy)
+	assert.Equal(t, "d1", matches[1].ID)
+	assert.Equal(t, scoring.PolicyWeightedSum, matches[1].Policy)
+}
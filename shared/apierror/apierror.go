@@ -0,0 +1,126 @@
+// Package apierror writes the errors returned by the APIs of both services.
+// Each error has a stable machine-readable code, an HTTP status and a
+// message, and is written either as ErrorResponse or, when the client asks
+// for it, as an RFC 7807 problem. The errors every service can return are
+// declared here; services declare their own with the same Error type.
+package apierror
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+const (
+	ProblemJSONContentType = "application/problem+json"
+	ProblemTypeDefault     = "about:blank"
+)
+
+// Error is an entry of an error catalogue.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+var (
+	ErrMalformedRequest         = Error{Code: "malformed_request", Status: http.StatusBadRequest, Message: "The request could not be parsed."}
+	ErrValidationFailed         = Error{Code: "validation_failed", Status: http.StatusBadRequest, Message: "The request contains invalid fields."}
+	ErrNotFound                 = Error{Code: "not_found", Status: http.StatusNotFound, Message: "Not found."}
+	ErrRequestTooLarge          = Error{Code: "request_too_large", Status: http.StatusRequestEntityTooLarge, Message: "The request body is too large."}
+	ErrRateLimited              = Error{Code: "rate_limited", Status: http.StatusTooManyRequests, Message: "Too many requests. Please retry later."}
+	ErrInvalidIdempotencyKey    = Error{Code: "invalid_idempotency_key", Status: http.StatusBadRequest, Message: "The Idempotency-Key header must be at most 255 printable ASCII characters."}
+	ErrIdempotencyKeyReused     = Error{Code: "idempotency_key_reused", Status: http.StatusUnprocessableEntity, Message: "The Idempotency-Key was already used for a different request."}
+	ErrIdempotencyKeyInProgress = Error{Code: "idempotency_key_in_progress", Status: http.StatusConflict, Message: "A request with this Idempotency-Key is still being processed."}
+	ErrInternal                 = Error{Code: "internal_error", Status: http.StatusInternalServerError, Message: "An unexpected error occurred. Please try again later."}
+)
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Success   bool         `json:"success"`
+	Code      string       `json:"code" example:"validation_failed"`
+	Error     string       `json:"error" example:"The request contains invalid fields."`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty" example:"3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"`
+}
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field   string `json:"field" example:"location.coordinates"`
+	Reason  string `json:"reason" example:"len"`
+	Message string `json:"message" example:"must have exactly 2 elements"`
+}
+
+// Problem is an RFC 7807 problem details body, returned instead of
+// ErrorResponse when the client accepts application/problem+json.
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Bad Request"`
+	Status    int          `json:"status" example:"400"`
+	Detail    string       `json:"detail" example:"The request contains invalid fields."`
+	Instance  string       `json:"instance,omitempty" example:"/api/v1/match"`
+	Code      string       `json:"code" example:"validation_failed"`
+	RequestID string       `json:"request_id,omitempty" example:"3f2b8c1e-8d4a-4c55-9a43-5b1f0e7d2a10"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Respond aborts the request with e. Details are only set for validation failures.
+func Respond(c *gin.Context, e Error, details ...FieldError) {
+	requestID := logging.RequestID(c.Request.Context())
+
+	if acceptsProblem(c.GetHeader("Accept")) {
+		c.Header("Content-Type", ProblemJSONContentType)
+		c.AbortWithStatusJSON(e.Status, Problem{
+			Type:      ProblemTypeDefault,
+			Title:     http.StatusText(e.Status),
+			Status:    e.Status,
+			Detail:    e.Message,
+			Instance:  c.Request.URL.Path,
+			Code:      e.Code,
+			RequestID: requestID,
+			Errors:    details,
+		})
+		return
+	}
+
+	c.AbortWithStatusJSON(e.Status, ErrorResponse{
+		Success:   false,
+		Code:      e.Code,
+		Error:     e.Message,
+		Details:   details,
+		RequestID: requestID,
+	})
+}
+
+// RespondBinding aborts the request with the error matching a failed
+// ShouldBind call, listing the rejected fields when validation failed.
+func RespondBinding(c *gin.Context, err error) {
+	if details := FieldErrors(err); len(details) > 0 {
+		Respond(c, ErrValidationFailed, details...)
+		return
+	}
+	Respond(c, ErrMalformedRequest)
+}
+
+// NotFound responds to requests for unknown routes.
+func NotFound(c *gin.Context) {
+	Respond(c, ErrNotFound)
+}
+
+// Recover responds to requests whose handler panicked; use it with gin.CustomRecovery.
+func Recover(c *gin.Context, _ any) {
+	Respond(c, ErrInternal)
+}
+
+func acceptsProblem(accept string) bool {
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType, _, _ = strings.Cut(mediaType, ";")
+		if strings.TrimSpace(mediaType) == ProblemJSONContentType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+package apierror
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+type testPoint struct {
+	Type        string    `json:"type" binding:"required,eq=Point"`
+	Coordinates []float64 `json:"coordinates" binding:"required,len=2"`
+}
+
+type testBodyRequest struct {
+	Location testPoint `json:"location" binding:"required"`
+}
+
+type testQueryRequest struct {
+	Latitude  *float64 `form:"lat" binding:"required,latitude"`
+	Longitude *float64 `form:"lon" binding:"required,longitude"`
+}
+
+func bindAndRespond(c *gin.Context) {
+	var req testBodyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondBinding(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func bindQueryAndRespond(c *gin.Context) {
+	var req testQueryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		RespondBinding(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+func TestRespondBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name            string
+		method          string
+		target          string
+		body            string
+		expectedCode    string
+		expectedDetails []FieldError
+	}{
+		{
+			name:         "malformed json",
+			method:       http.MethodPost,
+			target:       "/match",
+			body:         `{"location":`,
+			expectedCode: "malformed_request",
+		},
+		{
+			name:         "wrong type",
+			method:       http.MethodPost,
+			target:       "/match",
+			body:         `{"location":{"type":"Point","coordinates":[29,"41"]}}`,
+			expectedCode: "validation_failed",
+			expectedDetails: []FieldError{
+				{Field: "location.coordinates[1]", Reason: "type", Message: "must be a number"},
+			},
+		},
+		{
+			name:         "invalid fields",
+			method:       http.MethodPost,
+			target:       "/match",
+			body:         `{"location":{"type":"Line","coordinates":[29]}}`,
+			expectedCode: "validation_failed",
+			expectedDetails: []FieldError{
+				{Field: "location.type", Reason: "eq", Message: "must be Point"},
+				{Field: "location.coordinates", Reason: "len", Message: "must have exactly 2 elements"},
+			},
+		},
+		{
+			name:         "invalid query",
+			method:       http.MethodGet,
+			target:       "/surge?lat=91",
+			expectedCode: "validation_failed",
+			expectedDetails: []FieldError{
+				{Field: "lat", Reason: "latitude", Message: "must be a valid latitude"},
+				{Field: "lon", Reason: "required", Message: "is required"},
+			},
+		},
+		{
+			name:         "unparsable query",
+			method:       http.MethodGet,
+			target:       "/surge?lat=north&lon=29",
+			expectedCode: "malformed_request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := gin.New()
+			router.POST("/match", bindAndRespond)
+			router.GET("/surge", bindQueryAndRespond)
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, bytes.NewBufferString(tt.body))
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+			assert.False(t, resp.Success)
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedDetails, resp.Details)
+		})
+	}
+}
+
+func TestRespond_ProblemJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+	}{
+		{
+			name:                "default",
+			accept:              "",
+			expectedContentType: "application/json; charset=utf-8",
+		},
+		{
+			name:                "problem requested",
+			accept:              "application/problem+json",
+			expectedContentType: ProblemJSONContentType,
+		},
+		{
+			name:                "problem among other types",
+			accept:              "application/json;q=0.9, application/problem+json",
+			expectedContentType: ProblemJSONContentType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), "req-1"))
+			})
+			router.NoRoute(NotFound)
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/missing", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			assert.Equal(t, http.StatusNotFound, recorder.Code)
+			assert.Equal(t, tt.expectedContentType, recorder.Header().Get("Content-Type"))
+			if tt.expectedContentType == ProblemJSONContentType {
+				assert.JSONEq(t, `{
+					"type": "about:blank",
+					"title": "Not Found",
+					"status": 404,
+					"detail": "Not found.",
+					"instance": "/missing",
+					"code": "not_found",
+					"request_id": "req-1"
+				}`, recorder.Body.String())
+			} else {
+				assert.JSONEq(t, `{"success":false,"code":"not_found","error":"Not found.","request_id":"req-1"}`, recorder.Body.String())
+			}
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	// Setup
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.CustomRecoveryWithWriter(&bytes.Buffer{}, Recover))
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	// Execute
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.JSONEq(t, `{"success":false,"code":"internal_error","error":"`+ErrInternal.Message+`"}`, recorder.Body.String())
+}
@@ -0,0 +1,124 @@
+// Package idempotency stores the responses of requests made with an
+// Idempotency-Key header, so that retries of a request are answered with the
+// first response instead of being processed again.
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when completing or releasing a key that is not reserved.
+var ErrNotFound = errors.New("idempotency key not found")
+
+// Record is the state of an idempotency key. The request is only kept as its
+// hash, and Body holds the response. A record that is not Completed belongs
+// to a request that is still being processed.
+type Record struct {
+	Key         string    `bson:"_id"`
+	RequestHash string    `bson:"request_hash"`
+	Completed   bool      `bson:"completed"`
+	StatusCode  int       `bson:"status_code,omitempty"`
+	ContentType string    `bson:"content_type,omitempty"`
+	Body        []byte    `bson:"body,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+// Store keeps idempotency records until they expire.
+type Store interface {
+	// Reserve claims key for a request whose payload hashes to requestHash.
+	// It returns nil when the key was claimed, or the record already holding it.
+	Reserve(ctx context.Context, key, requestHash string) (*Record, error)
+	// Complete stores the response of the request that reserved key.
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	// Release frees key so that the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+// HashRequest identifies a request by its method, route template and body.
+func HashRequest(method, route string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + route + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// memoryStore keeps records in memory, so keys are only known to one replica.
+type memoryStore struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu       sync.Mutex
+	records  map[string]*Record
+	prunedAt time.Time
+}
+
+// NewMemoryStore creates a store keeping each record for ttl.
+func NewMemoryStore(ttl time.Duration) Store {
+	return &memoryStore{
+		ttl:     ttl,
+		now:     time.Now,
+		records: make(map[string]*Record),
+	}
+}
+
+// pruneInterval is how often expired records are dropped.
+const pruneInterval = time.Minute
+
+func (s *memoryStore) Reserve(_ context.Context, key, requestHash string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.prunedAt) >= pruneInterval {
+		s.pruneLocked(now)
+	}
+
+	if record, ok := s.records[key]; ok && now.Before(record.ExpiresAt) {
+		existing := *record
+		return &existing, nil
+	}
+
+	s.records[key] = &Record{Key: key, RequestHash: requestHash, ExpiresAt: now.Add(s.ttl)}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return ErrNotFound
+	}
+	record.Completed = true
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Body = body
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.records, key)
+	return nil
+}
+
+// pruneLocked drops expired records. s.mu must be held.
+func (s *memoryStore) pruneLocked(now time.Time) {
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+	s.prunedAt = now
+}
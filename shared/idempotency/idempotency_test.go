@@ -0,0 +1,68 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashRequest(t *testing.T) {
+	hash := HashRequest("POST", "/api/v1/match", []byte(`{"rider_id":"r1"}`))
+
+	assert.Equal(t, hash, HashRequest("POST", "/api/v1/match", []byte(`{"rider_id":"r1"}`)))
+	assert.NotEqual(t, hash, HashRequest("POST", "/api/v1/match", []byte(`{"rider_id":"r2"}`)))
+	assert.NotEqual(t, hash, HashRequest("POST", "/api/v1/estimate", []byte(`{"rider_id":"r1"}`)))
+}
+
+func TestMemoryStore(t *testing.T) {
+	// Setup
+	store := NewMemoryStore(time.Hour).(*memoryStore)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Execute
+	reserved, err := store.Reserve(ctx, "a", "hash")
+	require.NoError(t, err)
+	inProgress, err := store.Reserve(ctx, "a", "hash")
+	require.NoError(t, err)
+	require.NoError(t, store.Complete(ctx, "a", http.StatusOK, "application/json", []byte(`{}`)))
+	completed, err := store.Reserve(ctx, "a", "other")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	expired, err := store.Reserve(ctx, "a", "other")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Nil(t, reserved)
+	require.NotNil(t, inProgress)
+	assert.False(t, inProgress.Completed)
+	require.NotNil(t, completed)
+	assert.Equal(t, "hash", completed.RequestHash)
+	assert.Equal(t, http.StatusOK, completed.StatusCode)
+	assert.Equal(t, []byte(`{}`), completed.Body)
+	assert.Nil(t, expired, "an expired key can be reserved again")
+}
+
+func TestMemoryStore_Release(t *testing.T) {
+	// Setup
+	store := NewMemoryStore(time.Hour)
+	ctx := context.Background()
+	_, err := store.Reserve(ctx, "a", "hash")
+	require.NoError(t, err)
+
+	// Execute
+	require.NoError(t, store.Release(ctx, "a"))
+	record, err := store.Reserve(ctx, "a", "hash")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.ErrorIs(t, store.Release(ctx, "missing"), ErrNotFound)
+	assert.ErrorIs(t, store.Complete(ctx, "missing", http.StatusOK, "", nil), ErrNotFound)
+}
@@ -0,0 +1,159 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/requestid"
+)
+
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+	MaxKeyLength   = 255
+	// SaveTimeout bounds saving a response after the client has gone away.
+	SaveTimeout = 5 * time.Second
+)
+
+// Middleware replays the stored response of requests to routes that repeat
+// the Idempotency-Key header of an earlier request with the same payload.
+// Routes are written as "<METHOD> <route template>". Keys are scoped to the
+// caller identified by clientID. Only a hash of the request is stored, and
+// requests with a body larger than maxBodyBytes are rejected. Responses with
+// a 5xx status or a body larger than maxBodyBytes are not stored, so that the
+// request can be retried.
+func Middleware(store Store, routes []string, clientID func(*gin.Context) string, maxBodyBytes int64, logger *zap.Logger) gin.HandlerFunc {
+	idempotent := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		idempotent[route] = true
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		key := c.GetHeader(Header)
+		if key == "" || !idempotent[route] {
+			c.Next()
+			return
+		}
+		if len(key) > MaxKeyLength || !requestid.PrintableASCII(key) {
+			apierror.Respond(c, apierror.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		ctx := c.Request.Context()
+		log := logging.FromContext(ctx, logger)
+
+		// The body is hashed before the handler runs, so it is read into
+		// memory up to the limit.
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				apierror.Respond(c, apierror.ErrRequestTooLarge)
+				return
+			}
+			apierror.Respond(c, apierror.ErrMalformedRequest)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		storeKey := clientID(c) + "|" + key
+		requestHash := HashRequest(c.Request.Method, c.FullPath(), body)
+		record, err := store.Reserve(ctx, storeKey, requestHash)
+		if err != nil {
+			log.Error("Failed to reserve idempotency key", zap.Error(err))
+			apierror.Respond(c, apierror.ErrInternal)
+			return
+		}
+		if record != nil {
+			replay(c, record, requestHash)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer, limit: maxBodyBytes}
+		c.Writer = writer
+
+		// The outcome is saved even if the client has gone away, since that
+		// is when it retries. A panicking handler leaves the key released.
+		saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), SaveTimeout)
+		defer cancel()
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := store.Release(saveCtx, storeKey); err != nil {
+				log.Error("Failed to release idempotency key", zap.Error(err))
+			}
+		}()
+
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			return
+		}
+		if writer.truncated {
+			log.Warn("Idempotent response is too large to store", zap.Int64("max_body_bytes", maxBodyBytes))
+			return
+		}
+		if err := store.Complete(saveCtx, storeKey, writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes()); err != nil {
+			log.Error("Failed to save idempotent response", zap.Error(err))
+			return
+		}
+		completed = true
+	}
+}
+
+// replay answers a request whose idempotency key is held by record.
+func replay(c *gin.Context, record *Record, requestHash string) {
+	switch {
+	case record.RequestHash != requestHash:
+		apierror.Respond(c, apierror.ErrIdempotencyKeyReused)
+	case !record.Completed:
+		apierror.Respond(c, apierror.ErrIdempotencyKeyInProgress)
+	default:
+		c.Header(ReplayedHeader, "true")
+		c.Data(record.StatusCode, record.ContentType, record.Body)
+		c.Abort()
+	}
+}
+
+// recordingWriter keeps a copy of the response body, up to limit bytes.
+// Once the body grows past limit the copy is dropped and truncated is set.
+type recordingWriter struct {
+	gin.ResponseWriter
+	limit     int64
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(b []byte) {
+	if w.truncated {
+		return
+	}
+	if int64(w.body.Len()+len(b)) > w.limit {
+		w.truncated = true
+		w.body = bytes.Buffer{}
+		return
+	}
+	w.body.Write(b)
+}
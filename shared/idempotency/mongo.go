@@ -0,0 +1,108 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Observer records the latency of a MongoDB operation started at start.
+type Observer func(operation string, start time.Time, err error)
+
+// mongoStore keeps records in a collection with a TTL index, so keys are
+// shared by every replica and survive restarts.
+type mongoStore struct {
+	collection *mongo.Collection
+	ttl        time.Duration
+	observe    Observer
+	now        func() time.Time
+}
+
+// NewMongoStore creates a store keeping each record in collection for ttl,
+// reporting the latency of its operations to observe. MongoDB removes expired
+// records in the background, up to a minute late, so Reserve also takes over
+// records that have expired but are still stored.
+func NewMongoStore(ctx context.Context, collection *mongo.Collection, ttl time.Duration, observe Observer) (Store, error) {
+	ttlIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if _, err := collection.Indexes().CreateOne(ctx, ttlIndex); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency ttl index: %w", err)
+	}
+
+	return &mongoStore{collection: collection, ttl: ttl, observe: observe, now: time.Now}, nil
+}
+
+func (s *mongoStore) Reserve(ctx context.Context, key, requestHash string) (*Record, error) {
+	now := s.now()
+	record := Record{Key: key, RequestHash: requestHash, ExpiresAt: now.Add(s.ttl)}
+
+	// The upsert inserts the record unless an unexpired one holds the key, in
+	// which case the insert fails on the _id index.
+	filter := bson.D{
+		{Key: "_id", Value: key},
+		{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	start := time.Now()
+	_, err := s.collection.ReplaceOne(ctx, filter, record, options.Replace().SetUpsert(true))
+	s.observe("replace_one", start, err)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	start = time.Now()
+	var existing Record
+	err = s.collection.FindOne(ctx, bson.D{{Key: "_id", Value: key}}).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		s.observe("find_one", start, nil)
+		return nil, fmt.Errorf("idempotency key %s was removed while reserving it", key)
+	}
+	s.observe("find_one", start, err)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find idempotency key: %w", err)
+	}
+	return &existing, nil
+}
+
+func (s *mongoStore) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "completed", Value: true},
+		{Key: "status_code", Value: statusCode},
+		{Key: "content_type", Value: contentType},
+		{Key: "body", Value: body},
+	}}}
+
+	start := time.Now()
+	result, err := s.collection.UpdateOne(ctx, bson.D{{Key: "_id", Value: key}}, update)
+	s.observe("update_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *mongoStore) Release(ctx context.Context, key string) error {
+	start := time.Now()
+	result, err := s.collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: key}})
+	s.observe("delete_one", start, err)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+const (
+	LimitHeader      = "X-RateLimit-Limit"
+	RemainingHeader  = "X-RateLimit-Remaining"
+	RetryAfterHeader = "Retry-After"
+)
+
+// Middleware limits the requests of each client to each route, identifying
+// clients with clientID. Requests are let through when the store fails, so
+// that an unavailable Redis does not take the API down.
+func Middleware(store Store, limits Limits, clientID func(*gin.Context) string, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := clientID(c)
+		route := c.Request.Method + " " + c.FullPath()
+		limit := limits.For(c.Request.Method, c.FullPath())
+
+		result, err := store.Take(c.Request.Context(), client+"|"+route, limit)
+		if err != nil {
+			logging.FromContext(c.Request.Context(), logger).Warn("Rate limiter unavailable, allowing request", zap.Error(err))
+			c.Next()
+			return
+		}
+
+		c.Header(LimitHeader, strconv.Itoa(limit.Burst))
+		c.Header(RemainingHeader, strconv.Itoa(result.Remaining))
+		if !result.Allowed {
+			c.Header(RetryAfterHeader, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			apierror.Respond(c, apierror.ErrRateLimited)
+			return
+		}
+
+		c.Next()
+	}
+}
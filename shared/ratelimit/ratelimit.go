@@ -0,0 +1,190 @@
+// Package ratelimit implements per-client token bucket rate limiting, with
+// buckets kept in memory or, to share limits between replicas, in Redis.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second and holding at
+// most Burst tokens. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// ParseLimit parses a limit written as <count>/<unit>[:<burst>], where unit
+// is s, m or h, such as "10/s:20" or "100/m". The burst defaults to count.
+func ParseLimit(s string) (Limit, error) {
+	spec, burstSpec, hasBurst := strings.Cut(strings.TrimSpace(s), ":")
+	countSpec, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <count>/<unit>[:<burst>]", s)
+	}
+
+	count, err := strconv.Atoi(countSpec)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: count must be a positive integer", s)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, fmt.Errorf("invalid rate limit %q: unit must be s, m or h", s)
+	}
+
+	burst := count
+	if hasBurst {
+		burst, err = strconv.Atoi(burstSpec)
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", s)
+		}
+	}
+
+	return Limit{Rate: float64(count) / period.Seconds(), Burst: burst}, nil
+}
+
+// Limits holds the limit of each route, keyed by "<METHOD> <route template>",
+// and the limit of every other route.
+type Limits struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// ParseLimits parses the default limit and a list of route limits separated
+// by semicolons, such as "POST /api/v1/match=1/s:3;POST /api/v1/auth/token=10/m".
+func ParseLimits(defaultLimit, routes string) (Limits, error) {
+	limits := Limits{Routes: make(map[string]Limit)}
+
+	var err error
+	limits.Default, err = ParseLimit(defaultLimit)
+	if err != nil {
+		return Limits{}, err
+	}
+
+	for _, entry := range strings.Split(routes, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, spec, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath {
+			return Limits{}, fmt.Errorf("invalid route rate limit %q: expected <METHOD> <route>=<limit>", entry)
+		}
+
+		limit, err := ParseLimit(spec)
+		if err != nil {
+			return Limits{}, err
+		}
+		limits.Routes[strings.ToUpper(method)+" "+strings.TrimSpace(path)] = limit
+	}
+
+	return limits, nil
+}
+
+// For returns the limit of a route.
+func (l Limits) For(method, route string) Limit {
+	if limit, ok := l.Routes[method+" "+route]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Result is the outcome of taking a token.
+type Result struct {
+	Allowed   bool
+	Remaining int
+	// RetryAfter is how long until a token is available, when not allowed.
+	RetryAfter time.Duration
+}
+
+// Store takes tokens from the bucket of a key.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// result builds the result of a bucket holding tokens after a take.
+func result(allowed bool, tokens float64, limit Limit) Result {
+	r := Result{Allowed: allowed, Remaining: int(math.Floor(tokens))}
+	if !allowed {
+		r.RetryAfter = time.Duration((1 - tokens) / limit.Rate * float64(time.Second))
+	}
+	return r
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	// fullAt is when the bucket will have refilled to its burst.
+	fullAt time.Time
+}
+
+// memoryStore keeps buckets in memory, so limits apply per replica.
+type memoryStore struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	prunedAt time.Time
+}
+
+func NewMemoryStore() Store {
+	return &memoryStore{
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// pruneInterval is how often buckets that have refilled are dropped.
+const pruneInterval = time.Minute
+
+func (s *memoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.prunedAt) >= pruneInterval {
+		s.pruneLocked(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updatedAt).Seconds()*limit.Rate)
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	b.fullAt = now.Add(time.Duration((float64(limit.Burst) - b.tokens) / limit.Rate * float64(time.Second)))
+	return result(allowed, b.tokens, limit), nil
+}
+
+// pruneLocked drops buckets that have refilled, which are equivalent to a
+// new bucket. s.mu must be held.
+func (s *memoryStore) pruneLocked(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	s.prunedAt = now
+}
@@ -0,0 +1,130 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expected      Limit
+		expectedError bool
+	}{
+		{name: "per second with burst", input: "10/s:20", expected: Limit{Rate: 10, Burst: 20}},
+		{name: "per minute", input: "120/m", expected: Limit{Rate: 2, Burst: 120}},
+		{name: "per hour", input: "3600/h", expected: Limit{Rate: 1, Burst: 3600}},
+		{name: "missing unit", input: "10", expectedError: true},
+		{name: "unknown unit", input: "10/d", expectedError: true},
+		{name: "zero count", input: "0/s", expectedError: true},
+		{name: "invalid burst", input: "10/s:x", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute
+			limit, err := ParseLimit(tt.input)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, limit)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	// Execute
+	limits, err := ParseLimits("20/s", "post /api/v1/match=5/s:10; POST /api/v1/auth/token=1/m;")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, Limit{Rate: 5, Burst: 10}, limits.For("POST", "/api/v1/match"))
+	assert.Equal(t, Limit{Rate: 1.0 / 60, Burst: 1}, limits.For("POST", "/api/v1/auth/token"))
+	assert.Equal(t, Limit{Rate: 20, Burst: 20}, limits.For("POST", "/api/v1/estimate"))
+
+	_, err = ParseLimits("20/s", "/api/v1/match=5/s")
+	assert.Error(t, err)
+}
+
+// testStores returns a memory and a Redis store sharing a clock the test can move.
+func testStores(t *testing.T) (map[string]Store, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	memory := NewMemoryStore().(*memoryStore)
+	memory.now = clock
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	redisStore := NewRedisStore(client, "test:").(redisStore)
+	redisStore.now = clock
+
+	return map[string]Store{"memory": memory, "redis": redisStore}, &now
+}
+
+func TestStore_Take(t *testing.T) {
+	stores, now := testStores(t)
+	limit := Limit{Rate: 2, Burst: 3}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Execute: the burst is allowed, then the bucket is empty
+			for i := range 3 {
+				result, err := store.Take(ctx, "client-a", limit)
+				require.NoError(t, err)
+				assert.True(t, result.Allowed)
+				assert.Equal(t, 2-i, result.Remaining)
+			}
+			rejected, err := store.Take(ctx, "client-a", limit)
+			require.NoError(t, err)
+			other, err := store.Take(ctx, "client-b", limit)
+			require.NoError(t, err)
+
+			*now = now.Add(500 * time.Millisecond)
+			refilled, err := store.Take(ctx, "client-a", limit)
+			require.NoError(t, err)
+
+			// Assert
+			assert.False(t, rejected.Allowed)
+			assert.Equal(t, 500*time.Millisecond, rejected.RetryAfter)
+			assert.True(t, other.Allowed, "buckets are per key")
+			assert.True(t, refilled.Allowed, "one token is refilled after 1/rate seconds")
+		})
+	}
+}
+
+func TestMemoryStore_PrunesRefilledBuckets(t *testing.T) {
+	// Setup
+	store := NewMemoryStore().(*memoryStore)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "fast", Limit{Rate: 10, Burst: 10})
+	require.NoError(t, err)
+	_, err = store.Take(ctx, "slow", Limit{Rate: 1.0 / 3600, Burst: 1})
+	require.NoError(t, err)
+
+	// Execute
+	now = now.Add(2 * pruneInterval)
+	_, err = store.Take(ctx, "other", Limit{Rate: 10, Burst: 10})
+	require.NoError(t, err)
+
+	// Assert
+	assert.NotContains(t, store.buckets, "fast")
+	assert.Contains(t, store.buckets, "slow", "a bucket that has not refilled must be kept")
+}
@@ -0,0 +1,53 @@
+// Package requestid assigns every request an ID that is echoed to the caller,
+// forwarded to downstream services and attached to every log line.
+package requestid
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+const (
+	Header    = "X-Request-ID"
+	MaxLength = 128
+)
+
+// Middleware accepts the X-Request-ID header of the caller, or generates
+// one, and echoes it in the response. The request context carries the ID
+// and a logger annotated with it.
+func Middleware(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = uuid.NewString()
+		}
+
+		c.Header(Header, id)
+
+		ctx := logging.WithRequestID(c.Request.Context(), id)
+		ctx = logging.WithLogger(ctx, logger.With(zap.String("request_id", id)))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// valid accepts IDs of up to 128 printable ASCII characters without
+// spaces, so that caller-supplied values cannot break log lines or headers.
+func valid(id string) bool {
+	return id != "" && len(id) <= MaxLength && PrintableASCII(id)
+}
+
+// PrintableASCII reports whether s only holds printable ASCII characters
+// other than the space.
+func PrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] <= ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
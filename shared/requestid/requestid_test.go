@@ -0,0 +1,75 @@
+package requestid
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/BarkinBalci/bitaksi-case-study/shared/apierror"
+	"github.com/BarkinBalci/bitaksi-case-study/shared/logging"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedHeader string
+	}{
+		{name: "accepts caller ID", header: "abc-123", expectedHeader: "abc-123"},
+		{name: "generates missing ID", header: ""},
+		{name: "replaces ID with spaces", header: "abc 123"},
+		{name: "replaces overlong ID", header: strings.Repeat("a", MaxLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			core, logs := observer.New(zapcore.InfoLevel)
+			router := gin.New()
+			router.Use(Middleware(zap.New(core)))
+
+			var contextID string
+			router.GET("/test", func(c *gin.Context) {
+				contextID = logging.RequestID(c.Request.Context())
+				logging.FromContext(c.Request.Context(), zap.NewNop()).Info("handled")
+				apierror.Respond(c, apierror.ErrMalformedRequest)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.header != "" {
+				req.Header.Set(Header, tt.header)
+			}
+
+			// Execute
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			// Assert
+			id := recorder.Header().Get(Header)
+			if tt.expectedHeader != "" {
+				assert.Equal(t, tt.expectedHeader, id)
+			} else {
+				assert.Len(t, id, 36)
+			}
+			assert.Equal(t, id, contextID)
+
+			var response apierror.ErrorResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.Equal(t, id, response.RequestID)
+
+			require.Equal(t, 1, logs.Len())
+			assert.Equal(t, id, logs.All()[0].ContextMap()["request_id"])
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewMongoMonitor returns a command monitor that records a client span for
+// every MongoDB command, as a child of the span in the context of the operation.
+func NewMongoMonitor() *event.CommandMonitor {
+	tracer := otel.Tracer("github.com/BarkinBalci/bitaksi-case-study/shared/tracing")
+	var spans sync.Map
+
+	finish := func(requestID int64, err error) {
+		value, ok := spans.LoadAndDelete(requestID)
+		if !ok {
+			return
+		}
+		span := value.(trace.Span)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			attrs := []attribute.KeyValue{
+				attribute.String("db.system.name", "mongodb"),
+				attribute.String("db.operation.name", evt.CommandName),
+				attribute.String("db.namespace", evt.DatabaseName),
+			}
+
+			name := evt.CommandName
+			if collection := collectionName(evt.Command); collection != "" {
+				name += " " + collection
+				attrs = append(attrs, attribute.String("db.collection.name", collection))
+			}
+
+			_, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+			spans.Store(evt.RequestID, span)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.RequestID, nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.RequestID, evt.Failure)
+		},
+	}
+}
+
+// collectionName returns the collection a command targets, which MongoDB
+// commands carry as the value of their first element.
+func collectionName(command bson.Raw) string {
+	element, err := command.IndexErr(0)
+	if err != nil {
+		return ""
+	}
+	name, _ := element.Value().StringValueOK()
+	return name
+}
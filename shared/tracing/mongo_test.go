@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/event"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewMongoMonitor(t *testing.T) {
+	// Setup
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	command, err := bson.Marshal(bson.D{{Key: "aggregate", Value: "driver_location"}})
+	require.NoError(t, err)
+	monitor := NewMongoMonitor()
+
+	// Execute
+	monitor.Started(context.Background(), &event.CommandStartedEvent{
+		Command:      command,
+		DatabaseName: "driver_location",
+		CommandName:  "aggregate",
+		RequestID:    1,
+	})
+	monitor.Started(context.Background(), &event.CommandStartedEvent{
+		Command:      command,
+		DatabaseName: "driver_location",
+		CommandName:  "aggregate",
+		RequestID:    2,
+	})
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1},
+	})
+	monitor.Failed(context.Background(), &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2},
+		Failure:              errors.New("index not found"),
+	})
+
+	// Assert
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "aggregate driver_location", spans[0].Name())
+	assert.Contains(t, spans[0].Attributes(), attribute.String("db.collection.name", "driver_location"))
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
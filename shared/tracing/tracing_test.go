@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		name          string
+		exporter      string
+		expectedError bool
+	}{
+		{name: "disabled", exporter: ExporterNone},
+		{name: "stdout", exporter: ExporterStdout},
+		{name: "otlp", exporter: ExporterOTLP},
+		{name: "unknown exporter", exporter: "zipkin", expectedError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			opts := Options{
+				ServiceName:  "test",
+				Exporter:     tt.exporter,
+				OTLPEndpoint: "http://localhost:4318",
+				SampleRatio:  1,
+			}
+
+			// Execute
+			shutdown, err := Setup(context.Background(), opts)
+
+			// Assert
+			if tt.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.NoError(t, shutdown(context.Background()))
+		})
+	}
+}